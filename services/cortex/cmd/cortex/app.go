@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"arc-framework/cortex/internal/api"
+	"arc-framework/cortex/internal/audit"
 	"arc-framework/cortex/internal/clients"
 	"arc-framework/cortex/internal/config"
 	"arc-framework/cortex/internal/orchestrator"
+	"arc-framework/cortex/internal/secrets"
 	"arc-framework/cortex/internal/telemetry"
 
 	"github.com/sony/gobreaker"
@@ -21,15 +24,37 @@ type AppContext struct {
 	otelProvider *telemetry.Provider
 	orchestrator *orchestrator.Orchestrator
 	router       *api.Router
+
+	// nats is kept for operator subcommands (backup/restore) that talk to
+	// JetStream outside the orchestrator.
+	nats *clients.NATSClient
+
+	// pulsar owns the data-plane connection, opened on first use and shared
+	// by the canary probe and the audit worker's consumer. Close releases it
+	// once the worker has stopped.
+	pulsar *clients.PulsarClient
+
+	// pg owns the process-lifetime Postgres pool shared by the probe,
+	// provisioning and the audit store.
+	pg *clients.PostgresClient
+
+	// auditWorker is non-nil when audit.enabled is set.
+	auditWorker *audit.Worker
+
+	// embeddedNATS is non-nil when bootstrap.nats.embedded is set.
+	embeddedNATS *clients.EmbeddedNATS
 }
 
 // buildAppContext constructs all application dependencies from cfg:
 //  1. Initialises the OTEL provider (best-effort, non-fatal)
 //  2. Creates one circuit breaker per client
-//  3. Creates the four infrastructure clients
-//  4. Creates the orchestrator
-//  5. Creates the HTTP router
-func buildAppContext(cfg *config.Config) (*AppContext, error) {
+//  3. Starts the embedded NATS server when configured and startEmbeddedNATS
+//     is set; otherwise NATS clients connect to the one already running
+//  4. Creates the four infrastructure clients
+//  5. Creates the orchestrator
+//  6. Creates the audit worker and its store when enabled
+//  7. Creates the HTTP router
+func buildAppContext(cfg *config.Config, startEmbeddedNATS bool) (*AppContext, error) {
 	app := &AppContext{cfg: cfg}
 
 	// OTEL is best-effort: a missing collector must never block startup.
@@ -62,13 +87,60 @@ func buildAppContext(cfg *config.Config) (*AppContext, error) {
 	pulsarCB := gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: "pulsar"})
 	redisCB := gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: "redis"})
 
+	natsCfg := cfg.Bootstrap.NATS
+	switch {
+	case natsCfg.Embedded && !startEmbeddedNATS:
+		natsCfg.URL = natsCfg.EmbeddedURL()
+	case natsCfg.Embedded:
+		embedded, err := clients.StartEmbeddedNATS(natsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("starting embedded NATS: %w", err)
+		}
+		app.embeddedNATS = embedded
+		natsCfg.URL = embedded.ClientURL()
+		slog.Info("embedded NATS server started", "url", natsCfg.URL, "in_memory", natsCfg.EmbeddedInMemory)
+	}
+
 	pg := clients.NewPostgresClient(cfg.Bootstrap.Postgres, pgCB)
-	nats := clients.NewNATSClient(cfg.Bootstrap.NATS, natsCB)
+	if cfg.OpenBao.Enabled {
+		pg.WithSecrets(secrets.NewOpenBao(cfg.OpenBao), cfg.OpenBao.PostgresPath)
+	}
+	nats := clients.NewNATSClient(natsCfg, natsCB)
 	pulsar := clients.NewPulsarClient(cfg.Bootstrap.Pulsar, pulsarCB)
 	redis := clients.NewRedisClient(cfg.Bootstrap.Redis, redisCB)
 
+	app.pg = pg
+	app.nats = nats
+	app.pulsar = pulsar
 	app.orchestrator = orchestrator.New(pg, nats, pulsar, redis)
-	app.router = api.NewRouter(app.orchestrator)
+
+	var routerOpts []api.RouterOption
+	if cfg.Audit.Enabled {
+		pool, err := pg.Pool(context.Background())
+		if err != nil {
+			app.Close()
+			return nil, fmt.Errorf("opening audit store: %w", err)
+		}
+		store := audit.NewPGStore(pool)
+		app.auditWorker = audit.NewWorker(pulsar, cfg.AuditTopic(), cfg.Audit.Subscription, store)
+		routerOpts = append(routerOpts, api.WithAudit(store))
+	}
+	app.router = api.NewRouter(app.orchestrator, routerOpts...)
 
 	return app, nil
 }
+
+// Close releases process-lifetime resources owned by the AppContext. It is
+// safe to call on a partially built context.
+func (a *AppContext) Close() {
+	if a.pg != nil {
+		a.pg.Close()
+	}
+	if a.pulsar != nil {
+		a.pulsar.Close()
+	}
+	if a.embeddedNATS != nil {
+		a.embeddedNATS.Shutdown()
+		slog.Info("embedded NATS server stopped")
+	}
+}
@@ -27,6 +27,7 @@ success or non-zero on failure.`,
 func runBootstrap(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Bootstrap.Timeout)
 	defer cancel()
+	defer app.Close()
 
 	if app.otelProvider != nil {
 		defer func() {
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"arc-framework/cortex/internal/migrate"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply and inspect SQL schema migrations",
+	Long: `Migrate manages the arc-persistence schema recorded in schema_migrations.
+
+Migrations are read from the platform files embedded in Cortex (unless
+bootstrap.postgres.migrations.embedded is false) and from each
+service=path entry in bootstrap.postgres.migrations.dirs. Each migration
+runs in its own transaction; an advisory lock keeps concurrent runs apart.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply every pending migration",
+	Args:  cobra.NoArgs,
+	RunE: withRunner(func(ctx context.Context, r *migrate.Runner, args []string) (any, error) {
+		return r.Up(ctx)
+	}),
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Revert the n newest applied migrations (default 1)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: withRunner(func(ctx context.Context, r *migrate.Runner, args []string) (any, error) {
+		n := 1
+		if len(args) == 1 {
+			v, err := strconv.Atoi(args[0])
+			if err != nil || v < 1 {
+				return nil, fmt.Errorf("invalid count %q: want a positive integer", args[0])
+			}
+			n = v
+		}
+		return r.Down(ctx, n)
+	}),
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of every known and recorded version",
+	Args:  cobra.NoArgs,
+	RunE: withRunner(func(ctx context.Context, r *migrate.Runner, args []string) (any, error) {
+		return r.Status(ctx)
+	}),
+}
+
+var migrateGotoCmd = &cobra.Command{
+	Use:   "goto <version>",
+	Short: "Apply or revert migrations until the given version is current",
+	Long: `Goto reverts applied migrations above <version>, newest first, then
+applies pending migrations at or below it. Version 0 reverts everything.`,
+	Args: cobra.ExactArgs(1),
+	RunE: withRunner(func(ctx context.Context, r *migrate.Runner, args []string) (any, error) {
+		v, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid version %q", args[0])
+		}
+		return r.Goto(ctx, v)
+	}),
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateGotoCmd)
+}
+
+// withRunner loads the configured migrations, checks out a session of the
+// shared Postgres pool and prints fn's result as JSON.
+func withRunner(fn func(ctx context.Context, r *migrate.Runner, args []string) (any, error)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		defer app.Close()
+
+		sources, err := migrate.Sources(cfg.Bootstrap.Postgres.Migrations)
+		if err != nil {
+			return err
+		}
+		migrations, err := migrate.Load(sources...)
+		if err != nil {
+			return err
+		}
+
+		pool, err := app.pg.Pool(ctx)
+		if err != nil {
+			return err
+		}
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("connecting to postgres: %w", err)
+		}
+		defer conn.Release()
+
+		result, err := fn(ctx, migrate.NewRunner(conn, migrations), args)
+		if err != nil {
+			printResult("error", err.Error())
+			return err
+		}
+		return printJSON(result)
+	}
+}
@@ -53,7 +53,7 @@ func init() {
 			initLogger(cfg.Telemetry.LogLevel)
 		}
 
-		app, err = buildAppContext(cfg)
+		app, err = buildAppContext(cfg, startsEmbeddedNATS(cmd))
 		if err != nil {
 			return fmt.Errorf("building app context: %w", err)
 		}
@@ -63,6 +63,18 @@ func init() {
 
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(bootstrapCmd)
+	rootCmd.AddCommand(natsCmd)
+	rootCmd.AddCommand(pgCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(seedCmd)
+}
+
+// startsEmbeddedNATS reports whether cmd runs the embedded NATS server. The
+// operator subcommands instead connect to the one a running `cortex server`
+// owns, so they neither clash with its port and store dir nor operate on an
+// empty in-process server.
+func startsEmbeddedNATS(cmd *cobra.Command) bool {
+	return cmd == serverCmd || cmd == bootstrapCmd
 }
 
 // Execute is the entry point called by main.
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"arc-framework/cortex/internal/seed"
+
+	"github.com/spf13/cobra"
+)
+
+var seedForce bool
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Load sample data into a fresh environment",
+	Long: `Seed applies declarative seed files: rows for Postgres tables and
+objects for the arc-storage S3 API. Applied seeds are recorded in
+cortex.seeds so each one runs once per environment.
+
+Seeds are read from the sample data embedded in Cortex when
+bootstrap.postgres.seeds.embedded is true, and from each file or directory
+in bootstrap.postgres.seeds.files.`,
+}
+
+var seedApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply every seed not yet applied",
+	Long: `Apply runs each seed in its own transaction. A seed is skipped when it
+was already applied or when any table it writes to already has rows; with
+--force it is applied anyway, rows that conflict with existing ones are
+left alone and objects are overwritten.`,
+	Example: `  CORTEX_BOOTSTRAP_POSTGRES_SEEDS_EMBEDDED=true cortex seed apply
+  cortex seed apply --force`,
+	Args: cobra.NoArgs,
+	RunE: withApplier(func(ctx context.Context, a *seed.Applier, seeds []seed.Seed) (any, error) {
+		return a.Apply(ctx, seeds, seed.Options{Force: seedForce})
+	}),
+}
+
+var seedStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which seeds have been applied",
+	Args:  cobra.NoArgs,
+	RunE: withApplier(func(ctx context.Context, a *seed.Applier, seeds []seed.Seed) (any, error) {
+		return a.Status(ctx, seeds)
+	}),
+}
+
+func init() {
+	seedApplyCmd.Flags().BoolVar(&seedForce, "force", false, "apply seeds that were already applied or whose tables have rows")
+
+	seedCmd.AddCommand(seedApplyCmd)
+	seedCmd.AddCommand(seedStatusCmd)
+}
+
+// withApplier loads the configured seeds, checks out a session of the shared
+// Postgres pool and prints fn's result as JSON.
+func withApplier(fn func(ctx context.Context, a *seed.Applier, seeds []seed.Seed) (any, error)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		defer app.Close()
+
+		seeds, err := seed.Load(cfg.Bootstrap.Postgres.Seeds)
+		if err != nil {
+			return err
+		}
+		objects, err := seed.NewS3Objects(cfg.Storage)
+		if err != nil {
+			return err
+		}
+
+		pool, err := app.pg.Pool(ctx)
+		if err != nil {
+			return err
+		}
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("connecting to postgres: %w", err)
+		}
+		defer conn.Release()
+
+		result, err := fn(ctx, seed.NewApplier(conn, objects), seeds)
+		if err != nil {
+			printResult("error", err.Error())
+			return err
+		}
+		return printJSON(result)
+	}
+}
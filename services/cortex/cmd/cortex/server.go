@@ -26,6 +26,7 @@ telemetry on startup. It shuts down cleanly on SIGTERM or SIGINT.`,
 func runServer(cmd *cobra.Command, args []string) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	defer app.Close()
 
 	if app.otelProvider != nil {
 		defer func() {
@@ -54,6 +55,24 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
+	// The audit worker stops with ctx; wait for it before app.Close releases
+	// its pool.
+	auditDone := make(chan struct{})
+	if app.auditWorker != nil {
+		go func() {
+			defer close(auditDone)
+			if err := app.auditWorker.Run(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("audit worker stopped", "err", err)
+			}
+		}()
+	} else {
+		close(auditDone)
+	}
+	defer func() {
+		stop()
+		<-auditDone
+	}()
+
 	select {
 	case err := <-serverErr:
 		return fmt.Errorf("server error: %w", err)
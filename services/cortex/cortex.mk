@@ -13,7 +13,9 @@ CORTEX_BIN     := bin/cortex
 # These defaults point to localhost equivalents of the Docker service hostnames.
 CORTEX_LOCAL_ENV := \
   CORTEX_BOOTSTRAP_POSTGRES_HOST=localhost \
+  CORTEX_BOOTSTRAP_POSTGRES_MIGRATIONS_DIRS=reasoner=services/reasoner/migrations \
   CORTEX_BOOTSTRAP_NATS_URL=nats://localhost:4222 \
+  CORTEX_BOOTSTRAP_NATS_CONTRACTS=services/reasoner/contracts/asyncapi.yaml,services/voice/contracts/asyncapi.yaml \
   CORTEX_BOOTSTRAP_PULSAR_ADMIN_URL=http://localhost:8080 \
   CORTEX_BOOTSTRAP_REDIS_HOST=localhost \
   CORTEX_TELEMETRY_OTLP_ENDPOINT=
@@ -21,7 +23,7 @@ CORTEX_LOCAL_ENV := \
 # To enable with arc-friday-collector: make cortex-run CORTEX_TELEMETRY_OTLP_ENDPOINT=127.0.0.1:4317
 
 .PHONY: cortex-help cortex-build cortex-build-fresh cortex-push cortex-publish cortex-tag \
-        cortex-bin cortex-run cortex-run-dev cortex-bootstrap-local cortex-test cortex-lint cortex-check \
+        cortex-bin cortex-run cortex-run-dev cortex-run-embedded cortex-bootstrap-local cortex-test cortex-lint cortex-check \
         cortex-docker-up cortex-docker-down cortex-docker-logs cortex-docker-ps cortex-docker-bootstrap \
         cortex-up cortex-down
 
@@ -113,6 +115,11 @@ cortex-run-dev: cortex-bin
 	@printf "  API docs       → http://localhost:8081/api-docs\n"
 	@$(CORTEX_LOCAL_ENV) CORTEX_TELEMETRY_OTLP_ENDPOINT=127.0.0.1:4317 $(CORTEX_BIN) server
 
+## cortex-run-embedded: Start cortex locally with an in-process NATS server (no arc-messaging container needed)
+cortex-run-embedded: cortex-bin
+	@printf "$(COLOR_INFO)→$(COLOR_OFF) Starting cortex server on :8081 (embedded NATS on 127.0.0.1:4222)...\n"
+	@$(CORTEX_LOCAL_ENV) CORTEX_BOOTSTRAP_NATS_EMBEDDED=true $(CORTEX_BIN) server
+
 ## cortex-bootstrap-local: Run one-shot bootstrap against localhost infra and print JSON result
 ## Override any endpoint: CORTEX_BOOTSTRAP_POSTGRES_HOST=myhost make cortex-bootstrap-local
 cortex-bootstrap-local: cortex-bin
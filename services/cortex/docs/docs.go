@@ -15,6 +15,74 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/audit": {
+            "get": {
+                "description": "Lists events copied from the Pulsar audit/command-log topic, ordered by occurrence time. Pass nextCursor from the previous page as cursor to continue.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Query the audit trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events by this actor",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events at or after this RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Page"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameter",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Audit store unavailable",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/bootstrap": {
             "post": {
                 "description": "Starts a bootstrap run in the background. All 4 phases (Postgres, NATS, Pulsar, Redis) run concurrently. Returns 202 immediately; poll /ready or /health/deep to track completion.",
@@ -81,7 +149,7 @@ const docTemplate = `{
         },
         "/health/deep": {
             "get": {
-                "description": "Probes Postgres, NATS, Pulsar, and Redis concurrently. Returns 503 if any probe fails.",
+                "description": "Probes Postgres, NATS, Pulsar, and Redis concurrently. Returns 503 if any probe fails; status is \"degraded\" (200) when all probes pass but one or more crossed a threshold.",
                 "produces": [
                     "application/json"
                 ],
@@ -157,6 +225,57 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "definitions": {
+        "audit.Event": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "messageId": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "occurredAt": {
+                    "type": "string"
+                },
+                "outcome": {
+                    "type": "string"
+                },
+                "receivedAt": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Page": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Event"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                }
+            }
+        }
     }
 }`
 
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"arc-framework/cortex/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// auditQuerier is the subset of audit.Store used by the audit endpoint.
+type auditQuerier interface {
+	List(ctx context.Context, q audit.Query) (audit.Page, error)
+}
+
+// WithAudit registers GET /api/v1/audit backed by q.
+func WithAudit(q auditQuerier) RouterOption {
+	return func(h *Handler) { h.audit = q }
+}
+
+// Audit handles GET /api/v1/audit.
+// It lists audit events oldest first, filtered by actor and start time, and
+// pages with an opaque cursor.
+//
+// @Summary      Query the audit trail
+// @Description  Lists events copied from the Pulsar audit/command-log topic, ordered by occurrence time. Pass nextCursor from the previous page as cursor to continue.
+// @Tags         audit
+// @Produce      json
+// @Param        actor   query     string  false  "Only events by this actor"
+// @Param        since   query     string  false  "Only events at or after this RFC 3339 time"
+// @Param        limit   query     int     false  "Page size (default 100, max 1000)"
+// @Param        cursor  query     string  false  "Cursor from a previous page"
+// @Success      200  {object}  audit.Page
+// @Failure      400  {object}  object{error=string}  "Invalid query parameter"
+// @Failure      500  {object}  object{error=string}  "Audit store unavailable"
+// @Router       /api/v1/audit [get]
+func (h *Handler) Audit(c *gin.Context) {
+	q := audit.Query{
+		Actor:  c.Query("actor"),
+		Cursor: c.Query("cursor"),
+		Limit:  defaultAuditPageSize,
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		q.Since = t
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		q.Limit = n
+	}
+
+	page, err := h.audit.List(c.Request.Context(), q)
+	switch {
+	case errors.Is(err, audit.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, page)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"arc-framework/cortex/internal/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAudit records the last query and returns a fixed page or error.
+type fakeAudit struct {
+	got  audit.Query
+	page audit.Page
+	err  error
+}
+
+func (f *fakeAudit) List(_ context.Context, q audit.Query) (audit.Page, error) {
+	f.got = q
+	return f.page, f.err
+}
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		err       error
+		wantCode  int
+		wantQuery audit.Query
+	}{
+		{
+			name:      "defaults",
+			wantCode:  http.StatusOK,
+			wantQuery: audit.Query{Limit: 100},
+		},
+		{
+			name:      "filters and cursor",
+			query:     "?actor=user:alice&since=2026-10-18T09:30:00Z&limit=25&cursor=abc",
+			wantCode:  http.StatusOK,
+			wantQuery: audit.Query{Actor: "user:alice", Since: at, Limit: 25, Cursor: "abc"},
+		},
+		{name: "bad since", query: "?since=yesterday", wantCode: http.StatusBadRequest},
+		{name: "limit too large", query: "?limit=5000", wantCode: http.StatusBadRequest},
+		{name: "invalid cursor", query: "?cursor=zz", err: audit.ErrInvalidCursor, wantCode: http.StatusBadRequest},
+		{name: "store failure", err: errors.New("connection refused"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &fakeAudit{
+				err:  tc.err,
+				page: audit.Page{Events: []audit.Event{{ID: 1, Actor: "user:alice", OccurredAt: at}}, NextCursor: "next"},
+			}
+			h := &Handler{audit: store}
+			r := newTestEngine(http.MethodGet, "/api/v1/audit", h.Audit)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit"+tc.query, nil))
+
+			assert.Equal(t, tc.wantCode, w.Code)
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, store.got)
+
+			var page audit.Page
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+			assert.Equal(t, "next", page.NextCursor)
+			require.Len(t, page.Events, 1)
+			assert.Equal(t, "user:alice", page.Events[0].Actor)
+		})
+	}
+}
+
+func TestNewRouter_AuditOnlyWhenEnabled(t *testing.T) {
+	t.Parallel()
+
+	req := func(r *Router) int {
+		w := httptest.NewRecorder()
+		r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusNotFound, req(NewRouter(&fakeOrchestrator{})))
+	assert.Equal(t, http.StatusOK, req(NewRouter(&fakeOrchestrator{}, WithAudit(&fakeAudit{}))))
+}
@@ -21,6 +21,8 @@ type orchestratorService interface {
 // Handler holds the dependencies shared across all HTTP handlers.
 type Handler struct {
 	orchestrator orchestratorService
+	// audit is nil unless the audit worker is enabled.
+	audit auditQuerier
 }
 
 // Bootstrap handles POST /api/v1/bootstrap.
@@ -64,9 +66,11 @@ func (h *Handler) Health(c *gin.Context) {
 
 // DeepHealth handles GET /health/deep.
 // It probes all 4 backing services and returns 200 only when every probe is OK.
+// A reachable dependency that crossed a threshold reports "degraded" but still
+// returns 200 so orchestrators do not restart a healthy process.
 //
 // @Summary      Deep dependency health
-// @Description  Probes Postgres, NATS, Pulsar, and Redis concurrently. Returns 503 if any probe fails.
+// @Description  Probes Postgres, NATS, Pulsar, and Redis concurrently. Returns 503 if any probe fails; status is "degraded" (200) when all probes pass but one or more crossed a threshold.
 // @Tags         health
 // @Produce      json
 // @Success      200  {object}  object{status=string,dependencies=object}  "All dependencies healthy"
@@ -76,18 +80,25 @@ func (h *Handler) DeepHealth(c *gin.Context) {
 	probes := h.orchestrator.RunDeepHealth(c.Request.Context())
 
 	allOK := true
+	degraded := false
 	for _, p := range probes {
 		if !p.OK {
 			allOK = false
 			break
 		}
+		if p.Degraded {
+			degraded = true
+		}
 	}
 
 	status := "healthy"
 	code := http.StatusOK
-	if !allOK {
+	switch {
+	case !allOK:
 		status = "unhealthy"
 		code = http.StatusServiceUnavailable
+	case degraded:
+		status = "degraded"
 	}
 
 	c.JSON(code, gin.H{
@@ -211,6 +211,35 @@ func TestDeepHealth_503WhenAllUnhealthy(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 }
 
+func TestDeepHealth_200DegradedWhenThresholdCrossed(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeOrchestrator{
+		deepProbes: map[string]orchestrator.ProbeResult{
+			"postgres": {Name: "postgres", OK: true},
+			"nats": {
+				Name: "nats", OK: true, Degraded: true,
+				Warnings: []string{"jetstream storage at 95% of limit"},
+			},
+			"pulsar": {Name: "pulsar", OK: true},
+			"redis":  {Name: "redis", OK: true},
+		},
+	}
+	handler := &Handler{orchestrator: fake}
+
+	engine := newTestEngine(http.MethodGet, "/health/deep", handler.DeepHealth)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/health/deep", nil)
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "degraded", body["status"])
+}
+
 // --- Ready handler ---
 
 func TestReady_503BeforeBootstrap(t *testing.T) {
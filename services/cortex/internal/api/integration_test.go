@@ -17,10 +17,14 @@ import (
 
 // --- Mock client implementations ---
 
-// mockPGProber immediately returns a successful probe.
-type mockPGProber struct{}
+// mockPGProvisioner immediately succeeds provisioning and probe.
+type mockPGProvisioner struct{}
 
-func (m *mockPGProber) Probe(_ context.Context) orchestrator.ProbeResult {
+func (m *mockPGProvisioner) Provision(_ context.Context) ([]orchestrator.ResourceResult, error) {
+	return nil, nil
+}
+
+func (m *mockPGProvisioner) Probe(_ context.Context) orchestrator.ProbeResult {
 	return orchestrator.ProbeResult{Name: "postgres", OK: true, LatencyMs: 1}
 }
 
@@ -35,7 +39,9 @@ func (m *mockNATSProvisioner) Probe(_ context.Context) orchestrator.ProbeResult
 // mockPulsarProvisioner immediately succeeds provisioning and probe.
 type mockPulsarProvisioner struct{}
 
-func (m *mockPulsarProvisioner) Provision(_ context.Context) error { return nil }
+func (m *mockPulsarProvisioner) Provision(_ context.Context) ([]orchestrator.ResourceResult, error) {
+	return nil, nil
+}
 func (m *mockPulsarProvisioner) Probe(_ context.Context) orchestrator.ProbeResult {
 	return orchestrator.ProbeResult{Name: "pulsar", OK: true, LatencyMs: 1}
 }
@@ -56,7 +62,7 @@ func TestBootstrapFlow_202ThenReady(t *testing.T) {
 	t.Parallel()
 
 	o := orchestrator.New(
-		&mockPGProber{},
+		&mockPGProvisioner{},
 		&mockNATSProvisioner{},
 		&mockPulsarProvisioner{},
 		&mockRedisProber{},
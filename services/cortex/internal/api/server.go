@@ -16,12 +16,15 @@ type Router struct {
 	engine *gin.Engine
 }
 
+// RouterOption enables optional routes on the Router.
+type RouterOption func(h *Handler)
+
 // NewRouter constructs a Router with the full middleware chain and all routes
 // registered. The middleware order follows the spec (FR-3):
 //  1. Recovery — panic → 500
 //  2. FridayOTEL — trace context per request
 //  3. RequestLogger — structured request/response logging
-func NewRouter(o orchestratorService) *Router {
+func NewRouter(o orchestratorService, opts ...RouterOption) *Router {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 
@@ -30,9 +33,15 @@ func NewRouter(o orchestratorService) *Router {
 	engine.Use(RequestLogger(slog.Default()))
 
 	h := &Handler{orchestrator: o}
+	for _, opt := range opts {
+		opt(h)
+	}
 
 	v1 := engine.Group("/api/v1")
 	v1.POST("/bootstrap", h.Bootstrap)
+	if h.audit != nil {
+		v1.GET("/audit", h.Audit)
+	}
 
 	engine.GET("/health", h.Health)
 	engine.GET("/health/deep", h.DeepHealth)
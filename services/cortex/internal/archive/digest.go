@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Digest is an io.Writer that accumulates a SHA-256 checksum and byte count.
+// Tee a blob through it while writing, then record Sum in the manifest.
+type Digest struct {
+	h hash.Hash
+	n int64
+}
+
+// NewDigest returns an empty Digest.
+func NewDigest() *Digest {
+	return &Digest{h: sha256.New()}
+}
+
+func (d *Digest) Write(p []byte) (int, error) {
+	n, _ := d.h.Write(p)
+	d.n += int64(n)
+	return n, nil
+}
+
+// Sum returns the checksum as "sha256:<hex>".
+func (d *Digest) Sum() string {
+	return "sha256:" + hex.EncodeToString(d.h.Sum(nil))
+}
+
+// Size returns the number of bytes written so far.
+func (d *Digest) Size() int64 {
+	return d.n
+}
+
+// Verify reads name from store and compares its checksum with want.
+func Verify(ctx context.Context, store Store, name, want string) error {
+	rc, err := store.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close() //nolint:errcheck
+
+	d := NewDigest()
+	if _, err := io.Copy(d, rc); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	if got := d.Sum(); got != want {
+		return fmt.Errorf("checksum mismatch for %s: manifest %s, archive %s", name, want, got)
+	}
+	return nil
+}
+
+// WriteJSON encodes v as indented JSON into name.
+func WriteJSON(ctx context.Context, store Store, name string, v any) error {
+	w, err := store.Create(ctx, name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		w.Abort(err)
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadJSON decodes name into v.
+func ReadJSON(ctx context.Context, store Store, name string, v any) error {
+	rc, err := store.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close() //nolint:errcheck
+
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Package archive provides the backup targets Cortex writes snapshots to: a
+// local directory, a single tar or tar.gz file, or a bucket prefix on the
+// arc-storage S3 API.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// Store is a flat namespace of named blobs. Names use forward slashes and are
+// relative to the location the Store was opened at.
+type Store interface {
+	// Create returns a writer for name. The blob is only guaranteed to be
+	// persisted once Close returns nil.
+	Create(ctx context.Context, name string) (Writer, error)
+	// Open returns a reader for an existing blob.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Close finishes the location once every blob is written; for a tar
+	// archive it writes the end-of-archive marker and moves the file into
+	// place.
+	Close() error
+	// Abort abandons a run that failed part-way; for a tar archive it
+	// discards the partial file. It is a no-op after a successful Close.
+	Abort(err error)
+	// String describes the location for logs and error messages.
+	String() string
+}
+
+// Writer is a blob being written. Close commits it; Abort discards it and
+// leaves any earlier blob of the same name in place. Exactly one of the two
+// must be called.
+type Writer interface {
+	io.WriteCloser
+	Abort(err error)
+}
+
+// Open resolves location to a Store. "s3://bucket/prefix" selects the object
+// store described by cfg, a path ending in .tar, .tar.gz or .tgz a single
+// archive file, and anything else a filesystem directory.
+func Open(location string, cfg config.StorageConfig) (Store, error) {
+	if rest, ok := strings.CutPrefix(location, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid s3 location %q: missing bucket", location)
+		}
+		return newS3Store(cfg, bucket, strings.Trim(prefix, "/"))
+	}
+	if location == "" {
+		return nil, fmt.Errorf("empty archive location")
+	}
+	if ok, compressed := isTarPath(location); ok {
+		return &tarStore{path: location, gzip: compressed}, nil
+	}
+	return &dirStore{root: location}, nil
+}
+
+// dirStore stores blobs as files below root.
+type dirStore struct {
+	root string
+}
+
+func (d *dirStore) Create(_ context.Context, name string) (Writer, error) {
+	p := filepath.Join(d.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return nil, fmt.Errorf("creating directory for %s: %w", p, err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", p, err)
+	}
+	return &fileWriter{f: f, path: p}, nil
+}
+
+func (d *dirStore) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	p := filepath.Join(d.root, filepath.FromSlash(name))
+	f, err := os.Open(p) //nolint:gosec // path is operator-supplied
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", p, err)
+	}
+	return f, nil
+}
+
+func (d *dirStore) Close() error { return nil }
+
+func (d *dirStore) Abort(error) {}
+
+// fileWriter writes to a temporary file beside path and renames it into
+// place on Close, so a failed write never replaces an existing blob.
+type fileWriter struct {
+	f    *os.File
+	path string
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) { return w.f.Write(p) }
+
+func (w *fileWriter) Close() error {
+	if err := w.f.Close(); err != nil {
+		os.Remove(w.f.Name()) //nolint:errcheck,gosec
+		return fmt.Errorf("writing %s: %w", w.path, err)
+	}
+	if err := os.Rename(w.f.Name(), w.path); err != nil {
+		os.Remove(w.f.Name()) //nolint:errcheck,gosec
+		return fmt.Errorf("writing %s: %w", w.path, err)
+	}
+	return nil
+}
+
+func (w *fileWriter) Abort(error) {
+	w.f.Close()           //nolint:errcheck,gosec
+	os.Remove(w.f.Name()) //nolint:errcheck,gosec
+}
+
+func (d *dirStore) String() string { return d.root }
+
+// s3Store stores blobs as objects under bucket/prefix. put and get are
+// function fields so tests can replace the network calls.
+type s3Store struct {
+	bucket string
+	prefix string
+	put    func(ctx context.Context, key string, r io.Reader) error
+	get    func(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+func newS3Store(cfg config.StorageConfig, bucket, prefix string) (*s3Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating s3 client for %s: %w", cfg.Endpoint, err)
+	}
+
+	return &s3Store{
+		bucket: bucket,
+		prefix: prefix,
+		put: func(ctx context.Context, key string, r io.Reader) error {
+			_, err := client.PutObject(ctx, bucket, key, r, -1, minio.PutObjectOptions{
+				ContentType: "application/octet-stream",
+			})
+			return err
+		},
+		get: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			obj, err := client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+			if err != nil {
+				return nil, err
+			}
+			// GetObject is lazy; Stat surfaces a missing key before the
+			// caller starts reading.
+			if _, err := obj.Stat(); err != nil {
+				obj.Close() //nolint:errcheck,gosec
+				return nil, err
+			}
+			return obj, nil
+		},
+	}, nil
+}
+
+func (s *s3Store) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return path.Join(s.prefix, name)
+}
+
+// Create streams writes into a background PutObject through a pipe. Close
+// waits for the upload and returns its error; Abort fails the pipe so the
+// upload is abandoned instead of committing a partial object.
+func (s *s3Store) Create(ctx context.Context, name string) (Writer, error) {
+	pr, pw := io.Pipe()
+	w := &s3Writer{pw: pw, done: make(chan error, 1)}
+	key := s.key(name)
+
+	go func() {
+		err := s.put(ctx, key, pr)
+		if err != nil {
+			err = fmt.Errorf("uploading s3://%s/%s: %w", s.bucket, key, err)
+		}
+		pr.CloseWithError(err) //nolint:errcheck,gosec
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+func (s *s3Store) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	key := s.key(name)
+	rc, err := s.get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("opening s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return rc, nil
+}
+
+func (s *s3Store) Close() error { return nil }
+
+func (s *s3Store) Abort(error) {}
+
+func (s *s3Store) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+type s3Writer struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) { return w.pw.Write(p) }
+
+func (w *s3Writer) Close() error {
+	if err := w.pw.Close(); err != nil {
+		return err
+	}
+	return <-w.done
+}
+
+func (w *s3Writer) Abort(err error) {
+	if err == nil {
+		err = errors.New("upload aborted")
+	}
+	w.pw.CloseWithError(err) //nolint:errcheck,gosec
+	<-w.done
+}
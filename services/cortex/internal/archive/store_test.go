@@ -0,0 +1,250 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// memS3 replaces the network calls of an s3Store with an in-memory map.
+type memS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	putErr  error
+}
+
+func newMemS3Store(m *memS3, prefix string) *s3Store {
+	return &s3Store{
+		bucket: "backups",
+		prefix: prefix,
+		put: func(_ context.Context, key string, r io.Reader) error {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if m.putErr != nil {
+				return m.putErr
+			}
+			m.mu.Lock()
+			m.objects[key] = data
+			m.mu.Unlock()
+			return nil
+		},
+		get: func(_ context.Context, key string) (io.ReadCloser, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			data, ok := m.objects[key]
+			if !ok {
+				return nil, errors.New("NoSuchKey")
+			}
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+}
+
+func TestOpen_ResolvesLocation(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.StorageConfig{Endpoint: "arc-storage:9000", AccessKey: "arc", SecretKey: "x"}
+
+	dir, err := Open("/var/backups/cortex", cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &dirStore{}, dir)
+
+	s3, err := Open("s3://cortex-backups/nats/dev/", cfg)
+	require.NoError(t, err)
+	require.IsType(t, &s3Store{}, s3)
+	assert.Equal(t, "nats/dev", s3.(*s3Store).prefix)
+	assert.Equal(t, "s3://cortex-backups/nats/dev", s3.String())
+
+	_, err = Open("s3:///nats", cfg)
+	assert.ErrorContains(t, err, "missing bucket")
+
+	for location, compressed := range map[string]bool{
+		"./backups/reasoner.tar":    false,
+		"./backups/reasoner.tar.gz": true,
+		"./backups/reasoner.tgz":    true,
+	} {
+		store, err := Open(location, cfg)
+		require.NoError(t, err)
+		require.IsType(t, &tarStore{}, store, location)
+		assert.Equal(t, compressed, store.(*tarStore).gzip, location)
+	}
+
+	_, err = Open("", cfg)
+	assert.Error(t, err)
+}
+
+func TestTarStore_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"backup.tar", "backup.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "nested", name)
+			store, err := Open(path, config.StorageConfig{})
+			require.NoError(t, err)
+
+			d := NewDigest()
+			w, err := store.Create(ctx, "reasoner/data/documents.copy")
+			require.NoError(t, err)
+			_, err = io.MultiWriter(w, d).Write([]byte("1\tfirst\n"))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			require.NoError(t, WriteJSON(ctx, store, "reasoner/manifest.json", map[string]int{"rows": 1}))
+
+			_, err = store.Open(ctx, "reasoner/manifest.json")
+			assert.ErrorContains(t, err, "being written")
+			require.NoError(t, store.Close())
+			require.NoError(t, store.Close())
+
+			store, err = Open(path, config.StorageConfig{})
+			require.NoError(t, err)
+			var manifest map[string]int
+			require.NoError(t, ReadJSON(ctx, store, "reasoner/manifest.json", &manifest))
+			assert.Equal(t, map[string]int{"rows": 1}, manifest)
+			require.NoError(t, Verify(ctx, store, "reasoner/data/documents.copy", d.Sum()))
+
+			_, err = store.Open(ctx, "reasoner/missing.copy")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			require.NoError(t, store.Close())
+		})
+	}
+}
+
+func TestDirStore_RoundTripAndVerify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := Open(t.TempDir(), config.StorageConfig{})
+	require.NoError(t, err)
+
+	d := NewDigest()
+	w, err := store.Create(ctx, "AGENT_EVENTS/messages.jsonl")
+	require.NoError(t, err)
+	_, err = io.MultiWriter(w, d).Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, int64(6), d.Size())
+	require.NoError(t, Verify(ctx, store, "AGENT_EVENTS/messages.jsonl", d.Sum()))
+
+	err = Verify(ctx, store, "AGENT_EVENTS/messages.jsonl", "sha256:deadbeef")
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestDirStore_AbortKeepsPreviousBlob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := Open(dir, config.StorageConfig{})
+	require.NoError(t, err)
+	require.NoError(t, WriteJSON(ctx, store, "reasoner/manifest.json", map[string]int{"rows": 1}))
+
+	w, err := store.Create(ctx, "reasoner/manifest.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("{\"rows\":"))
+	require.NoError(t, err)
+	w.Abort(errors.New("copy failed"))
+
+	var out map[string]int
+	require.NoError(t, ReadJSON(ctx, store, "reasoner/manifest.json", &out))
+	assert.Equal(t, map[string]int{"rows": 1}, out)
+	entries, err := os.ReadDir(filepath.Join(dir, "reasoner"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file left behind")
+}
+
+func TestS3Store_AbortDoesNotCommit(t *testing.T) {
+	t.Parallel()
+
+	mem := &memS3{objects: map[string][]byte{"nats/AGENT_EVENTS/messages.jsonl": []byte("old\n")}}
+	store := newMemS3Store(mem, "nats")
+
+	w, err := store.Create(context.Background(), "AGENT_EVENTS/messages.jsonl")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("partial"))
+	require.NoError(t, err)
+	w.Abort(errors.New("stream read failed"))
+
+	assert.Equal(t, []byte("old\n"), mem.objects["nats/AGENT_EVENTS/messages.jsonl"])
+}
+
+func TestTarStore_AbortKeepsPreviousArchive(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reasoner.tar.gz")
+	store, err := Open(path, config.StorageConfig{})
+	require.NoError(t, err)
+	require.NoError(t, WriteJSON(ctx, store, "reasoner/manifest.json", map[string]int{"rows": 1}))
+	require.NoError(t, store.Close())
+	good, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	store, err = Open(path, config.StorageConfig{})
+	require.NoError(t, err)
+	w, err := store.Create(ctx, "reasoner/data/documents.copy")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("1\tpartial\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	store.Abort(errors.New("copy failed"))
+	require.NoError(t, store.Close(), "Close after Abort is a no-op")
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, good, after)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file left behind")
+}
+
+func TestS3Store_RoundTripJSON(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	mem := &memS3{objects: map[string][]byte{}}
+	store := newMemS3Store(mem, "nats")
+
+	in := map[string]int{"messages": 3}
+	require.NoError(t, WriteJSON(ctx, store, "AGENT_EVENTS/manifest.json", in))
+	assert.Contains(t, mem.objects, "nats/AGENT_EVENTS/manifest.json")
+
+	var out map[string]int
+	require.NoError(t, ReadJSON(ctx, store, "AGENT_EVENTS/manifest.json", &out))
+	assert.Equal(t, in, out)
+
+	_, err := store.Open(ctx, "missing.json")
+	assert.ErrorContains(t, err, "s3://backups/nats/missing.json")
+}
+
+func TestS3Store_UploadErrorSurfacesOnClose(t *testing.T) {
+	t.Parallel()
+
+	mem := &memS3{objects: map[string][]byte{}, putErr: errors.New("AccessDenied")}
+	store := newMemS3Store(mem, "")
+
+	w, err := store.Create(context.Background(), "manifest.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("{}"))
+	require.NoError(t, err)
+
+	err = w.Close()
+	assert.ErrorContains(t, err, "AccessDenied")
+}
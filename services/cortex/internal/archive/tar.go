@@ -0,0 +1,218 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// isTarPath reports whether location names a single-file tar archive and
+// whether it is gzip-compressed.
+func isTarPath(location string) (ok, compressed bool) {
+	switch {
+	case strings.HasSuffix(location, ".tar.gz"), strings.HasSuffix(location, ".tgz"):
+		return true, true
+	case strings.HasSuffix(location, ".tar"):
+		return true, false
+	}
+	return false, false
+}
+
+// tarStore packs blobs into one tar file, gzip-compressed when gzip is set.
+// A tar header carries the entry size, so Create spools each blob to a
+// temporary file and appends it on Close. The archive itself is written to a
+// temporary file beside path: Close writes the end-of-archive marker and
+// renames it into place, Abort discards it, so a failed run never replaces an
+// existing archive. Open scans the file from the start, so a tarStore is
+// used either for writing or for reading, not both.
+type tarStore struct {
+	path string
+	gzip bool
+
+	mu sync.Mutex
+	f  *os.File
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (s *tarStore) Create(_ context.Context, name string) (Writer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tw == nil {
+		if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+			return nil, fmt.Errorf("creating directory for %s: %w", s.path, err)
+		}
+		f, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*.tmp")
+		if err != nil {
+			return nil, fmt.Errorf("creating %s: %w", s.path, err)
+		}
+		s.f = f
+		var w io.Writer = f
+		if s.gzip {
+			s.gz = gzip.NewWriter(f)
+			w = s.gz
+		}
+		s.tw = tar.NewWriter(w)
+	}
+
+	spool, err := os.CreateTemp("", "cortex-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("spooling %s: %w", name, err)
+	}
+	return &tarEntry{store: s, name: name, spool: spool}, nil
+}
+
+func (s *tarStore) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	writing := s.tw != nil
+	s.mu.Unlock()
+	if writing {
+		return nil, fmt.Errorf("opening %s in %s: archive is being written", name, s.path)
+	}
+
+	f, err := os.Open(s.path) //nolint:gosec // path is operator-supplied
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", s.path, err)
+	}
+	rc := &tarReader{closers: []io.Closer{f}}
+	var r io.Reader = f
+	if s.gzip {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			rc.Close() //nolint:errcheck,gosec
+			return nil, fmt.Errorf("reading %s: %w", s.path, err)
+		}
+		rc.closers = append(rc.closers, gz)
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			rc.Close() //nolint:errcheck,gosec
+			return nil, fmt.Errorf("opening %s in %s: %w", name, s.path, fs.ErrNotExist)
+		}
+		if err != nil {
+			rc.Close() //nolint:errcheck,gosec
+			return nil, fmt.Errorf("reading %s: %w", s.path, err)
+		}
+		if hdr.Name == name && hdr.Typeflag == tar.TypeReg {
+			rc.Reader = tr
+			return rc, nil
+		}
+	}
+}
+
+// Close finishes an archive that was written to and moves it into place. It
+// is a no-op for one that was only read, and safe to call more than once.
+func (s *tarStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tw == nil {
+		return nil
+	}
+	tmp := s.f.Name()
+	errs := []error{s.tw.Close()}
+	if s.gz != nil {
+		errs = append(errs, s.gz.Close())
+	}
+	errs = append(errs, s.f.Close())
+	s.tw, s.gz, s.f = nil, nil, nil
+	if err := errors.Join(errs...); err != nil {
+		os.Remove(tmp) //nolint:errcheck,gosec
+		return fmt.Errorf("closing %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp) //nolint:errcheck,gosec
+		return fmt.Errorf("closing %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Abort discards an archive being written, leaving any earlier one at path
+// untouched. It is a no-op once Close has returned.
+func (s *tarStore) Abort(error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tw == nil {
+		return
+	}
+	s.f.Close()           //nolint:errcheck,gosec
+	os.Remove(s.f.Name()) //nolint:errcheck,gosec
+	s.tw, s.gz, s.f = nil, nil, nil
+}
+
+func (s *tarStore) String() string { return s.path }
+
+// tarEntry spools one blob and appends it to the archive on Close.
+type tarEntry struct {
+	store *tarStore
+	name  string
+	spool *os.File
+}
+
+func (e *tarEntry) Write(p []byte) (int, error) { return e.spool.Write(p) }
+
+func (e *tarEntry) Close() error {
+	defer os.Remove(e.spool.Name()) //nolint:errcheck
+	defer e.spool.Close()           //nolint:errcheck
+
+	size, err := e.spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("spooling %s: %w", e.name, err)
+	}
+	if _, err := e.spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("spooling %s: %w", e.name, err)
+	}
+
+	s := e.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tw == nil {
+		return fmt.Errorf("writing %s to %s: archive is closed", e.name, s.path)
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     e.name,
+		Mode:     0o640,
+		Size:     size,
+		ModTime:  time.Now().UTC(),
+	}
+	if err := s.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing %s to %s: %w", e.name, s.path, err)
+	}
+	if _, err := io.Copy(s.tw, e.spool); err != nil {
+		return fmt.Errorf("writing %s to %s: %w", e.name, s.path, err)
+	}
+	return nil
+}
+
+// Abort drops the spooled blob without appending it.
+func (e *tarEntry) Abort(error) {
+	e.spool.Close()           //nolint:errcheck,gosec
+	os.Remove(e.spool.Name()) //nolint:errcheck,gosec
+}
+
+// tarReader reads one entry and closes the decompressor and file under it.
+type tarReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *tarReader) Close() error {
+	var errs []error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		errs = append(errs, r.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
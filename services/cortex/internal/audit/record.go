@@ -0,0 +1,80 @@
+// Package audit copies records from the Pulsar audit/command-log topic into
+// the append-only audit.events table and serves queries over it.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxFieldLen bounds the indexed text columns so a malformed producer cannot
+// bloat the indexes.
+const maxFieldLen = 256
+
+// Record is the JSON payload published to the audit topic. Actor, action and
+// occurred_at are required; metadata, when present, must be an object or null.
+type Record struct {
+	EventID    string          `json:"event_id,omitempty"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	Resource   string          `json:"resource,omitempty"`
+	Outcome    string          `json:"outcome,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
+}
+
+// Event is a stored record. MessageID is the Pulsar message ID it was read
+// from; ReceivedAt is when Cortex wrote it.
+type Event struct {
+	ID         int64           `json:"id"`
+	MessageID  string          `json:"messageId"`
+	EventID    string          `json:"eventId,omitempty"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	Resource   string          `json:"resource,omitempty"`
+	Outcome    string          `json:"outcome,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	ReceivedAt time.Time       `json:"receivedAt"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
+}
+
+// ParseRecord decodes and validates a message payload. Unknown fields are
+// rejected so producer typos surface instead of being silently dropped.
+func ParseRecord(payload []byte) (Record, error) {
+	var r Record
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&r); err != nil {
+		return Record{}, fmt.Errorf("decoding record: %w", err)
+	}
+	return r, r.Validate()
+}
+
+// Validate reports every problem with r at once.
+func (r Record) Validate() error {
+	var errs []error
+	required := []struct{ name, value string }{{"actor", r.Actor}, {"action", r.Action}}
+	for _, f := range required {
+		if f.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", f.name))
+		}
+	}
+	for _, f := range []struct{ name, value string }{
+		{"event_id", r.EventID}, {"actor", r.Actor}, {"action", r.Action},
+		{"resource", r.Resource}, {"outcome", r.Outcome},
+	} {
+		if len(f.value) > maxFieldLen {
+			errs = append(errs, fmt.Errorf("%s exceeds %d bytes", f.name, maxFieldLen))
+		}
+	}
+	if r.OccurredAt.IsZero() {
+		errs = append(errs, errors.New("occurred_at is required"))
+	}
+	if meta := bytes.TrimSpace(r.Metadata); len(meta) > 0 && meta[0] != '{' && !bytes.Equal(meta, []byte("null")) {
+		errs = append(errs, errors.New("metadata must be an object"))
+	}
+	return errors.Join(errs...)
+}
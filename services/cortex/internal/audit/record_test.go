@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr []string
+	}{
+		{
+			name:    "valid",
+			payload: `{"event_id":"e-1","actor":"user:alice","action":"agent.create","resource":"agent/42","outcome":"success","occurred_at":"2026-10-18T09:30:00Z","metadata":{"ip":"10.0.0.1"}}`,
+		},
+		{
+			name:    "null metadata",
+			payload: `{"actor":"user:alice","action":"agent.create","occurred_at":"2026-10-18T09:30:00Z","metadata":null}`,
+		},
+		{
+			name:    "missing required fields",
+			payload: `{"resource":"agent/42"}`,
+			wantErr: []string{"actor is required", "action is required", "occurred_at is required"},
+		},
+		{
+			name:    "metadata not an object",
+			payload: `{"actor":"a","action":"b","occurred_at":"2026-10-18T09:30:00Z","metadata":["x"]}`,
+			wantErr: []string{"metadata must be an object"},
+		},
+		{
+			name:    "oversized actor",
+			payload: `{"actor":"` + strings.Repeat("a", 300) + `","action":"b","occurred_at":"2026-10-18T09:30:00Z"}`,
+			wantErr: []string{"actor exceeds 256 bytes"},
+		},
+		{
+			name:    "unknown field",
+			payload: `{"actor":"a","action":"b","occurred_at":"2026-10-18T09:30:00Z","user":"typo"}`,
+			wantErr: []string{`unknown field "user"`},
+		},
+		{
+			name:    "bad timestamp",
+			payload: `{"actor":"a","action":"b","occurred_at":"yesterday"}`,
+			wantErr: []string{"decoding record"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := ParseRecord([]byte(tc.payload))
+			if len(tc.wantErr) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC), r.OccurredAt)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tc.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
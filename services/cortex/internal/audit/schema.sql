@@ -0,0 +1,46 @@
+-- audit.events is the append-only copy of the Pulsar audit/command-log topic.
+-- message_id is the Pulsar message ID and makes redelivery idempotent.
+CREATE SCHEMA IF NOT EXISTS audit;
+
+CREATE TABLE IF NOT EXISTS audit.events (
+    id          BIGSERIAL PRIMARY KEY,
+    message_id  TEXT        NOT NULL UNIQUE,
+    event_id    TEXT        NOT NULL DEFAULT '',
+    actor       TEXT        NOT NULL,
+    action      TEXT        NOT NULL,
+    resource    TEXT        NOT NULL DEFAULT '',
+    outcome     TEXT        NOT NULL DEFAULT '',
+    occurred_at TIMESTAMPTZ NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    metadata    JSONB       NOT NULL DEFAULT '{}'::jsonb
+);
+
+-- audit.rejected is the dead-letter table for records that failed
+-- validation; payload is kept verbatim so they can be fixed and replayed.
+CREATE TABLE IF NOT EXISTS audit.rejected (
+    id          BIGSERIAL PRIMARY KEY,
+    message_id  TEXT        NOT NULL UNIQUE,
+    payload     BYTEA       NOT NULL,
+    reason      TEXT        NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS events_actor_occurred_at_idx ON audit.events (actor, occurred_at, id);
+CREATE INDEX IF NOT EXISTS events_occurred_at_idx ON audit.events (occurred_at, id);
+
+CREATE OR REPLACE FUNCTION audit.reject_mutation() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'audit.events is append-only';
+END;
+$$;
+
+DROP TRIGGER IF EXISTS events_append_only ON audit.events;
+CREATE TRIGGER events_append_only
+    BEFORE UPDATE OR DELETE ON audit.events
+    FOR EACH ROW EXECUTE FUNCTION audit.reject_mutation();
+
+DROP TRIGGER IF EXISTS events_no_truncate ON audit.events;
+CREATE TRIGGER events_no_truncate
+    BEFORE TRUNCATE ON audit.events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit.reject_mutation();
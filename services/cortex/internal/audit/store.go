@@ -0,0 +1,205 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed schema.sql
+var schemaSQL string
+
+// Query selects events for the audit endpoint. Results are ordered by
+// occurred_at then id; Cursor resumes after the last event of a previous
+// page.
+type Query struct {
+	Actor  string
+	Since  time.Time
+	Limit  int
+	Cursor string
+}
+
+// Page is one page of events. NextCursor is empty on the last page.
+type Page struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+// Store persists and lists audit events.
+type Store interface {
+	// EnsureSchema creates the audit schema, table and append-only triggers
+	// if they do not exist.
+	EnsureSchema(ctx context.Context) error
+	// Insert writes r under messageID. It reports false when an event with
+	// that message ID already exists.
+	Insert(ctx context.Context, messageID string, r Record) (bool, error)
+	// Reject dead-letters a payload that is not a valid record, keeping the
+	// first reason when messageID is redelivered.
+	Reject(ctx context.Context, messageID string, payload []byte, reason string) error
+	List(ctx context.Context, q Query) (Page, error)
+}
+
+// pgxConn is the subset of *pgxpool.Pool used by PGStore.
+type pgxConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// PGStore is the Postgres-backed Store.
+type PGStore struct {
+	db pgxConn
+}
+
+// NewPGStore returns a Store over db, typically a *pgxpool.Pool.
+func NewPGStore(db pgxConn) *PGStore {
+	return &PGStore{db: db}
+}
+
+func (s *PGStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx, schemaSQL); err != nil {
+		return fmt.Errorf("creating audit schema: %w", err)
+	}
+	return nil
+}
+
+func (s *PGStore) Insert(ctx context.Context, messageID string, r Record) (bool, error) {
+	var metadata any
+	if meta := bytes.TrimSpace(r.Metadata); len(meta) > 0 && !bytes.Equal(meta, []byte("null")) {
+		metadata = string(meta)
+	}
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO audit.events (message_id, event_id, actor, action, resource, outcome, occurred_at, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::jsonb, '{}'::jsonb))
+		ON CONFLICT (message_id) DO NOTHING`,
+		messageID, r.EventID, r.Actor, r.Action, r.Resource, r.Outcome, r.OccurredAt, metadata,
+	)
+	if err != nil {
+		return false, fmt.Errorf("inserting audit event %s: %w", messageID, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *PGStore) Reject(ctx context.Context, messageID string, payload []byte, reason string) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO audit.rejected (message_id, payload, reason) VALUES ($1, $2, $3)
+		ON CONFLICT (message_id) DO NOTHING`,
+		messageID, payload, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("dead-lettering audit record %s: %w", messageID, err)
+	}
+	return nil
+}
+
+func (s *PGStore) List(ctx context.Context, q Query) (Page, error) {
+	sql, args, err := buildListQuery(q)
+	if err != nil {
+		return Page{}, err
+	}
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("querying audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var metadata []byte
+		if err := rows.Scan(&e.ID, &e.MessageID, &e.EventID, &e.Actor, &e.Action, &e.Resource,
+			&e.Outcome, &e.OccurredAt, &e.ReceivedAt, &metadata); err != nil {
+			return Page{}, fmt.Errorf("scanning audit event: %w", err)
+		}
+		if !bytes.Equal(metadata, []byte("{}")) {
+			e.Metadata = metadata
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, fmt.Errorf("reading audit events: %w", err)
+	}
+	return paginate(events, q.Limit), nil
+}
+
+// buildListQuery renders the keyset query for q. One extra row is fetched to
+// tell whether another page follows.
+func buildListQuery(q Query) (string, []any, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if q.Actor != "" {
+		where = append(where, "actor = "+arg(q.Actor))
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "occurred_at >= "+arg(q.Since))
+	}
+	if q.Cursor != "" {
+		at, id, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, fmt.Sprintf("(occurred_at, id) > (%s, %s)", arg(at), arg(id)))
+	}
+
+	sql := "SELECT id, message_id, event_id, actor, action, resource, outcome, occurred_at, received_at, metadata FROM audit.events"
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	sql += " ORDER BY occurred_at, id LIMIT " + arg(q.Limit+1)
+	return sql, args, nil
+}
+
+// paginate trims the look-ahead row and sets the cursor when it was present.
+func paginate(events []Event, limit int) Page {
+	page := Page{Events: events}
+	if page.Events == nil {
+		page.Events = []Event{}
+	}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = encodeCursor(last.OccurredAt, last.ID)
+	}
+	return page
+}
+
+// ErrInvalidCursor is returned by List for a cursor it did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func encodeCursor(at time.Time, id int64) string {
+	raw := strconv.FormatInt(at.UnixMicro(), 10) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	micros, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.UnixMicro(us).UTC(), id, nil
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildListQuery(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2026, 10, 18, 9, 30, 0, 123456000, time.UTC)
+
+	sql, args, err := buildListQuery(Query{Limit: 50})
+	require.NoError(t, err)
+	assert.Contains(t, sql, "FROM audit.events ORDER BY occurred_at, id LIMIT $1")
+	assert.Equal(t, []any{51}, args)
+
+	sql, args, err = buildListQuery(Query{Actor: "user:alice", Since: since, Cursor: encodeCursor(at, 7), Limit: 10})
+	require.NoError(t, err)
+	assert.Contains(t, sql, "WHERE actor = $1 AND occurred_at >= $2 AND (occurred_at, id) > ($3, $4) ORDER BY occurred_at, id LIMIT $5")
+	assert.Equal(t, []any{"user:alice", since, at, int64(7), 11}, args)
+
+	_, _, err = buildListQuery(Query{Cursor: "not-a-cursor", Limit: 10})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	events := []Event{
+		{ID: 1, OccurredAt: at},
+		{ID: 2, OccurredAt: at.Add(time.Second)},
+		{ID: 3, OccurredAt: at.Add(2 * time.Second)},
+	}
+
+	page := paginate(events, 2)
+	require.Len(t, page.Events, 2)
+	gotAt, gotID, err := decodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, at.Add(time.Second), gotAt)
+	assert.Equal(t, int64(2), gotID)
+
+	page = paginate(events, 3)
+	assert.Len(t, page.Events, 3)
+	assert.Empty(t, page.NextCursor)
+
+	page = paginate(nil, 3)
+	assert.NotNil(t, page.Events, "an empty page encodes as []")
+}
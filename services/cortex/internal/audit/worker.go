@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+// consumer is the subset of pulsar.Consumer used by the Worker.
+type consumer interface {
+	Receive(ctx context.Context) (pulsar.Message, error)
+	Ack(msg pulsar.Message) error
+	Nack(msg pulsar.Message)
+	Close()
+}
+
+// Worker consumes the audit topic and writes every valid record to the
+// Store. Invalid records are dead-lettered to audit.rejected and acknowledged
+// so they cannot block the subscription; store failures are negatively
+// acknowledged and redelivered.
+type Worker struct {
+	subscribe func() (consumer, error)
+	store     Store
+	// retry is the pause before retrying the schema setup or the
+	// subscription while Postgres or Pulsar is unavailable.
+	retry time.Duration
+}
+
+// Subscriber opens Pulsar consumers; *clients.PulsarClient implements it.
+type Subscriber interface {
+	Subscribe(opts pulsar.ConsumerOptions) (pulsar.Consumer, error)
+}
+
+// NewWorker returns a Worker reading topic through a durable failover
+// subscription, so one replica consumes at a time and a new subscription
+// starts from the oldest retained record.
+func NewWorker(sub Subscriber, topic, subscription string, store Store) *Worker {
+	return &Worker{
+		subscribe: func() (consumer, error) {
+			return sub.Subscribe(pulsar.ConsumerOptions{
+				Topic:                       topic,
+				SubscriptionName:            subscription,
+				Type:                        pulsar.Failover,
+				SubscriptionInitialPosition: pulsar.SubscriptionPositionEarliest,
+			})
+		},
+		store: store,
+		retry: 5 * time.Second,
+	}
+}
+
+// Run ensures the schema exists, subscribes, and consumes until ctx is
+// cancelled. Startup steps are retried so the worker can start before
+// bootstrap has provisioned its dependencies, and a subscription that fails
+// to receive is closed and reopened the same way.
+func (w *Worker) Run(ctx context.Context) error {
+	if err := w.retryUntil(ctx, "audit schema", w.store.EnsureSchema); err != nil {
+		return err
+	}
+
+	for {
+		var c consumer
+		err := w.retryUntil(ctx, "audit subscription", func(context.Context) error {
+			var err error
+			c, err = w.subscribe()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		slog.Info("audit worker subscribed")
+		err = w.consume(ctx, c)
+		c.Close()
+		if ctx.Err() != nil {
+			return nil
+		}
+		slog.Warn("audit subscription failed, resubscribing", "err", err, "retry_in", w.retry)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.retry):
+		}
+	}
+}
+
+// consume handles messages from c until Receive fails.
+func (w *Worker) consume(ctx context.Context, c consumer) error {
+	for {
+		msg, err := c.Receive(ctx)
+		if err != nil {
+			return fmt.Errorf("receiving audit record: %w", err)
+		}
+		if err := w.handle(ctx, messageID(msg.ID()), msg.Payload()); err != nil {
+			slog.Warn("audit record not stored, will be redelivered", "message_id", messageID(msg.ID()), "err", err)
+			c.Nack(msg)
+			continue
+		}
+		if err := c.Ack(msg); err != nil {
+			slog.Warn("acknowledging audit record", "message_id", messageID(msg.ID()), "err", err)
+		}
+	}
+}
+
+// handle validates and stores one message. It returns an error only when the
+// message should be redelivered.
+func (w *Worker) handle(ctx context.Context, id string, payload []byte) error {
+	record, err := ParseRecord(payload)
+	if err != nil {
+		slog.Warn("rejecting invalid audit record", "message_id", id, "err", err)
+		return w.store.Reject(ctx, id, payload, err.Error())
+	}
+	inserted, err := w.store.Insert(ctx, id, record)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		slog.Debug("duplicate audit record skipped", "message_id", id)
+	}
+	return nil
+}
+
+func (w *Worker) retryUntil(ctx context.Context, what string, fn func(context.Context) error) error {
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		slog.Warn(what+" not ready, retrying", "err", err, "retry_in", w.retry)
+		select {
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), err)
+		case <-time.After(w.retry):
+		}
+	}
+}
+
+// messageID renders a Pulsar message ID as the dedup key. MessageID.String
+// omits the batch index, which would collapse batched messages into one.
+func messageID(id pulsar.MessageID) string {
+	return fmt.Sprintf("%d:%d:%d:%d", id.LedgerID(), id.EntryID(), id.PartitionIdx(), id.BatchIdx())
+}
@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMessage overrides the two pulsar.Message methods the worker reads.
+type fakeMessage struct {
+	pulsar.Message
+	id      pulsar.MessageID
+	payload []byte
+}
+
+func (m fakeMessage) ID() pulsar.MessageID { return m.id }
+func (m fakeMessage) Payload() []byte      { return m.payload }
+
+// fakeConsumer replays msgs, then blocks until ctx is cancelled. A non-nil
+// receiveErr is returned by every Receive instead.
+type fakeConsumer struct {
+	msgs       chan pulsar.Message
+	receiveErr error
+	mu         sync.Mutex
+	acked      []string
+	nacked     []string
+	closed     bool
+}
+
+func (c *fakeConsumer) Receive(ctx context.Context) (pulsar.Message, error) {
+	if c.receiveErr != nil {
+		return nil, c.receiveErr
+	}
+	select {
+	case m := <-c.msgs:
+		return m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *fakeConsumer) Ack(m pulsar.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.acked = append(c.acked, messageID(m.ID()))
+	return nil
+}
+
+func (c *fakeConsumer) Nack(m pulsar.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nacked = append(c.nacked, messageID(m.ID()))
+}
+
+func (c *fakeConsumer) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+// fakeStore records inserts in memory, deduplicating by message ID.
+type fakeStore struct {
+	mu        sync.Mutex
+	schemaErr error
+	insertErr error
+	rejectErr error
+	events    map[string]Record
+	rejected  map[string]string
+}
+
+func (s *fakeStore) EnsureSchema(context.Context) error { return s.schemaErr }
+
+func (s *fakeStore) Insert(_ context.Context, id string, r Record) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.insertErr != nil {
+		return false, s.insertErr
+	}
+	if _, ok := s.events[id]; ok {
+		return false, nil
+	}
+	s.events[id] = r
+	return true, nil
+}
+
+func (s *fakeStore) Reject(_ context.Context, id string, _ []byte, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rejectErr != nil {
+		return s.rejectErr
+	}
+	if _, ok := s.rejected[id]; !ok {
+		s.rejected[id] = reason
+	}
+	return nil
+}
+
+func (s *fakeStore) List(context.Context, Query) (Page, error) { return Page{}, nil }
+
+const validPayload = `{"actor":"user:alice","action":"agent.create","occurred_at":"2026-10-18T09:30:00Z"}`
+
+func TestWorker_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		msgs         []fakeMessage
+		insertErr    error
+		rejectErr    error
+		wantStored   []string
+		wantRejected []string
+		wantAcked    []string
+		wantNacked   []string
+	}{
+		{
+			name: "stores valid records and skips duplicates",
+			msgs: []fakeMessage{
+				{id: pulsar.NewMessageID(5, 1, 0, 0), payload: []byte(validPayload)},
+				{id: pulsar.NewMessageID(5, 1, 1, 0), payload: []byte(validPayload)},
+				{id: pulsar.NewMessageID(5, 1, 0, 0), payload: []byte(validPayload)},
+			},
+			wantStored: []string{"5:1:0:0", "5:1:0:1"},
+			wantAcked:  []string{"5:1:0:0", "5:1:0:1", "5:1:0:0"},
+		},
+		{
+			name:         "dead-letters and acknowledges invalid records",
+			msgs:         []fakeMessage{{id: pulsar.NewMessageID(5, 2, 0, 0), payload: []byte(`{"actor":""}`)}},
+			wantRejected: []string{"5:2:0:0"},
+			wantAcked:    []string{"5:2:0:0"},
+		},
+		{
+			name:       "redelivers invalid records when dead-lettering fails",
+			msgs:       []fakeMessage{{id: pulsar.NewMessageID(5, 4, 0, 0), payload: []byte(`{"actor":""}`)}},
+			rejectErr:  errors.New("connection refused"),
+			wantNacked: []string{"5:4:0:0"},
+		},
+		{
+			name:       "redelivers on store failure",
+			msgs:       []fakeMessage{{id: pulsar.NewMessageID(5, 3, 0, 0), payload: []byte(validPayload)}},
+			insertErr:  errors.New("connection refused"),
+			wantNacked: []string{"5:3:0:0"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &fakeConsumer{msgs: make(chan pulsar.Message, len(tc.msgs))}
+			for _, m := range tc.msgs {
+				c.msgs <- m
+			}
+			store := &fakeStore{events: map[string]Record{}, rejected: map[string]string{},
+				insertErr: tc.insertErr, rejectErr: tc.rejectErr}
+			w := &Worker{subscribe: func() (consumer, error) { return c, nil }, store: store, retry: time.Millisecond}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- w.Run(ctx) }()
+
+			require.Eventually(t, func() bool {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+				return len(c.acked)+len(c.nacked) == len(tc.msgs)
+			}, time.Second, time.Millisecond)
+			cancel()
+			require.NoError(t, <-done)
+
+			var stored []string
+			for id := range store.events {
+				stored = append(stored, id)
+			}
+			assert.ElementsMatch(t, tc.wantStored, stored)
+			var rejected []string
+			for id := range store.rejected {
+				rejected = append(rejected, id)
+			}
+			assert.ElementsMatch(t, tc.wantRejected, rejected)
+			assert.Equal(t, tc.wantAcked, c.acked)
+			assert.Equal(t, tc.wantNacked, c.nacked)
+		})
+	}
+}
+
+func TestWorker_RetriesStartup(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	c := &fakeConsumer{msgs: make(chan pulsar.Message)}
+	w := &Worker{
+		subscribe: func() (consumer, error) {
+			attempts++
+			if attempts < 3 {
+				return nil, errors.New("topic not found")
+			}
+			return c, nil
+		},
+		store: &fakeStore{},
+		retry: time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.NoError(t, w.Run(ctx))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestWorker_ResubscribesAfterReceiveError(t *testing.T) {
+	t.Parallel()
+
+	broken := &fakeConsumer{receiveErr: errors.New("consumer closed")}
+	healthy := &fakeConsumer{msgs: make(chan pulsar.Message, 1)}
+	healthy.msgs <- fakeMessage{id: pulsar.NewMessageID(6, 1, 0, 0), payload: []byte(validPayload)}
+
+	var mu sync.Mutex
+	consumers := []*fakeConsumer{broken, healthy}
+	w := &Worker{
+		subscribe: func() (consumer, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			c := consumers[0]
+			consumers = consumers[1:]
+			return c, nil
+		},
+		store: &fakeStore{events: map[string]Record{}},
+		retry: time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		healthy.mu.Lock()
+		defer healthy.mu.Unlock()
+		return len(healthy.acked) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.True(t, broken.closed)
+}
+
+func TestWorker_StopsWhileWaitingForSchema(t *testing.T) {
+	t.Parallel()
+
+	w := &Worker{store: &fakeStore{schemaErr: errors.New("connection refused")}, retry: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := w.Run(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/sony/gobreaker"
 
 	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/contracts"
 	"arc-framework/cortex/internal/orchestrator"
 )
 
@@ -52,39 +53,144 @@ type jsContext interface {
 	StreamInfo(stream string, opts ...nats.JSOpt) (*nats.StreamInfo, error)
 	AddStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
 	UpdateStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+	AccountInfo(opts ...nats.JSOpt) (*nats.AccountInfo, error)
+	ConsumersInfo(stream string, opts ...nats.JSOpt) <-chan *nats.ConsumerInfo
+}
+
+// jetStreamHealth is the Details payload of the NATS deep-health probe.
+type jetStreamHealth struct {
+	Account jetStreamAccount `json:"account"`
+	Streams []streamHealth   `json:"streams"`
+}
+
+// jetStreamAccount reports account-level usage. A limit of -1 means the
+// account is unlimited for that resource.
+type jetStreamAccount struct {
+	MemoryUsed   uint64 `json:"memoryUsed"`
+	MemoryLimit  int64  `json:"memoryLimit"`
+	StorageUsed  uint64 `json:"storageUsed"`
+	StorageLimit int64  `json:"storageLimit"`
+	Streams      int    `json:"streams"`
+	Consumers    int    `json:"consumers"`
+}
+
+// streamHealth reports per-stream counts for one of the planned streams.
+type streamHealth struct {
+	Name                string           `json:"name"`
+	Messages            uint64           `json:"messages"`
+	Bytes               uint64           `json:"bytes"`
+	OldestMessageAgeSec float64          `json:"oldestMessageAgeSec"`
+	Consumers           []consumerHealth `json:"consumers,omitempty"`
+}
+
+// consumerHealth reports how far behind a single consumer is.
+type consumerHealth struct {
+	Name        string `json:"name"`
+	NumPending  uint64 `json:"numPending"`
+	AckPending  int    `json:"ackPending"`
+	Redelivered int    `json:"redelivered"`
 }
 
 // NATSClient manages JetStream stream provisioning and health probing for the
 // arc-messaging (NATS) dependency.
 type NATSClient struct {
-	url    string
-	cb     *gobreaker.CircuitBreaker
-	newJS  func(url string) (jsContext, func(), error)
+	url          string
+	opts         []nats.Option
+	health       config.NATSHealthConfig
+	contracts    []string
+	coreSubjects []string
+	storage      nats.StorageType
+	cb           *gobreaker.CircuitBreaker
+	newJS        func(url string, opts ...nats.Option) (jsContext, func(), error)
+	now          func() time.Time
 }
 
 // NewNATSClient constructs a NATSClient. No connection is made at construction
 // time; connections are opened lazily inside ProvisionStreams and Probe.
 func NewNATSClient(cfg config.NATSConfig, cb *gobreaker.CircuitBreaker) *NATSClient {
 	return &NATSClient{
-		url:   cfg.URL,
-		cb:    cb,
-		newJS: realNewJS,
+		url:          cfg.URL,
+		opts:         natsOptions(cfg),
+		health:       cfg.Health,
+		contracts:    cfg.Contracts,
+		coreSubjects: cfg.CoreSubjects,
+		storage:      streamStorage(cfg),
+		cb:           cb,
+		newJS:        realNewJS,
+		now:          time.Now,
+	}
+}
+
+// streamStorage picks the JetStream storage backend for provisioned streams.
+// Memory storage is only used for an embedded server that asked for it.
+func streamStorage(cfg config.NATSConfig) nats.StorageType {
+	if cfg.Embedded && cfg.EmbeddedInMemory {
+		return nats.MemoryStorage
+	}
+	return nats.FileStorage
+}
+
+// natsOptions translates the auth and TLS settings in cfg into nats.Options.
+// Options that read files (nkey seed, TLS material) defer the read until
+// connect time so a bad path surfaces as a connection error, not a panic at
+// startup.
+func natsOptions(cfg config.NATSConfig) []nats.Option {
+	var opts []nats.Option
+
+	switch {
+	case cfg.CredsFile != "":
+		opts = append(opts, nats.UserCredentials(cfg.CredsFile))
+	case cfg.NKeySeedFile != "":
+		seedFile := cfg.NKeySeedFile
+		opts = append(opts, func(o *nats.Options) error {
+			opt, err := nats.NkeyOptionFromSeed(seedFile)
+			if err != nil {
+				return fmt.Errorf("loading nkey seed %s: %w", seedFile, err)
+			}
+			return opt(o)
+		})
+	case cfg.Token != "":
+		opts = append(opts, nats.Token(cfg.Token))
+	case cfg.User != "":
+		opts = append(opts, nats.UserInfo(cfg.User, cfg.Password))
 	}
+
+	if cfg.TLS.Enabled() {
+		tlsSettings := cfg.TLS
+		opts = append(opts, func(o *nats.Options) error {
+			tlsCfg, err := buildTLSConfig(tlsSettings)
+			if err != nil {
+				return fmt.Errorf("nats tls: %w", err)
+			}
+			o.Secure = true
+			o.TLSConfig = tlsCfg
+			return nil
+		})
+	}
+
+	return opts
 }
 
 // ProvisionStreams connects to NATS JetStream and creates or updates the three
-// required streams. It is idempotent: existing streams are updated rather than
-// errored. The entire operation is wrapped in the circuit breaker.
+// required streams plus any stream the configured AsyncAPI contracts declare.
+// It is idempotent: existing streams are updated rather than errored. The
+// stream plan is built and validated first; a plan error is a configuration
+// problem, so it is returned without touching NATS or the circuit breaker.
 func (c *NATSClient) ProvisionStreams(ctx context.Context) error {
-	_, err := c.cb.Execute(func() (any, error) {
-		js, cleanup, err := c.newJS(c.url)
+	streams, err := c.streamPlan()
+	if err != nil {
+		return fmt.Errorf("stream plan: %w", err)
+	}
+
+	_, err = c.cb.Execute(func() (any, error) {
+		js, cleanup, err := c.newJS(c.url, c.opts...)
 		if err != nil {
 			return nil, fmt.Errorf("connecting to NATS: %w", err)
 		}
 		defer cleanup()
 
-		for _, spec := range requiredStreams {
-			if err := provisionStream(js, spec); err != nil {
+		for _, spec := range streams {
+			if err := provisionStream(js, spec, c.storage); err != nil {
 				return nil, err
 			}
 		}
@@ -100,25 +206,42 @@ func (c *NATSClient) ProvisionStreams(ctx context.Context) error {
 	return nil
 }
 
-// Probe verifies NATS connectivity and returns a ProbeResult. A missing stream
-// is not treated as a failure — NATS being reachable is what matters here.
+// streamPlan loads the configured AsyncAPI contracts, adds the streams they
+// declare to requiredStreams and validates the result against them.
+func (c *NATSClient) streamPlan() ([]streamSpec, error) {
+	channels, err := contracts.LoadAll(c.contracts)
+	if err != nil {
+		return nil, err
+	}
+	streams, err := buildStreamPlan(requiredStreams, channels)
+	if err != nil {
+		return nil, err
+	}
+	return streams, checkStreamPlan(streams, channels, c.coreSubjects)
+}
+
+// Probe verifies NATS connectivity and reports JetStream account usage,
+// per-stream counts and per-consumer lag. A missing stream is not treated as
+// a failure — NATS being reachable is what matters here. Crossing one of the
+// configured health thresholds marks the result degraded rather than failed,
+// as does a stream plan that cannot be built, in which case only the required
+// streams are reported.
 func (c *NATSClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 	start := time.Now()
 
-	_, err := c.cb.Execute(func() (any, error) {
-		js, cleanup, err := c.newJS(c.url)
+	streams, planErr := c.streamPlan()
+	if planErr != nil {
+		streams = requiredStreams
+	}
+
+	out, err := c.cb.Execute(func() (any, error) {
+		js, cleanup, err := c.newJS(c.url, c.opts...)
 		if err != nil {
 			return nil, fmt.Errorf("connecting to NATS: %w", err)
 		}
 		defer cleanup()
 
-		// A missing stream means NATS is up but streams haven't been
-		// provisioned yet — that's fine for a health check.
-		_, infoErr := js.StreamInfo(requiredStreams[0].name)
-		if infoErr != nil && !errors.Is(infoErr, nats.ErrStreamNotFound) {
-			return nil, fmt.Errorf("stream info: %w", infoErr)
-		}
-		return nil, nil
+		return c.collectHealth(js, streams)
 	})
 
 	latency := time.Since(start).Milliseconds()
@@ -136,21 +259,122 @@ func (c *NATSClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 		}
 	}
 
+	health := out.(*jetStreamHealth)
+	warnings := c.evaluateHealth(health)
+	if planErr != nil {
+		warnings = append(warnings, "stream plan: "+planErr.Error())
+	}
+
 	return orchestrator.ProbeResult{
 		Name:      natsProbeNameConst,
 		OK:        true,
+		Degraded:  len(warnings) > 0,
 		LatencyMs: latency,
+		Warnings:  warnings,
+		Details:   health,
+	}
+}
+
+// collectHealth gathers account, stream and consumer state for streams.
+// Streams that have not been provisioned yet are skipped.
+func (c *NATSClient) collectHealth(js jsContext, streams []streamSpec) (*jetStreamHealth, error) {
+	acct, err := js.AccountInfo()
+	if err != nil {
+		return nil, fmt.Errorf("account info: %w", err)
+	}
+
+	health := &jetStreamHealth{
+		Account: jetStreamAccount{
+			MemoryUsed:   acct.Memory,
+			MemoryLimit:  acct.Limits.MaxMemory,
+			StorageUsed:  acct.Store,
+			StorageLimit: acct.Limits.MaxStore,
+			Streams:      acct.Streams,
+			Consumers:    acct.Consumers,
+		},
+	}
+
+	for _, spec := range streams {
+		info, infoErr := js.StreamInfo(spec.name)
+		if errors.Is(infoErr, nats.ErrStreamNotFound) {
+			continue
+		}
+		if infoErr != nil {
+			return nil, fmt.Errorf("stream info %s: %w", spec.name, infoErr)
+		}
+
+		sh := streamHealth{
+			Name:     spec.name,
+			Messages: info.State.Msgs,
+			Bytes:    info.State.Bytes,
+		}
+		if info.State.Msgs > 0 && !info.State.FirstTime.IsZero() {
+			sh.OldestMessageAgeSec = c.now().Sub(info.State.FirstTime).Seconds()
+		}
+
+		for ci := range js.ConsumersInfo(spec.name) {
+			sh.Consumers = append(sh.Consumers, consumerHealth{
+				Name:        ci.Name,
+				NumPending:  ci.NumPending,
+				AckPending:  ci.NumAckPending,
+				Redelivered: ci.NumRedelivered,
+			})
+		}
+
+		health.Streams = append(health.Streams, sh)
+	}
+
+	return health, nil
+}
+
+// evaluateHealth compares the collected state against the configured
+// thresholds and returns one warning per threshold crossed.
+func (c *NATSClient) evaluateHealth(h *jetStreamHealth) []string {
+	var warnings []string
+
+	if ratio, ok := usageRatio(h.Account.StorageUsed, h.Account.StorageLimit); ok &&
+		c.health.MaxStorageRatio > 0 && ratio >= c.health.MaxStorageRatio {
+		warnings = append(warnings, fmt.Sprintf("jetstream storage at %.0f%% of account limit", ratio*100))
+	}
+	if ratio, ok := usageRatio(h.Account.MemoryUsed, h.Account.MemoryLimit); ok &&
+		c.health.MaxMemoryRatio > 0 && ratio >= c.health.MaxMemoryRatio {
+		warnings = append(warnings, fmt.Sprintf("jetstream memory at %.0f%% of account limit", ratio*100))
+	}
+
+	for _, s := range h.Streams {
+		if c.health.MaxMessageAge > 0 && s.OldestMessageAgeSec > c.health.MaxMessageAge.Seconds() {
+			warnings = append(warnings, fmt.Sprintf("stream %s oldest message is %s old",
+				s.Name, (time.Duration(s.OldestMessageAgeSec)*time.Second).String()))
+		}
+		for _, cons := range s.Consumers {
+			if c.health.MaxConsumerPending > 0 && cons.NumPending > c.health.MaxConsumerPending {
+				warnings = append(warnings, fmt.Sprintf("consumer %s/%s has %d pending messages",
+					s.Name, cons.Name, cons.NumPending))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// usageRatio returns used/limit. ok is false when the account has no limit
+// for the resource (limit <= 0), in which case there is nothing to compare.
+func usageRatio(used uint64, limit int64) (float64, bool) {
+	if limit <= 0 {
+		return 0, false
 	}
+	return float64(used) / float64(limit), true
 }
 
 // provisionStream creates the stream if it does not exist, or updates it if it
 // does. nats.ErrStreamNotFound signals "create"; any other error is returned.
-func provisionStream(js jsContext, spec streamSpec) error {
+func provisionStream(js jsContext, spec streamSpec, storage nats.StorageType) error {
 	cfg := &nats.StreamConfig{
 		Name:      spec.name,
 		Subjects:  spec.subjects,
 		Retention: spec.retention,
 		MaxAge:    spec.maxAge,
+		Storage:   storage,
 	}
 
 	_, err := js.StreamInfo(spec.name)
@@ -171,8 +395,8 @@ func provisionStream(js jsContext, spec streamSpec) error {
 
 // realNewJS opens a real NATS connection and returns a JetStreamContext plus a
 // cleanup function that drains and closes the connection.
-func realNewJS(url string) (jsContext, func(), error) {
-	nc, err := nats.Connect(url)
+func realNewJS(url string, opts ...nats.Option) (jsContext, func(), error) {
+	nc, err := nats.Connect(url, opts...)
 	if err != nil {
 		return nil, func() {}, fmt.Errorf("nats connect %s: %w", url, err)
 	}
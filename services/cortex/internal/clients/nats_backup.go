@@ -0,0 +1,335 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"arc-framework/cortex/internal/archive"
+)
+
+const (
+	// streamBackupFormat is bumped whenever the archive layout changes.
+	streamBackupFormat = 1
+
+	backupManifestFile = "manifest.json"
+	backupMessagesFile = "messages.jsonl"
+
+	// Restored messages carry their original sequence and timestamp so
+	// consumers can correlate them even when sequences could not be kept.
+	headerOriginalSeq  = "Cortex-Original-Sequence"
+	headerOriginalTime = "Cortex-Original-Time"
+)
+
+// jsBackupContext extends jsContext with the calls needed to read messages
+// by sequence and to rebuild a stream. nats.JetStreamContext satisfies it.
+type jsBackupContext interface {
+	jsContext
+	GetMsg(name string, seq uint64, opts ...nats.JSOpt) (*nats.RawStreamMsg, error)
+	PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error)
+	AddConsumer(stream string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+	DeleteStream(name string, opts ...nats.JSOpt) error
+	PurgeStream(name string, opts ...nats.JSOpt) error
+}
+
+// StreamBackupManifest describes a stream snapshot. It is stored next to the
+// message log as manifest.json and checked before anything is restored.
+type StreamBackupManifest struct {
+	Format    int                   `json:"format"`
+	Stream    string                `json:"stream"`
+	CreatedAt time.Time             `json:"createdAt"`
+	Config    nats.StreamConfig     `json:"config"`
+	Consumers []nats.ConsumerConfig `json:"consumers,omitempty"`
+	FirstSeq  uint64                `json:"firstSeq"`
+	LastSeq   uint64                `json:"lastSeq"`
+	Messages  uint64                `json:"messages"`
+	Bytes     int64                 `json:"bytes"`
+	Checksum  string                `json:"checksum"`
+}
+
+// StreamRestoreResult summarises a RestoreStream run. SequencesPreserved is
+// false when the backup had gaps (deleted messages), in which case restored
+// messages were renumbered and only the Cortex-Original-Sequence header
+// carries the original value. Skipped counts messages of an interest-based
+// stream that no restored consumer was interested in; the server would have
+// discarded them, so they are not published.
+type StreamRestoreResult struct {
+	Stream             string `json:"stream"`
+	Messages           uint64 `json:"messages"`
+	Skipped            uint64 `json:"skipped,omitempty"`
+	Consumers          int    `json:"consumers"`
+	SequencesPreserved bool   `json:"sequencesPreserved"`
+}
+
+// RestoreOptions controls RestoreStream.
+type RestoreOptions struct {
+	// Replace deletes an existing stream of the same name before restoring.
+	Replace bool
+}
+
+// ArchiveProgress is called after each message with the number processed so
+// far and the total expected.
+type ArchiveProgress func(done, total uint64)
+
+// backupMessage is one line of messages.jsonl.
+type backupMessage struct {
+	Seq     uint64      `json:"seq"`
+	Subject string      `json:"subject"`
+	Time    time.Time   `json:"time"`
+	Header  nats.Header `json:"header,omitempty"`
+	Data    []byte      `json:"data"`
+}
+
+// BackupStream writes the configuration, durable consumer configurations and
+// every message of stream into store under "<stream>/". Messages are read by
+// sequence with the direct get API, so no consumer is created on the stream
+// and interest-based retention is not disturbed.
+func (c *NATSClient) BackupStream(ctx context.Context, stream string, store archive.Store, progress ArchiveProgress) (*StreamBackupManifest, error) {
+	js, cleanup, err := c.backupJS()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		return nil, fmt.Errorf("stream info %s: %w", stream, err)
+	}
+
+	manifest := &StreamBackupManifest{
+		Format:    streamBackupFormat,
+		Stream:    stream,
+		CreatedAt: c.now().UTC(),
+		Config:    info.Config,
+	}
+	for ci := range js.ConsumersInfo(stream) {
+		if ci.Config.Durable != "" {
+			manifest.Consumers = append(manifest.Consumers, ci.Config)
+		}
+	}
+
+	w, err := store.Create(ctx, stream+"/"+backupMessagesFile)
+	if err != nil {
+		return nil, err
+	}
+	digest := archive.NewDigest()
+	bw := bufio.NewWriter(io.MultiWriter(w, digest))
+	enc := json.NewEncoder(bw)
+
+	total := info.State.Msgs
+	if info.State.Msgs > 0 {
+		for seq := info.State.FirstSeq; seq <= info.State.LastSeq; seq++ {
+			if err := ctx.Err(); err != nil {
+				w.Abort(err)
+				return nil, err
+			}
+
+			raw, err := js.GetMsg(stream, seq)
+			if errors.Is(err, nats.ErrMsgNotFound) {
+				continue // deleted or expired since StreamInfo
+			}
+			if err != nil {
+				w.Abort(err)
+				return nil, fmt.Errorf("reading %s seq %d: %w", stream, seq, err)
+			}
+
+			if err := enc.Encode(backupMessage{
+				Seq:     raw.Sequence,
+				Subject: raw.Subject,
+				Time:    raw.Time,
+				Header:  raw.Header,
+				Data:    raw.Data,
+			}); err != nil {
+				w.Abort(err)
+				return nil, fmt.Errorf("encoding %s seq %d: %w", stream, seq, err)
+			}
+
+			if manifest.Messages == 0 {
+				manifest.FirstSeq = raw.Sequence
+			}
+			manifest.LastSeq = raw.Sequence
+			manifest.Messages++
+			if progress != nil {
+				progress(manifest.Messages, total)
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		w.Abort(err)
+		return nil, fmt.Errorf("writing %s messages: %w", stream, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("writing %s messages: %w", stream, err)
+	}
+
+	manifest.Bytes = digest.Size()
+	manifest.Checksum = digest.Sum()
+
+	if err := archive.WriteJSON(ctx, store, stream+"/"+backupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// RestoreStream recreates stream from a backup written by BackupStream. The
+// message log checksum is verified before the stream is touched. Durable
+// consumers are recreated before messages are published so interest-based
+// streams retain them; messages of such a stream that none of them would
+// receive are skipped and counted in Skipped instead.
+func (c *NATSClient) RestoreStream(ctx context.Context, stream string, store archive.Store, opts RestoreOptions, progress ArchiveProgress) (*StreamRestoreResult, error) {
+	var manifest StreamBackupManifest
+	if err := archive.ReadJSON(ctx, store, stream+"/"+backupManifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != streamBackupFormat {
+		return nil, fmt.Errorf("unsupported backup format %d (want %d)", manifest.Format, streamBackupFormat)
+	}
+	if manifest.Stream != stream || manifest.Config.Name != stream {
+		return nil, fmt.Errorf("backup at %s is for stream %q, not %q", store, manifest.Stream, stream)
+	}
+	if err := archive.Verify(ctx, store, stream+"/"+backupMessagesFile, manifest.Checksum); err != nil {
+		return nil, err
+	}
+
+	js, cleanup, err := c.backupJS()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	_, err = js.StreamInfo(stream)
+	switch {
+	case err == nil && !opts.Replace:
+		return nil, fmt.Errorf("stream %s already exists; restore with replace to overwrite it", stream)
+	case err == nil:
+		if err := js.DeleteStream(stream); err != nil {
+			return nil, fmt.Errorf("deleting stream %s: %w", stream, err)
+		}
+	case !errors.Is(err, nats.ErrStreamNotFound):
+		return nil, fmt.Errorf("stream info %s: %w", stream, err)
+	}
+
+	cfg := manifest.Config
+	if _, err := js.AddStream(&cfg); err != nil {
+		return nil, fmt.Errorf("creating stream %s: %w", stream, err)
+	}
+	// Purging up to FirstSeq on the empty stream makes the next publish land
+	// on the original first sequence.
+	if manifest.FirstSeq > 1 {
+		if err := js.PurgeStream(stream, &nats.StreamPurgeRequest{Sequence: manifest.FirstSeq}); err != nil {
+			return nil, fmt.Errorf("setting first sequence of %s: %w", stream, err)
+		}
+	}
+	for i := range manifest.Consumers {
+		if _, err := js.AddConsumer(stream, &manifest.Consumers[i]); err != nil {
+			return nil, fmt.Errorf("creating consumer %s on %s: %w", manifest.Consumers[i].Durable, stream, err)
+		}
+	}
+
+	rc, err := store.Open(ctx, stream+"/"+backupMessagesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck
+
+	result := &StreamRestoreResult{
+		Stream:             stream,
+		Consumers:          len(manifest.Consumers),
+		SequencesPreserved: true,
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(rc))
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var m backupMessage
+		if err := dec.Decode(&m); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decoding message %d: %w", result.Messages+1, err)
+		}
+
+		if cfg.Retention == nats.InterestPolicy && !consumersInterested(manifest.Consumers, m.Subject) {
+			result.Skipped++
+			if progress != nil {
+				progress(result.Messages+result.Skipped, manifest.Messages)
+			}
+			continue
+		}
+
+		ack, err := js.PublishMsg(restoreMsg(m))
+		if err != nil {
+			return nil, fmt.Errorf("publishing seq %d to %s: %w", m.Seq, m.Subject, err)
+		}
+		if ack.Sequence != m.Seq {
+			result.SequencesPreserved = false
+		}
+
+		result.Messages++
+		if progress != nil {
+			progress(result.Messages+result.Skipped, manifest.Messages)
+		}
+	}
+
+	if result.Messages+result.Skipped != manifest.Messages {
+		return nil, fmt.Errorf("restored %d messages but manifest lists %d", result.Messages+result.Skipped, manifest.Messages)
+	}
+	return result, nil
+}
+
+// consumersInterested reports whether any consumer's filter matches subject,
+// i.e. whether an interest-based stream would keep a message published to it.
+func consumersInterested(consumers []nats.ConsumerConfig, subject string) bool {
+	for _, cc := range consumers {
+		filters := cc.FilterSubjects
+		if cc.FilterSubject != "" {
+			filters = append(filters, cc.FilterSubject)
+		}
+		if len(filters) == 0 || subjectCoveredBy(subject, filters) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupJS opens a JetStream connection that supports the backup calls.
+func (c *NATSClient) backupJS() (jsBackupContext, func(), error) {
+	js, cleanup, err := c.newJS(c.url, c.opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	bjs, ok := js.(jsBackupContext)
+	if !ok {
+		cleanup()
+		return nil, nil, errors.New("JetStream context does not support backup operations")
+	}
+	return bjs, cleanup, nil
+}
+
+// restoreMsg rebuilds a publishable message from a backup line. Server-side
+// expectation headers are dropped because they only made sense against the
+// original stream state.
+func restoreMsg(m backupMessage) *nats.Msg {
+	msg := nats.NewMsg(m.Subject)
+	msg.Data = m.Data
+	for k, v := range m.Header {
+		if strings.HasPrefix(k, "Nats-Expected-") {
+			continue
+		}
+		msg.Header[k] = v
+	}
+	msg.Header.Set(headerOriginalSeq, strconv.FormatUint(m.Seq, 10))
+	msg.Header.Set(headerOriginalTime, m.Time.UTC().Format(time.RFC3339Nano))
+	return msg
+}
@@ -0,0 +1,191 @@
+package clients
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/archive"
+	"arc-framework/cortex/internal/config"
+)
+
+// seedStream provisions the required streams on an embedded server and
+// publishes n messages to AGENT_COMMANDS. It returns the client and a raw
+// JetStream context for assertions.
+func seedStream(t *testing.T, n int) (*NATSClient, nats.JetStreamContext) {
+	t.Helper()
+
+	cfg := startEmbedded(t, false)
+	client := NewNATSClient(cfg, NewCircuitBreaker("backup-"+t.Name()))
+	require.NoError(t, client.ProvisionStreams(context.Background()))
+
+	nc, err := nats.Connect(cfg.URL)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	for i := range n {
+		msg := nats.NewMsg("agent.alpha.cmd")
+		msg.Data = []byte{byte('a' + i)}
+		msg.Header.Set("Trace-Id", "t-"+string(rune('a'+i)))
+		_, err := js.PublishMsg(msg)
+		require.NoError(t, err)
+	}
+	return client, js
+}
+
+func openDir(t *testing.T) archive.Store {
+	t.Helper()
+	store, err := archive.Open(t.TempDir(), config.StorageConfig{})
+	require.NoError(t, err)
+	return store
+}
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	client, js := seedStream(t, 5)
+	_, err := js.AddConsumer("AGENT_COMMANDS", &nats.ConsumerConfig{
+		Durable:   "worker",
+		AckPolicy: nats.AckExplicitPolicy,
+	})
+	require.NoError(t, err)
+	// Drop the first message so the backup starts at seq 2.
+	require.NoError(t, js.DeleteMsg("AGENT_COMMANDS", 1))
+
+	store := openDir(t)
+	var calls int
+	manifest, err := client.BackupStream(context.Background(), "AGENT_COMMANDS", store, func(done, total uint64) {
+		calls++
+		assert.Equal(t, uint64(4), total)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, uint64(4), manifest.Messages)
+	assert.Equal(t, uint64(2), manifest.FirstSeq)
+	assert.Equal(t, uint64(5), manifest.LastSeq)
+	require.Len(t, manifest.Consumers, 1)
+	assert.Equal(t, "worker", manifest.Consumers[0].Durable)
+
+	result, err := client.RestoreStream(context.Background(), "AGENT_COMMANDS", store, RestoreOptions{Replace: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), result.Messages)
+	assert.Equal(t, 1, result.Consumers)
+	assert.True(t, result.SequencesPreserved)
+
+	info, err := js.StreamInfo("AGENT_COMMANDS")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), info.State.Msgs)
+	assert.Equal(t, uint64(2), info.State.FirstSeq)
+
+	raw, err := js.GetMsg("AGENT_COMMANDS", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("c"), raw.Data)
+	assert.Equal(t, "t-c", raw.Header.Get("Trace-Id"))
+	assert.Equal(t, "3", raw.Header.Get(headerOriginalSeq))
+
+	ci, err := js.ConsumerInfo("AGENT_COMMANDS", "worker")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), ci.NumPending)
+}
+
+func TestRestore_ExistingStreamWithoutReplace(t *testing.T) {
+	t.Parallel()
+
+	client, _ := seedStream(t, 1)
+	store := openDir(t)
+	_, err := client.BackupStream(context.Background(), "AGENT_COMMANDS", store, nil)
+	require.NoError(t, err)
+
+	_, err = client.RestoreStream(context.Background(), "AGENT_COMMANDS", store, RestoreOptions{}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestRestore_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	client, js := seedStream(t, 2)
+	dir := t.TempDir()
+	store, err := archive.Open(dir, config.StorageConfig{})
+	require.NoError(t, err)
+	_, err = client.BackupStream(context.Background(), "AGENT_COMMANDS", store, nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "AGENT_COMMANDS", backupMessagesFile)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("{}\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = client.RestoreStream(context.Background(), "AGENT_COMMANDS", store, RestoreOptions{Replace: true}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+
+	// The stream must be untouched when verification fails.
+	info, err := js.StreamInfo("AGENT_COMMANDS")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), info.State.Msgs)
+}
+
+func TestBackupStream_UnknownStream(t *testing.T) {
+	t.Parallel()
+
+	client, _ := seedStream(t, 0)
+	_, err := client.BackupStream(context.Background(), "NOPE", openDir(t), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stream info NOPE")
+}
+
+func TestRestore_InterestStreamSkipsMessagesWithoutInterest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		consumers []nats.ConsumerConfig
+		restored  uint64
+		skipped   uint64
+	}{
+		{name: "no consumers", skipped: 3},
+		{name: "other subjects", consumers: []nats.ConsumerConfig{
+			{Durable: "events", AckPolicy: nats.AckExplicitPolicy, FilterSubject: "agent.*.status"},
+		}, skipped: 3},
+		{name: "matching filter", consumers: []nats.ConsumerConfig{
+			{Durable: "events", AckPolicy: nats.AckExplicitPolicy, FilterSubjects: []string{"agent.*.status", "agent.*.cmd"}},
+		}, restored: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, js := seedStream(t, 3)
+			store := openDir(t)
+			_, err := client.BackupStream(context.Background(), "AGENT_COMMANDS", store, nil)
+			require.NoError(t, err)
+
+			// Turn the backup into one of an interest-based stream.
+			var manifest StreamBackupManifest
+			require.NoError(t, archive.ReadJSON(context.Background(), store, "AGENT_COMMANDS/"+backupManifestFile, &manifest))
+			manifest.Config.Retention = nats.InterestPolicy
+			manifest.Consumers = tc.consumers
+			require.NoError(t, archive.WriteJSON(context.Background(), store, "AGENT_COMMANDS/"+backupManifestFile, &manifest))
+
+			result, err := client.RestoreStream(context.Background(), "AGENT_COMMANDS", store, RestoreOptions{Replace: true}, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.restored, result.Messages)
+			assert.Equal(t, tc.skipped, result.Skipped)
+
+			info, err := js.StreamInfo("AGENT_COMMANDS")
+			require.NoError(t, err)
+			assert.Equal(t, tc.restored, info.State.Msgs)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// embeddedReadyTimeout bounds how long StartEmbeddedNATS waits for the
+// in-process server to accept connections.
+const embeddedReadyTimeout = 10 * time.Second
+
+// EmbeddedNATS is an in-process nats-server with JetStream enabled. It lets
+// Cortex run locally and in tests without a separate arc-messaging container.
+type EmbeddedNATS struct {
+	srv     *server.Server
+	tempDir string
+}
+
+// StartEmbeddedNATS starts a JetStream-enabled nats-server on
+// cfg.EmbeddedHost:cfg.EmbeddedPort (port -1 picks a free port) and blocks
+// until it accepts connections. It requires the same token or user/password
+// the clients connect with, so the embedded server is not left open when
+// credentials are configured. When cfg.EmbeddedStoreDir is empty a temp
+// directory is created and removed again by Shutdown.
+func StartEmbeddedNATS(cfg config.NATSConfig) (*EmbeddedNATS, error) {
+	e := &EmbeddedNATS{}
+
+	storeDir := cfg.EmbeddedStoreDir
+	if storeDir == "" {
+		dir, err := os.MkdirTemp("", "cortex-nats-*")
+		if err != nil {
+			return nil, fmt.Errorf("creating embedded NATS store dir: %w", err)
+		}
+		storeDir = dir
+		e.tempDir = dir
+	}
+
+	opts := &server.Options{
+		ServerName: "cortex-embedded",
+		Host:       cfg.EmbeddedHost,
+		Port:       cfg.EmbeddedPort,
+		JetStream:  true,
+		StoreDir:   storeDir,
+		NoLog:      true,
+		NoSigs:     true,
+	}
+	// Same precedence as natsOptions.
+	switch {
+	case cfg.Token != "":
+		opts.Authorization = cfg.Token
+	case cfg.User != "":
+		opts.Username = cfg.User
+		opts.Password = cfg.Password
+	}
+
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		e.removeTempDir()
+		return nil, fmt.Errorf("configuring embedded NATS: %w", err)
+	}
+	e.srv = srv
+
+	go srv.Start()
+	if !srv.ReadyForConnections(embeddedReadyTimeout) {
+		e.Shutdown()
+		return nil, fmt.Errorf("embedded NATS not ready after %s", embeddedReadyTimeout)
+	}
+
+	return e, nil
+}
+
+// ClientURL returns the nats:// URL clients should connect to.
+func (e *EmbeddedNATS) ClientURL() string {
+	return e.srv.ClientURL()
+}
+
+// Shutdown stops the server and removes its temp store directory, if any.
+func (e *EmbeddedNATS) Shutdown() {
+	if e.srv != nil {
+		e.srv.Shutdown()
+		e.srv.WaitForShutdown()
+	}
+	e.removeTempDir()
+}
+
+func (e *EmbeddedNATS) removeTempDir() {
+	if e.tempDir != "" {
+		_ = os.RemoveAll(e.tempDir)
+	}
+}
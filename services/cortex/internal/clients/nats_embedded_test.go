@@ -0,0 +1,131 @@
+package clients
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// startEmbedded starts an embedded NATS server on a free loopback port and
+// registers its shutdown with t.Cleanup.
+func startEmbedded(t *testing.T, inMemory bool) config.NATSConfig {
+	t.Helper()
+
+	cfg := config.NATSConfig{
+		Embedded:         true,
+		EmbeddedHost:     "127.0.0.1",
+		EmbeddedPort:     -1,
+		EmbeddedStoreDir: t.TempDir(),
+		EmbeddedInMemory: inMemory,
+	}
+	srv, err := StartEmbeddedNATS(cfg)
+	require.NoError(t, err)
+	t.Cleanup(srv.Shutdown)
+
+	cfg.URL = srv.ClientURL()
+	return cfg
+}
+
+func TestEmbeddedNATS_ProvisionStreamsAndProbe(t *testing.T) {
+	t.Parallel()
+
+	cfg := startEmbedded(t, false)
+	client := NewNATSClient(cfg, NewCircuitBreaker("embedded-e2e"))
+
+	// Probe before provisioning: reachable, no streams yet.
+	before := client.Probe(context.Background())
+	require.True(t, before.OK, before.Error)
+	health, ok := before.Details.(*jetStreamHealth)
+	require.True(t, ok)
+	assert.Empty(t, health.Streams)
+
+	require.NoError(t, client.ProvisionStreams(context.Background()))
+	// Second run updates in place.
+	require.NoError(t, client.ProvisionStreams(context.Background()))
+
+	nc, err := nats.Connect(cfg.URL)
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	for _, spec := range requiredStreams {
+		info, err := js.StreamInfo(spec.name)
+		require.NoError(t, err, spec.name)
+		assert.Equal(t, spec.subjects, info.Config.Subjects)
+		assert.Equal(t, nats.FileStorage, info.Config.Storage)
+	}
+
+	_, err = js.Publish("agent.alpha.cmd", []byte(`{"op":"ping"}`))
+	require.NoError(t, err)
+
+	after := client.Probe(context.Background())
+	require.True(t, after.OK, after.Error)
+	health, ok = after.Details.(*jetStreamHealth)
+	require.True(t, ok)
+	require.Len(t, health.Streams, len(requiredStreams))
+	assert.Equal(t, "AGENT_COMMANDS", health.Streams[0].Name)
+	assert.Equal(t, uint64(1), health.Streams[0].Messages)
+	assert.Equal(t, len(requiredStreams), health.Account.Streams)
+}
+
+func TestEmbeddedNATS_InMemoryStreams(t *testing.T) {
+	t.Parallel()
+
+	cfg := startEmbedded(t, true)
+	client := NewNATSClient(cfg, NewCircuitBreaker("embedded-memory"))
+
+	require.NoError(t, client.ProvisionStreams(context.Background()))
+
+	nc, err := nats.Connect(cfg.URL)
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	info, err := js.StreamInfo("AGENT_EVENTS")
+	require.NoError(t, err)
+	assert.Equal(t, nats.MemoryStorage, info.Config.Storage)
+}
+
+func TestEmbeddedNATS_ShutdownRemovesTempDir(t *testing.T) {
+	t.Parallel()
+
+	srv, err := StartEmbeddedNATS(config.NATSConfig{EmbeddedHost: "127.0.0.1", EmbeddedPort: -1})
+	require.NoError(t, err)
+	require.NotEmpty(t, srv.tempDir)
+
+	srv.Shutdown()
+	assert.NoDirExists(t, srv.tempDir)
+}
+
+func TestEmbeddedNATS_RequiresConfiguredCredentials(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]config.NATSConfig{
+		"token":         {Token: "s3cret"},
+		"user/password": {User: "cortex", Password: "s3cret"},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg.EmbeddedHost, cfg.EmbeddedPort, cfg.EmbeddedStoreDir = "127.0.0.1", -1, t.TempDir()
+			srv, err := StartEmbeddedNATS(cfg)
+			require.NoError(t, err)
+			t.Cleanup(srv.Shutdown)
+
+			_, err = nats.Connect(srv.ClientURL())
+			require.Error(t, err, "anonymous clients are rejected")
+
+			nc, err := nats.Connect(srv.ClientURL(), natsOptions(cfg)...)
+			require.NoError(t, err)
+			nc.Close()
+		})
+	}
+}
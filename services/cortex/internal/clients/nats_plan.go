@@ -0,0 +1,183 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"arc-framework/cortex/internal/contracts"
+)
+
+// streamRetention maps the x-nats-stream retention names to JetStream
+// policies.
+var streamRetention = map[string]nats.RetentionPolicy{
+	"limits":    nats.LimitsPolicy,
+	"interest":  nats.InterestPolicy,
+	"workqueue": nats.WorkQueuePolicy,
+}
+
+// buildStreamPlan derives the streams to provision from base and the
+// x-nats-stream extensions of the contract channels. A channel naming an
+// existing stream adds its subject to it; any other name defines a new stream
+// with the channel's retention (limits by default) and max age. Channels whose
+// retention or max age disagree with the stream they name, and channels that
+// are both core-NATS-only and bound to a stream, are reported together.
+func buildStreamPlan(base []streamSpec, channels []contracts.Channel) ([]streamSpec, error) {
+	plan := make([]streamSpec, len(base))
+	for i, s := range base {
+		plan[i] = s
+		plan[i].subjects = slices.Clone(s.subjects)
+	}
+	// definedBy records which channel defined each contract-only stream.
+	definedBy := make(map[string]contracts.Channel)
+
+	var errs []error
+	for _, ch := range channels {
+		if !ch.HasProtocol("nats") || ch.Stream == nil {
+			continue
+		}
+		want := ch.Stream
+		if ch.CoreOnly {
+			errs = append(errs, fmt.Errorf("channel %s in %s is marked core-NATS-only but names stream %s",
+				ch.ID, ch.Source, want.Name))
+			continue
+		}
+
+		i := slices.IndexFunc(plan, func(s streamSpec) bool { return s.name == want.Name })
+		if i < 0 {
+			retention := nats.LimitsPolicy
+			if want.Retention != "" {
+				retention = streamRetention[want.Retention]
+			}
+			plan = append(plan, streamSpec{name: want.Name, retention: retention, maxAge: want.MaxAge})
+			definedBy[want.Name] = ch
+			i = len(plan) - 1
+		}
+		spec := &plan[i]
+
+		if (want.Retention != "" && streamRetention[want.Retention] != spec.retention) ||
+			(want.MaxAge > 0 && want.MaxAge != spec.maxAge) {
+			origin := "provisioned by Cortex"
+			if def, ok := definedBy[spec.name]; ok {
+				origin = fmt.Sprintf("defined by channel %s in %s", def.ID, def.Source)
+			}
+			errs = append(errs, fmt.Errorf("channel %s in %s wants a different retention or max age for stream %s (%s)",
+				ch.ID, ch.Source, spec.name, origin))
+			continue
+		}
+
+		if subject := ch.NATSSubject(); !subjectCoveredBy(subject, spec.subjects) {
+			spec.subjects = append(spec.subjects, subject)
+		}
+	}
+
+	return plan, errors.Join(errs...)
+}
+
+// checkStreamPlan validates the stream catalog before anything is sent to
+// NATS. It fails when two streams claim overlapping subjects (JetStream would
+// reject the second one anyway, but with a far less useful error) and when a
+// NATS channel declared in an AsyncAPI contract is neither captured by a
+// stream nor marked core-NATS-only. All problems are reported together.
+func checkStreamPlan(streams []streamSpec, channels []contracts.Channel, coreSubjects []string) error {
+	var errs []error
+
+	for i := range streams {
+		for j := i + 1; j < len(streams); j++ {
+			for _, a := range streams[i].subjects {
+				for _, b := range streams[j].subjects {
+					if subjectsOverlap(a, b) {
+						errs = append(errs, fmt.Errorf("streams %s (%s) and %s (%s) claim overlapping subjects",
+							streams[i].name, a, streams[j].name, b))
+					}
+				}
+			}
+		}
+	}
+
+	for _, ch := range channels {
+		if !ch.HasProtocol("nats") || ch.CoreOnly {
+			continue
+		}
+		subject := ch.NATSSubject()
+		if subjectCoveredBy(subject, coreSubjects) {
+			continue
+		}
+		if streamFor(streams, subject) == "" {
+			errs = append(errs, fmt.Errorf("subject %s (channel %s in %s) is not captured by any stream and is not marked core-NATS-only",
+				subject, ch.ID, ch.Source))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// streamFor returns the name of the first stream whose subjects capture every
+// subject matched by subject, or "" when none does.
+func streamFor(streams []streamSpec, subject string) string {
+	for _, s := range streams {
+		if subjectCoveredBy(subject, s.subjects) {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// subjectCoveredBy reports whether any pattern in patterns is a superset of
+// subject.
+func subjectCoveredBy(subject string, patterns []string) bool {
+	for _, p := range patterns {
+		if subjectCovers(p, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectCovers reports whether every concrete subject matched by subject is
+// also matched by pattern. Both arguments may contain the NATS wildcards "*"
+// (exactly one token) and ">" (one or more trailing tokens).
+func subjectCovers(pattern, subject string) bool {
+	pt := strings.Split(pattern, ".")
+	st := strings.Split(subject, ".")
+
+	for i, p := range pt {
+		if p == ">" {
+			return i < len(st)
+		}
+		if i >= len(st) {
+			return false
+		}
+		s := st[i]
+		switch {
+		case s == ">":
+			return false
+		case p == "*":
+			continue
+		case s == "*" || s != p:
+			return false
+		}
+	}
+	return len(pt) == len(st)
+}
+
+// subjectsOverlap reports whether at least one concrete subject is matched by
+// both a and b.
+func subjectsOverlap(a, b string) bool {
+	at := strings.Split(a, ".")
+	bt := strings.Split(b, ".")
+
+	for i := 0; i < len(at) && i < len(bt); i++ {
+		x, y := at[i], bt[i]
+		if x == ">" || y == ">" {
+			return true
+		}
+		if x != "*" && y != "*" && x != y {
+			return false
+		}
+	}
+	return len(at) == len(bt)
+}
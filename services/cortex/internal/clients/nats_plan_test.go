@@ -0,0 +1,243 @@
+package clients
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/contracts"
+)
+
+func TestSubjectCovers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{"agent.*.cmd", "agent.*.cmd", true},
+		{"agent.*.cmd", "agent.alpha.cmd", true},
+		{"agent.*.cmd", "agent.alpha.event", false},
+		{"agent.alpha.cmd", "agent.*.cmd", false},
+		{"metrics.>", "metrics.cpu", true},
+		{"metrics.>", "metrics.*.host", true},
+		{"metrics.>", "metrics", false},
+		{"metrics.*", "metrics.>", false},
+		{"arc.>", "arc.reasoner.stream.*", true},
+		{"arc.reasoner.*", "arc.reasoner.stream.*", false},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, subjectCovers(tc.pattern, tc.subject), "%s ⊇ %s", tc.pattern, tc.subject)
+	}
+}
+
+func TestSubjectsOverlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"agent.*.cmd", "agent.*.event", false},
+		{"agent.*.cmd", "agent.x.cmd", true},
+		{"agent.>", "agent.*.event", true},
+		{"metrics.>", "agent.*.cmd", false},
+		{"a.b", "a.b.c", false},
+		{"*.*", "a.b", true},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, subjectsOverlap(tc.a, tc.b), "%s ∩ %s", tc.a, tc.b)
+		assert.Equal(t, tc.want, subjectsOverlap(tc.b, tc.a), "%s ∩ %s", tc.b, tc.a)
+	}
+}
+
+func TestCheckStreamPlan_RequiredStreamsAreDisjoint(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, checkStreamPlan(requiredStreams, nil, nil))
+}
+
+func TestCheckStreamPlan_OverlappingStreams(t *testing.T) {
+	t.Parallel()
+
+	streams := []streamSpec{
+		{name: "A", subjects: []string{"agent.*.cmd"}},
+		{name: "B", subjects: []string{"agent.>"}},
+	}
+
+	err := checkStreamPlan(streams, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "streams A (agent.*.cmd) and B (agent.>) claim overlapping subjects")
+}
+
+func TestCheckStreamPlan_ContractSubjects(t *testing.T) {
+	t.Parallel()
+
+	channels := []contracts.Channel{
+		{ID: "cmd", Address: "agent.{agent_id}.cmd", Protocols: []string{"nats"}},
+		{ID: "stream", Address: "arc.reasoner.stream.{request_id}", Protocols: []string{"nats"}, CoreOnly: true},
+		{ID: "ingest", Address: "arc.ingest.request", Protocols: []string{"nats"}},
+		{ID: "orphan", Address: "billing.usage", Protocols: []string{"nats"}, Source: "billing.yaml"},
+		{ID: "pulsar", Address: "persistent://arc/default/x", Protocols: []string{"pulsar"}},
+	}
+
+	err := checkStreamPlan(requiredStreams, channels, []string{"arc.ingest.>"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subject billing.usage (channel orphan in billing.yaml) is not captured")
+	assert.NotContains(t, err.Error(), "agent.*.cmd", "captured by AGENT_COMMANDS")
+	assert.NotContains(t, err.Error(), "arc.reasoner.stream", "marked core-only in the contract")
+	assert.NotContains(t, err.Error(), "arc.ingest.request", "listed in core subjects")
+	assert.NotContains(t, err.Error(), "persistent://", "pulsar channels are ignored")
+}
+
+func TestBuildStreamPlan(t *testing.T) {
+	t.Parallel()
+
+	requeue := &contracts.NATSStream{Name: "REQUESTS", Retention: "workqueue", MaxAge: time.Hour}
+	channels := []contracts.Channel{
+		{ID: "durable", Address: "arc.requests.durable", Protocols: []string{"nats"}, Stream: requeue},
+		{ID: "failed", Address: "arc.requests.failed", Protocols: []string{"nats"}, Stream: &contracts.NATSStream{Name: "REQUESTS"}},
+		{ID: "heartbeat", Address: "agent.{agent_id}.heartbeat", Protocols: []string{"nats"},
+			Stream: &contracts.NATSStream{Name: "AGENT_EVENTS", Retention: "interest"}},
+		{ID: "status", Address: "agent.{agent_id}.status", Protocols: []string{"nats"},
+			Stream: &contracts.NATSStream{Name: "AGENT_EVENTS"}},
+		{ID: "pulsar", Address: "persistent://arc/default/x", Protocols: []string{"pulsar"},
+			Stream: &contracts.NATSStream{Name: "IGNORED"}},
+	}
+
+	plan, err := buildStreamPlan(requiredStreams, channels)
+	require.NoError(t, err)
+	require.Len(t, plan, len(requiredStreams)+1)
+
+	assert.Equal(t, []string{"agent.*.event", "agent.*.status", "agent.*.heartbeat"}, plan[1].subjects)
+	assert.Equal(t, []string{"agent.*.event", "agent.*.status"}, requiredStreams[1].subjects, "base is not modified")
+	assert.Equal(t, streamSpec{
+		name:      "REQUESTS",
+		subjects:  []string{"arc.requests.durable", "arc.requests.failed"},
+		retention: nats.WorkQueuePolicy,
+		maxAge:    time.Hour,
+	}, plan[3])
+	require.NoError(t, checkStreamPlan(plan, channels, nil))
+}
+
+func TestBuildStreamPlan_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	channels := []contracts.Channel{
+		{ID: "core", Address: "arc.core", Protocols: []string{"nats"}, CoreOnly: true,
+			Stream: &contracts.NATSStream{Name: "CORE"}, Source: "a.yaml"},
+		{ID: "events", Address: "agent.{agent_id}.audit", Protocols: []string{"nats"},
+			Stream: &contracts.NATSStream{Name: "AGENT_EVENTS", Retention: "limits"}, Source: "a.yaml"},
+		{ID: "first", Address: "arc.one", Protocols: []string{"nats"},
+			Stream: &contracts.NATSStream{Name: "ARC", MaxAge: time.Hour}, Source: "a.yaml"},
+		{ID: "second", Address: "arc.two", Protocols: []string{"nats"},
+			Stream: &contracts.NATSStream{Name: "ARC", MaxAge: 2 * time.Hour}, Source: "b.yaml"},
+	}
+
+	_, err := buildStreamPlan(requiredStreams, channels)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel core in a.yaml is marked core-NATS-only but names stream CORE")
+	assert.Contains(t, err.Error(), "channel events in a.yaml wants a different retention or max age for stream AGENT_EVENTS (provisioned by Cortex)")
+	assert.Contains(t, err.Error(), "channel second in b.yaml wants a different retention or max age for stream ARC (defined by channel first in a.yaml)")
+}
+
+func TestProvisionStreams_ContractStreams(t *testing.T) {
+	t.Parallel()
+
+	doc := filepath.Join(t.TempDir(), "asyncapi.yaml")
+	require.NoError(t, os.WriteFile(doc, []byte(`asyncapi: 3.0.0
+servers:
+  nats:
+    protocol: nats
+channels:
+  usage:
+    address: billing.usage
+    x-nats-stream:
+      name: BILLING
+`), 0o600))
+
+	js := &fakeJS{streamInfoErr: map[string]error{"BILLING": nats.ErrStreamNotFound}}
+	client := makeNATSClient(js, NewCircuitBreaker("provision-contract-streams"))
+	client.contracts = []string{doc}
+
+	require.NoError(t, client.ProvisionStreams(context.Background()))
+	assert.Equal(t, []string{"BILLING"}, js.addStreamCalls)
+	assert.ElementsMatch(t, []string{"AGENT_COMMANDS", "AGENT_EVENTS", "SYSTEM_METRICS"}, js.updateStreamCalls)
+
+	js.streamInfoErr = nil
+	result := client.Probe(context.Background())
+	require.True(t, result.OK)
+	assert.Len(t, result.Details.(*jetStreamHealth).Streams, len(requiredStreams)+1)
+}
+
+func TestProbe_PlanErrorDegrades(t *testing.T) {
+	t.Parallel()
+
+	client := makeNATSClient(&fakeJS{}, NewCircuitBreaker("probe-plan-err"))
+	client.contracts = []string{filepath.Join(t.TempDir(), "missing.yaml")}
+
+	result := client.Probe(context.Background())
+	assert.True(t, result.OK)
+	assert.True(t, result.Degraded)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "stream plan: reading asyncapi document")
+	assert.Len(t, result.Details.(*jetStreamHealth).Streams, len(requiredStreams))
+}
+
+func TestProvisionStreams_PlanErrorSkipsNATS(t *testing.T) {
+	t.Parallel()
+
+	doc := filepath.Join(t.TempDir(), "asyncapi.yaml")
+	require.NoError(t, os.WriteFile(doc, []byte(`asyncapi: 3.0.0
+servers:
+  nats:
+    protocol: nats
+channels:
+  orphan:
+    address: billing.usage
+`), 0o600))
+
+	js := &fakeJS{}
+	client := makeNATSClient(js, NewCircuitBreaker("provision-plan-err"))
+	client.contracts = []string{doc}
+
+	err := client.ProvisionStreams(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stream plan")
+	assert.Empty(t, js.addStreamCalls)
+	assert.Empty(t, js.updateStreamCalls)
+}
+
+func TestCheckStreamPlan_RepoContracts(t *testing.T) {
+	t.Parallel()
+
+	paths := []string{
+		"../../../reasoner/contracts/asyncapi.yaml",
+		"../../../voice/contracts/asyncapi.yaml",
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			t.Skipf("contract %s not available: %v", p, err)
+		}
+	}
+
+	channels, err := contracts.LoadAll(paths)
+	require.NoError(t, err)
+	plan, err := buildStreamPlan(requiredStreams, channels)
+	require.NoError(t, err)
+	assert.NoError(t, checkStreamPlan(plan, channels, nil))
+
+	require.Len(t, plan, len(requiredStreams)+1)
+	assert.Equal(t, "REASONER_REQUESTS", plan[3].name)
+	assert.Equal(t, []string{"arc.reasoner.requests.durable", "arc.reasoner.requests.failed"}, plan[3].subjects)
+}
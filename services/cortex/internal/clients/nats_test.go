@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/sony/gobreaker"
@@ -19,6 +20,14 @@ type fakeJS struct {
 	// streamInfoErr is keyed by stream name; a nil value means "stream exists".
 	streamInfoErr map[string]error
 
+	// streamState is keyed by stream name and returned inside StreamInfo.
+	streamState map[string]nats.StreamState
+	// consumers is keyed by stream name and returned by ConsumersInfo.
+	consumers map[string][]*nats.ConsumerInfo
+
+	accountInfo    *nats.AccountInfo
+	accountInfoErr error
+
 	addStreamErr    error
 	updateStreamErr error
 
@@ -29,11 +38,30 @@ type fakeJS struct {
 func (f *fakeJS) StreamInfo(stream string, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
 	err, ok := f.streamInfoErr[stream]
 	if !ok || err == nil {
-		return &nats.StreamInfo{}, nil
+		return &nats.StreamInfo{State: f.streamState[stream]}, nil
 	}
 	return nil, err
 }
 
+func (f *fakeJS) AccountInfo(_ ...nats.JSOpt) (*nats.AccountInfo, error) {
+	if f.accountInfoErr != nil {
+		return nil, f.accountInfoErr
+	}
+	if f.accountInfo != nil {
+		return f.accountInfo, nil
+	}
+	return &nats.AccountInfo{Tier: nats.Tier{Limits: nats.AccountLimits{MaxMemory: -1, MaxStore: -1}}}, nil
+}
+
+func (f *fakeJS) ConsumersInfo(stream string, _ ...nats.JSOpt) <-chan *nats.ConsumerInfo {
+	ch := make(chan *nats.ConsumerInfo, len(f.consumers[stream]))
+	for _, ci := range f.consumers[stream] {
+		ch <- ci
+	}
+	close(ch)
+	return ch
+}
+
 func (f *fakeJS) AddStream(cfg *nats.StreamConfig, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
 	f.addStreamCalls = append(f.addStreamCalls, cfg.Name)
 	return &nats.StreamInfo{}, f.addStreamErr
@@ -49,9 +77,10 @@ func makeNATSClient(js jsContext, cb *gobreaker.CircuitBreaker) *NATSClient {
 	return &NATSClient{
 		url: "nats://localhost:4222",
 		cb:  cb,
-		newJS: func(_ string) (jsContext, func(), error) {
+		newJS: func(_ string, _ ...nats.Option) (jsContext, func(), error) {
 			return js, func() {}, nil
 		},
+		now: time.Now,
 	}
 }
 
@@ -60,7 +89,7 @@ func makeNATSClientWithConnErr(connErr error, cb *gobreaker.CircuitBreaker) *NAT
 	return &NATSClient{
 		url: "nats://localhost:4222",
 		cb:  cb,
-		newJS: func(_ string) (jsContext, func(), error) {
+		newJS: func(_ string, _ ...nats.Option) (jsContext, func(), error) {
 			return nil, func() {}, connErr
 		},
 	}
@@ -76,6 +105,78 @@ func TestNewNATSClient(t *testing.T) {
 	assert.NotNil(t, client)
 	assert.Equal(t, "nats://arc-messaging:4222", client.url)
 	assert.NotNil(t, client.newJS)
+	assert.Empty(t, client.opts, "no auth or TLS configured")
+}
+
+func TestNATSOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		cfg        config.NATSConfig
+		wantUser   string
+		wantPass   string
+		wantToken  string
+		wantSecure bool
+		wantErrSub string
+	}{
+		{
+			name:     "user and password",
+			cfg:      config.NATSConfig{User: "cortex", Password: "s3cret"},
+			wantUser: "cortex",
+			wantPass: "s3cret",
+		},
+		{
+			name:      "token takes precedence over user",
+			cfg:       config.NATSConfig{User: "cortex", Token: "tok"},
+			wantToken: "tok",
+		},
+		{
+			name:       "tls with server name only",
+			cfg:        config.NATSConfig{TLS: config.TLSConfig{ServerName: "arc-messaging"}},
+			wantSecure: true,
+		},
+		{
+			name:       "missing nkey seed surfaces at connect time",
+			cfg:        config.NATSConfig{NKeySeedFile: "/nonexistent/seed.nk"},
+			wantErrSub: "nkey seed",
+		},
+		{
+			name:       "missing CA file surfaces at connect time",
+			cfg:        config.NATSConfig{TLS: config.TLSConfig{CAFile: "/nonexistent/ca.pem"}},
+			wantErrSub: "nats tls",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var o nats.Options
+			var applyErr error
+			for _, opt := range natsOptions(tc.cfg) {
+				if err := opt(&o); err != nil {
+					applyErr = err
+					break
+				}
+			}
+
+			if tc.wantErrSub != "" {
+				require.Error(t, applyErr)
+				assert.Contains(t, applyErr.Error(), tc.wantErrSub)
+				return
+			}
+			require.NoError(t, applyErr)
+			assert.Equal(t, tc.wantUser, o.User)
+			assert.Equal(t, tc.wantPass, o.Password)
+			assert.Equal(t, tc.wantToken, o.Token)
+			assert.Equal(t, tc.wantSecure, o.Secure)
+			if tc.wantSecure {
+				require.NotNil(t, o.TLSConfig)
+				assert.Equal(t, tc.cfg.TLS.ServerName, o.TLSConfig.ServerName)
+			}
+		})
+	}
 }
 
 func TestProvisionStreams_AllNew(t *testing.T) {
@@ -223,3 +324,99 @@ func TestProbe_CircuitOpenAfterThreeFailures(t *testing.T) {
 	assert.False(t, result.OK)
 	assert.Equal(t, "circuit open", result.Error)
 }
+
+func TestProbe_ReportsJetStreamDetails(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	js := &fakeJS{
+		streamInfoErr: map[string]error{
+			"SYSTEM_METRICS": nats.ErrStreamNotFound,
+		},
+		streamState: map[string]nats.StreamState{
+			"AGENT_EVENTS": {Msgs: 42, Bytes: 4096, FirstTime: now.Add(-time.Hour)},
+		},
+		consumers: map[string][]*nats.ConsumerInfo{
+			"AGENT_EVENTS": {{Name: "billing", NumPending: 7, NumAckPending: 2}},
+		},
+		accountInfo: &nats.AccountInfo{Tier: nats.Tier{
+			Memory: 10, Store: 100, Streams: 2, Consumers: 1,
+			Limits: nats.AccountLimits{MaxMemory: 1000, MaxStore: 1000},
+		}},
+	}
+
+	client := makeNATSClient(js, NewCircuitBreaker("probe-details"))
+	client.health = config.NATSHealthConfig{MaxStorageRatio: 0.9, MaxMemoryRatio: 0.9, MaxConsumerPending: 100}
+	client.now = func() time.Time { return now }
+
+	result := client.Probe(context.Background())
+
+	require.True(t, result.OK)
+	assert.False(t, result.Degraded)
+	assert.Empty(t, result.Warnings)
+
+	health, ok := result.Details.(*jetStreamHealth)
+	require.True(t, ok)
+	assert.Equal(t, uint64(100), health.Account.StorageUsed)
+	assert.Equal(t, int64(1000), health.Account.StorageLimit)
+	require.Len(t, health.Streams, 2, "missing SYSTEM_METRICS is skipped")
+
+	var events streamHealth
+	for _, s := range health.Streams {
+		if s.Name == "AGENT_EVENTS" {
+			events = s
+		}
+	}
+	assert.Equal(t, uint64(42), events.Messages)
+	assert.InDelta(t, 3600, events.OldestMessageAgeSec, 0.001)
+	require.Len(t, events.Consumers, 1)
+	assert.Equal(t, uint64(7), events.Consumers[0].NumPending)
+}
+
+func TestProbe_DegradedWhenThresholdsCrossed(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	js := &fakeJS{
+		streamState: map[string]nats.StreamState{
+			"AGENT_COMMANDS": {Msgs: 1, FirstTime: now.Add(-48 * time.Hour)},
+		},
+		consumers: map[string][]*nats.ConsumerInfo{
+			"AGENT_EVENTS": {{Name: "slow", NumPending: 50000}},
+		},
+		accountInfo: &nats.AccountInfo{Tier: nats.Tier{
+			Store:  990,
+			Limits: nats.AccountLimits{MaxMemory: -1, MaxStore: 1000},
+		}},
+	}
+
+	client := makeNATSClient(js, NewCircuitBreaker("probe-degraded"))
+	client.health = config.NATSHealthConfig{
+		MaxStorageRatio:    0.9,
+		MaxMemoryRatio:     0.9,
+		MaxConsumerPending: 10000,
+		MaxMessageAge:      24 * time.Hour,
+	}
+	client.now = func() time.Time { return now }
+
+	result := client.Probe(context.Background())
+
+	assert.True(t, result.OK, "degraded is still reachable")
+	assert.True(t, result.Degraded)
+	require.Len(t, result.Warnings, 3)
+	assert.Contains(t, result.Warnings[0], "storage at 99%")
+	assert.Contains(t, result.Warnings[1], "AGENT_COMMANDS oldest message")
+	assert.Contains(t, result.Warnings[2], "AGENT_EVENTS/slow has 50000 pending")
+}
+
+func TestProbe_AccountInfoError(t *testing.T) {
+	t.Parallel()
+
+	js := &fakeJS{accountInfoErr: nats.ErrJetStreamNotEnabled}
+
+	client := makeNATSClient(js, NewCircuitBreaker("probe-account-err"))
+	result := client.Probe(context.Background())
+
+	assert.False(t, result.OK)
+	assert.Contains(t, result.Error, "account info")
+}
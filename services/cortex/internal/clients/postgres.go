@@ -4,61 +4,149 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sony/gobreaker"
 
 	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/dbschema"
+	"arc-framework/cortex/internal/migrate"
 	"arc-framework/cortex/internal/orchestrator"
 )
 
 const probeName = "arc-persistence"
 
-// dbPinger abstracts the pgxpool.Pool methods used in Probe so that tests
-// can inject a fake without standing up a real database.
-type dbPinger interface {
+// pgPool abstracts the pgxpool.Pool methods used by Probe and Provision so
+// that tests can inject a fake without standing up a real database.
+type pgPool interface {
 	Ping(ctx context.Context) error
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	Close()
 }
 
-// PostgresClient wraps a pgx connection pool with a circuit breaker.
+// PostgresClient wraps a long-lived pgx connection pool with a circuit
+// breaker. The pool is opened on first use and shared by every probe and
+// provisioning run until Close.
 type PostgresClient struct {
 	cfg     config.PostgresConfig
 	cb      *gobreaker.CircuitBreaker
-	connect func(ctx context.Context, cfg config.PostgresConfig) (dbPinger, error)
+	connect func(ctx context.Context, cfg config.PostgresConfig) (pgPool, error)
+
+	mu   sync.Mutex
+	pool pgPool
+
+	// migrations loads the migrations the recorded versions are compared
+	// against.
+	migrations func() ([]migrate.Migration, error)
+	// migrateUp applies pending migrations during Provision when
+	// Migrations.Auto is set.
+	migrateUp func(ctx context.Context, migrations []migrate.Migration) ([]migrate.Step, error)
+	// expectations loads the schema each service requires.
+	expectations func() ([]dbschema.Expectation, error)
+
+	connectAdmin func(ctx context.Context, cfg config.PostgresConfig) (pgAdmin, error)
+	// acquireCopy checks out the single session used by DumpSchema and
+	// RestoreSchema.
+	acquireCopy func(ctx context.Context) (copyConn, error)
+	// secrets is nil unless WithSecrets was called.
+	secrets      secretStore
+	secretPrefix string
+}
+
+// postgresHealth is the Details payload of the Postgres probe.
+type postgresHealth struct {
+	Pool       *poolHealth            `json:"pool,omitempty"`
+	Server     *serverHealth          `json:"server,omitempty"`
+	Extensions []extensionHealth      `json:"extensions,omitempty"`
+	Migrations []migrate.ServiceState `json:"migrations"`
+	Schema     []dbschema.Result      `json:"schema,omitempty"`
+	Activity   *activityHealth        `json:"activity,omitempty"`
+	// checkWarnings holds deep-check queries that failed.
+	checkWarnings []string
 }
 
 // NewPostgresClient creates a PostgresClient that lazily opens a pgx pool on
-// the first call to Probe. The circuit breaker is applied around each probe
-// attempt. No connection is made at construction time.
+// the first call to Probe or Provision. The circuit breaker is applied around
+// each attempt. No connection is made at construction time.
 func NewPostgresClient(cfg config.PostgresConfig, cb *gobreaker.CircuitBreaker) *PostgresClient {
-	return &PostgresClient{
-		cfg:     cfg,
-		cb:      cb,
-		connect: realConnect,
+	c := &PostgresClient{
+		cfg:          cfg,
+		cb:           cb,
+		connect:      realConnect,
+		connectAdmin: realConnectAdmin,
+		migrations: func() ([]migrate.Migration, error) {
+			sources, err := migrate.Sources(cfg.Migrations)
+			if err != nil {
+				return nil, err
+			}
+			return migrate.Load(sources...)
+		},
+		expectations: func() ([]dbschema.Expectation, error) {
+			return dbschema.Load(cfg.Expectations)
+		},
 	}
+	c.acquireCopy = c.acquirePoolConn
+	c.migrateUp = c.runMigrationsUp
+	return c
 }
 
-// Probe pings the Postgres server and verifies the schema_migrations table
-// exists in the public schema. It wraps the check in the circuit breaker so
-// that persistent failures trip the breaker after three consecutive errors.
+// Probe pings the Postgres server, checks the server version and required
+// extensions, verifies the schema_migrations table exists in the public
+// schema, compares the recorded versions with the known migrations of each
+// service and checks the tables and indexes each service expects. An unmet
+// requirement, a dirty version or a schema mismatch fails the probe; a
+// service that is behind or ahead, or a crossed health threshold such as
+// connection utilization or replica lag, degrades it. The database checks
+// run in the circuit breaker so that persistent failures trip the breaker
+// after three consecutive errors.
 func (c *PostgresClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 	start := time.Now()
 
-	_, err := c.cb.Execute(func() (any, error) {
-		pool, err := c.connect(ctx, c.cfg)
+	migrations, err := c.migrations()
+	if err != nil {
+		return orchestrator.ProbeResult{
+			Name:      probeName,
+			OK:        false,
+			LatencyMs: time.Since(start).Milliseconds(),
+			Error:     fmt.Sprintf("loading migrations: %v", err),
+		}
+	}
+	expectations, err := c.expectations()
+	if err != nil {
+		return orchestrator.ProbeResult{
+			Name:      probeName,
+			OK:        false,
+			LatencyMs: time.Since(start).Milliseconds(),
+			Error:     fmt.Sprintf("loading schema expectations: %v", err),
+		}
+	}
+
+	out, err := c.cb.Execute(func() (any, error) {
+		pool, err := c.getPool(ctx)
 		if err != nil {
 			return nil, err
 		}
-		defer pool.Close()
 
 		if err := pool.Ping(ctx); err != nil {
 			return nil, fmt.Errorf("ping: %w", err)
 		}
 
+		health := &postgresHealth{Pool: readPoolStats(pool)}
+		if health.Server, err = readServer(ctx, pool); err != nil {
+			return nil, err
+		}
+		health.Server.MinVersion = c.cfg.MinServerVersion
+		if health.Extensions, err = readExtensions(ctx, pool, c.cfg.ExtensionLayout()); err != nil {
+			return nil, err
+		}
+
 		var exists int
 		row := pool.QueryRow(ctx,
 			"SELECT 1 FROM information_schema.tables WHERE table_schema='public' AND table_name='schema_migrations'",
@@ -67,7 +155,20 @@ func (c *PostgresClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 			return nil, fmt.Errorf("schema_migrations table not found: %w", err)
 		}
 
-		return nil, nil
+		applied, err := migrate.ReadApplied(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		health.Migrations = migrate.Check(migrations, applied)
+		if len(expectations) > 0 {
+			cat, err := dbschema.ReadCatalog(ctx, pool, dbschema.Schemas(expectations))
+			if err != nil {
+				return nil, err
+			}
+			health.Schema = dbschema.Verify(expectations, cat)
+		}
+		health.Activity, health.checkWarnings = readActivity(ctx, pool, c.cfg.Health)
+		return health, nil
 	})
 
 	latency := time.Since(start).Milliseconds()
@@ -85,25 +186,187 @@ func (c *PostgresClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 		}
 	}
 
-	return orchestrator.ProbeResult{
+	health := out.(*postgresHealth)
+	result := orchestrator.ProbeResult{
 		Name:      probeName,
 		OK:        true,
 		LatencyMs: latency,
+		Details:   health,
+	}
+	problems := requirementErrors(c.cfg, health.Server, health.Extensions)
+	if dirty := dirtyMigrations(health.Migrations); dirty != "" {
+		problems = append(problems, dirty)
+	}
+	for _, r := range health.Schema {
+		if !r.OK {
+			problems = append(problems, fmt.Sprintf("%s schema: %s", r.Service, strings.Join(r.Problems, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		result.OK = false
+		result.Error = strings.Join(problems, "; ")
+		return result
+	}
+	result.Warnings = evaluateMigrations(health.Migrations)
+	result.Warnings = append(result.Warnings, health.checkWarnings...)
+	result.Warnings = append(result.Warnings, evaluateActivity(health.Activity, c.cfg.Health)...)
+	result.Degraded = len(result.Warnings) > 0
+	return result
+}
+
+// dirtyMigrations describes every dirty version, or returns "" when there
+// are none.
+func dirtyMigrations(states []migrate.ServiceState) string {
+	var parts []string
+	for _, s := range states {
+		if len(s.Dirty) > 0 {
+			parts = append(parts, fmt.Sprintf("%s version %s", s.Service, joinVersions(s.Dirty)))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "dirty migrations: " + strings.Join(parts, "; ")
+}
+
+// evaluateMigrations returns one warning per service that is behind or
+// ahead of the migrations this Cortex knows about.
+func evaluateMigrations(states []migrate.ServiceState) []string {
+	var warnings []string
+	for _, s := range states {
+		if len(s.Pending) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s migrations behind: at version %d, latest %d (pending %s); run `cortex migrate up` or enable bootstrap.postgres.migrations.auto",
+				s.Service, s.Current, s.Latest, joinVersions(s.Pending)))
+		}
+		if len(s.Unknown) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s migrations ahead: version %s applied but unknown (latest known %d)",
+				s.Service, joinVersions(s.Unknown), s.Latest))
+		}
+	}
+	return warnings
+}
+
+func joinVersions(versions []int64) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// getPool returns the shared pool, opening it on first use. A failed open is
+// not cached, so the next call retries.
+func (c *PostgresClient) getPool(ctx context.Context) (pgPool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pool == nil {
+		pool, err := c.connect(ctx, c.cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.pool = pool
+	}
+	return c.pool, nil
+}
+
+// Pool returns the shared pool for callers outside the client, such as the
+// audit store. It fails when the client was built with a fake connect func.
+func (c *PostgresClient) Pool(ctx context.Context) (*pgxpool.Pool, error) {
+	pool, err := c.getPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := pool.(*pgxpool.Pool)
+	if !ok {
+		return nil, fmt.Errorf("postgres pool is a %T, not a *pgxpool.Pool", pool)
+	}
+	return p, nil
+}
+
+// Close closes the shared pool, waiting for acquired connections to be
+// released. A later Probe or Provision opens a new pool.
+func (c *PostgresClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pool != nil {
+		c.pool.Close()
+		c.pool = nil
+	}
+}
+
+// poolHealth reports pgxpool statistics. Durations are cumulative since the
+// pool was opened.
+type poolHealth struct {
+	AcquiredConns        int32 `json:"acquiredConns"`
+	IdleConns            int32 `json:"idleConns"`
+	ConstructingConns    int32 `json:"constructingConns"`
+	TotalConns           int32 `json:"totalConns"`
+	MaxConns             int32 `json:"maxConns"`
+	AcquireCount         int64 `json:"acquireCount"`
+	EmptyAcquireCount    int64 `json:"emptyAcquireCount"`
+	CanceledAcquireCount int64 `json:"canceledAcquireCount"`
+	NewConnsCount        int64 `json:"newConnsCount"`
+	AcquireDurationMs    int64 `json:"acquireDurationMs"`
+	// EmptyAcquireWaitMs is the time spent waiting because no idle
+	// connection was available.
+	EmptyAcquireWaitMs int64 `json:"emptyAcquireWaitMs"`
+}
+
+// readPoolStats returns the statistics of a *pgxpool.Pool, or nil for other
+// pgPool implementations.
+func readPoolStats(pool pgPool) *poolHealth {
+	p, ok := pool.(interface{ Stat() *pgxpool.Stat })
+	if !ok {
+		return nil
+	}
+	s := p.Stat()
+	return &poolHealth{
+		AcquiredConns:        s.AcquiredConns(),
+		IdleConns:            s.IdleConns(),
+		ConstructingConns:    s.ConstructingConns(),
+		TotalConns:           s.TotalConns(),
+		MaxConns:             s.MaxConns(),
+		AcquireCount:         s.AcquireCount(),
+		EmptyAcquireCount:    s.EmptyAcquireCount(),
+		CanceledAcquireCount: s.CanceledAcquireCount(),
+		NewConnsCount:        s.NewConnsCount(),
+		AcquireDurationMs:    s.AcquireDuration().Milliseconds(),
+		EmptyAcquireWaitMs:   s.EmptyAcquireWaitTime().Milliseconds(),
 	}
 }
 
 // realConnect opens a pgxpool.Pool using the provided PostgresConfig.
-func realConnect(ctx context.Context, cfg config.PostgresConfig) (dbPinger, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DB, cfg.SSLMode,
-	)
+func realConnect(ctx context.Context, cfg config.PostgresConfig) (pgPool, error) {
+	return OpenPostgresPool(ctx, cfg)
+}
+
+// OpenPostgresPool opens a pgx pool for cfg. Connections are established
+// lazily; the caller owns the pool and must Close it.
+func OpenPostgresPool(ctx context.Context, cfg config.PostgresConfig) (*pgxpool.Pool, error) {
+	connString, err := PostgresConnString(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building postgres DSN: %w", err)
+	}
 
-	poolCfg, err := pgxpool.ParseConfig(dsn)
+	poolCfg, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("parsing postgres DSN: %w", err)
 	}
-	poolCfg.MaxConns = cfg.MaxConns
+	// Provisioning reuses a DSN for other databases by changing DB.
+	if cfg.DSN != "" && cfg.DB != "" {
+		poolCfg.ConnConfig.Database = cfg.DB
+	}
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// PostgresConnString returns the keyword/value connection string for cfg, or
+// cfg.DSN verbatim when it is set. Values are quoted as libpq requires, so
+// passwords containing '@', '/', spaces or quotes survive, and multiple
+// hosts are listed with their ports in order.
+func PostgresConnString(cfg config.PostgresConfig) (string, error) {
+	if cfg.DSN != "" {
+		return cfg.DSN, nil
+	}
+	hostPorts, err := cfg.HostList()
+	if err != nil {
+		return "", err
+	}
+	hosts := make([]string, len(hostPorts))
+	ports := make([]string, len(hostPorts))
+	for i, hp := range hostPorts {
+		// HostList only returns valid host:port pairs.
+		hosts[i], ports[i], _ = net.SplitHostPort(hp)
+	}
+
+	var b strings.Builder
+	param := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(quoteConnValue(value))
+	}
+	param("host", strings.Join(hosts, ","))
+	param("port", strings.Join(ports, ","))
+	param("user", cfg.User)
+	param("password", cfg.Password)
+	param("dbname", cfg.DB)
+	param("sslmode", cfg.SSLMode)
+	param("sslrootcert", cfg.SSLRootCert)
+	param("sslcert", cfg.SSLCert)
+	param("sslkey", cfg.SSLKey)
+	param("application_name", cfg.ApplicationName)
+	param("target_session_attrs", cfg.TargetSessionAttrs)
+	if cfg.ConnectTimeout > 0 {
+		// libpq takes whole seconds; round up so a sub-second timeout is
+		// not read as "wait forever".
+		param("connect_timeout", strconv.Itoa(int((cfg.ConnectTimeout+time.Second-1)/time.Second)))
+	}
+	return b.String(), nil
+}
+
+// quoteConnValue single-quotes v when it contains characters that end or
+// escape a keyword/value token.
+func quoteConnValue(v string) string {
+	if !strings.ContainsAny(v, ` '\`+"\t\n\r") {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
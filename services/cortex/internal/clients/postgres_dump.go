@@ -0,0 +1,348 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"arc-framework/cortex/internal/archive"
+)
+
+const (
+	// schemaDumpFormat is bumped whenever the archive layout changes.
+	schemaDumpFormat = 1
+
+	dumpPreDataFile  = "pre-data.sql"
+	dumpPostDataFile = "post-data.sql"
+)
+
+// copyConn is one session able to stream COPY data. Dump and restore need a
+// single connection so the snapshot and the restore transaction cover every
+// table.
+type copyConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyTo(ctx context.Context, w io.Writer, sql string) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, r io.Reader, sql string) (pgconn.CommandTag, error)
+	Release()
+}
+
+// SchemaDumpManifest describes a schema snapshot. It is stored as
+// manifest.json next to the DDL and data files and checked before anything
+// is restored.
+type SchemaDumpManifest struct {
+	Format        int       `json:"format"`
+	Schema        string    `json:"schema"`
+	CreatedAt     time.Time `json:"createdAt"`
+	ServerVersion string    `json:"serverVersion"`
+	// PreData creates the sequences and tables; PostData adds constraints
+	// and indexes once the data is loaded and sets sequence positions.
+	PreData   DumpFile    `json:"preData"`
+	PostData  DumpFile    `json:"postData"`
+	Sequences []string    `json:"sequences,omitempty"`
+	Tables    []TableDump `json:"tables"`
+	Rows      int64       `json:"rows"`
+	Bytes     int64       `json:"bytes"`
+}
+
+// DumpFile is one file of a schema dump.
+type DumpFile struct {
+	File     string `json:"file"`
+	Bytes    int64  `json:"bytes"`
+	Checksum string `json:"checksum"`
+}
+
+// TableDump is the COPY text-format data of one table. Columns excludes
+// generated columns, which are recomputed on restore.
+type TableDump struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+	DumpFile
+}
+
+// SchemaRestoreResult summarises a RestoreSchema run.
+type SchemaRestoreResult struct {
+	Schema   string `json:"schema"`
+	Tables   int    `json:"tables"`
+	Rows     int64  `json:"rows"`
+	Replaced bool   `json:"replaced"`
+}
+
+// DumpSchema writes the tables of schema to store under "<schema>/": DDL
+// rebuilt from the catalog, one COPY file per table and a manifest with row
+// counts and checksums. Every table is read in one repeatable-read
+// snapshot. Views, functions and triggers are not included.
+func (c *PostgresClient) DumpSchema(ctx context.Context, schema string, store archive.Store, progress ArchiveProgress) (*SchemaDumpManifest, error) {
+	conn, err := c.acquireCopy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY"); err != nil {
+		return nil, fmt.Errorf("starting snapshot: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "ROLLBACK") //nolint:errcheck
+
+	manifest := &SchemaDumpManifest{Format: schemaDumpFormat, Schema: schema, CreatedAt: time.Now().UTC()}
+	if err := conn.QueryRow(ctx, "SELECT current_setting('server_version')").Scan(&manifest.ServerVersion); err != nil {
+		return nil, fmt.Errorf("reading server version: %w", err)
+	}
+
+	def, err := readSchemaDef(ctx, conn, schema)
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range def.sequences {
+		if !seq.identity {
+			manifest.Sequences = append(manifest.Sequences, seq.name)
+		}
+	}
+
+	if manifest.PreData, err = writeDumpFile(ctx, store, schema, dumpPreDataFile, def.preData()); err != nil {
+		return nil, err
+	}
+	if manifest.PostData, err = writeDumpFile(ctx, store, schema, dumpPostDataFile, def.postData()); err != nil {
+		return nil, err
+	}
+
+	total := uint64(len(def.tables))
+	for i, t := range def.tables {
+		table := TableDump{Name: t.name, Columns: t.copyColumns()}
+		table.File = "data/" + url.PathEscape(t.name) + ".copy"
+
+		w, err := store.Create(ctx, schema+"/"+table.File)
+		if err != nil {
+			return nil, err
+		}
+		digest := archive.NewDigest()
+		tag, err := conn.CopyTo(ctx, io.MultiWriter(w, digest), copySQL(schema, table, "TO STDOUT"))
+		if err != nil {
+			w.Abort(err)
+			return nil, fmt.Errorf("copying %s.%s: %w", schema, t.name, err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("writing %s.%s: %w", schema, t.name, err)
+		}
+
+		table.Rows = tag.RowsAffected()
+		table.Bytes = digest.Size()
+		table.Checksum = digest.Sum()
+		manifest.Tables = append(manifest.Tables, table)
+		manifest.Rows += table.Rows
+		manifest.Bytes += table.Bytes
+		if progress != nil {
+			progress(uint64(i+1), total)
+		}
+	}
+
+	if err := archive.WriteJSON(ctx, store, schema+"/"+backupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// RestoreSchema loads a dump written by DumpSchema in one transaction:
+// pre-data DDL, then every table through COPY, then constraints and
+// indexes. All checksums are verified before the database is touched.
+// Tables that already exist are only dropped and recreated with
+// opts.Replace; other objects in the schema are left alone.
+func (c *PostgresClient) RestoreSchema(ctx context.Context, schema string, store archive.Store, opts RestoreOptions, progress ArchiveProgress) (*SchemaRestoreResult, error) {
+	var manifest SchemaDumpManifest
+	if err := archive.ReadJSON(ctx, store, schema+"/"+backupManifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != schemaDumpFormat {
+		return nil, fmt.Errorf("unsupported dump format %d (want %d)", manifest.Format, schemaDumpFormat)
+	}
+	if manifest.Schema != schema {
+		return nil, fmt.Errorf("dump at %s is for schema %q, not %q", store, manifest.Schema, schema)
+	}
+	files := []DumpFile{manifest.PreData, manifest.PostData}
+	for _, t := range manifest.Tables {
+		files = append(files, t.DumpFile)
+	}
+	for _, f := range files {
+		if err := archive.Verify(ctx, store, schema+"/"+f.File, f.Checksum); err != nil {
+			return nil, err
+		}
+	}
+	preData, err := readDumpFile(ctx, store, schema, manifest.PreData)
+	if err != nil {
+		return nil, err
+	}
+	postData, err := readDumpFile(ctx, store, schema, manifest.PostData)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.acquireCopy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "BEGIN"); err != nil {
+		return nil, fmt.Errorf("starting restore transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.Exec(context.WithoutCancel(ctx), "ROLLBACK") //nolint:errcheck,gosec
+		}
+	}()
+
+	result := &SchemaRestoreResult{Schema: schema, Tables: len(manifest.Tables)}
+	names := make([]string, len(manifest.Tables))
+	for i, t := range manifest.Tables {
+		names[i] = t.Name
+	}
+	rows, err := conn.Query(ctx, `
+		SELECT c.relname FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND c.relname = ANY($2)
+		ORDER BY c.relname`, schema, names)
+	if err != nil {
+		return nil, fmt.Errorf("looking up existing tables: %w", err)
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("looking up existing tables: %w", err)
+	}
+	if len(existing) > 0 {
+		if !opts.Replace {
+			return nil, fmt.Errorf("schema %s already has tables %s; restore with replace to overwrite them", schema, strings.Join(existing, ", "))
+		}
+		if _, err := conn.Exec(ctx, dropSQL(schema, names, manifest.Sequences)); err != nil {
+			return nil, fmt.Errorf("dropping existing tables: %w", err)
+		}
+		result.Replaced = true
+	}
+
+	if _, err := conn.Exec(ctx, preData); err != nil {
+		return nil, fmt.Errorf("creating tables: %w", err)
+	}
+	for i, t := range manifest.Tables {
+		rc, err := store.Open(ctx, schema+"/"+t.File)
+		if err != nil {
+			return nil, err
+		}
+		tag, err := conn.CopyFrom(ctx, rc, copySQL(schema, t, "FROM STDIN"))
+		rc.Close() //nolint:errcheck,gosec
+		if err != nil {
+			return nil, fmt.Errorf("loading %s.%s: %w", schema, t.Name, err)
+		}
+		if tag.RowsAffected() != t.Rows {
+			return nil, fmt.Errorf("loaded %d rows into %s.%s but manifest lists %d", tag.RowsAffected(), schema, t.Name, t.Rows)
+		}
+		result.Rows += t.Rows
+		if progress != nil {
+			progress(uint64(i+1), uint64(len(manifest.Tables)))
+		}
+	}
+	if _, err := conn.Exec(ctx, postData); err != nil {
+		return nil, fmt.Errorf("adding constraints and indexes: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, "COMMIT"); err != nil {
+		return nil, fmt.Errorf("committing restore: %w", err)
+	}
+	committed = true
+	return result, nil
+}
+
+func copySQL(schema string, t TableDump, direction string) string {
+	cols := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		cols[i] = pgx.Identifier{col}.Sanitize()
+	}
+	return fmt.Sprintf("COPY %s (%s) %s", pgx.Identifier{schema, t.Name}.Sanitize(), strings.Join(cols, ", "), direction)
+}
+
+func dropSQL(schema string, tables, sequences []string) string {
+	var b strings.Builder
+	for _, t := range tables {
+		fmt.Fprintf(&b, "DROP TABLE IF EXISTS %s CASCADE;\n", pgx.Identifier{schema, t}.Sanitize())
+	}
+	for _, s := range sequences {
+		fmt.Fprintf(&b, "DROP SEQUENCE IF EXISTS %s CASCADE;\n", pgx.Identifier{schema, s}.Sanitize())
+	}
+	return b.String()
+}
+
+func writeDumpFile(ctx context.Context, store archive.Store, schema, name, body string) (DumpFile, error) {
+	w, err := store.Create(ctx, schema+"/"+name)
+	if err != nil {
+		return DumpFile{}, err
+	}
+	digest := archive.NewDigest()
+	if _, err := io.WriteString(io.MultiWriter(w, digest), body); err != nil {
+		w.Abort(err)
+		return DumpFile{}, fmt.Errorf("writing %s: %w", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return DumpFile{}, fmt.Errorf("writing %s: %w", name, err)
+	}
+	return DumpFile{File: name, Bytes: digest.Size(), Checksum: digest.Sum()}, nil
+}
+
+func readDumpFile(ctx context.Context, store archive.Store, schema string, f DumpFile) (string, error) {
+	rc, err := store.Open(ctx, schema+"/"+f.File)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close() //nolint:errcheck
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", f.File, err)
+	}
+	return string(data), nil
+}
+
+// acquirePoolConn checks out a connection of the shared pool for COPY.
+func (c *PostgresClient) acquirePoolConn(ctx context.Context) (copyConn, error) {
+	pool, err := c.Pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+	return pooledCopyConn{conn}, nil
+}
+
+// pooledCopyConn adapts a *pgxpool.Conn to copyConn.
+type pooledCopyConn struct {
+	conn *pgxpool.Conn
+}
+
+func (p pooledCopyConn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return p.conn.Exec(ctx, sql, args...)
+}
+
+func (p pooledCopyConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return p.conn.Query(ctx, sql, args...)
+}
+
+func (p pooledCopyConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return p.conn.QueryRow(ctx, sql, args...)
+}
+
+func (p pooledCopyConn) CopyTo(ctx context.Context, w io.Writer, sql string) (pgconn.CommandTag, error) {
+	return p.conn.Conn().PgConn().CopyTo(ctx, w, sql)
+}
+
+func (p pooledCopyConn) CopyFrom(ctx context.Context, r io.Reader, sql string) (pgconn.CommandTag, error) {
+	return p.conn.Conn().PgConn().CopyFrom(ctx, r, sql)
+}
+
+func (p pooledCopyConn) Release() { p.conn.Release() }
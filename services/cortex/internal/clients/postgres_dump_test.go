@@ -0,0 +1,247 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/archive"
+	"arc-framework/cortex/internal/config"
+)
+
+// fakeCopyConn serves a small reasoner schema from canned catalog rows and
+// records every statement and COPY it is given.
+type fakeCopyConn struct {
+	noSchema bool
+	existing []string
+	data     map[string]string // table -> COPY text served by CopyTo
+
+	execs    []string
+	loaded   map[string]string // COPY statement -> data received by CopyFrom
+	released bool
+}
+
+func newFakeCopyConn() *fakeCopyConn {
+	return &fakeCopyConn{
+		data: map[string]string{
+			"chunks":    "1\t1\thello\n2\t1\tworld\n3\t2\tagain\n",
+			"documents": "1\tfirst\n2\tsecond\n",
+		},
+		loaded: map[string]string{},
+	}
+}
+
+func (f *fakeCopyConn) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	f.execs = append(f.execs, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (f *fakeCopyConn) QueryRow(_ context.Context, sql string, _ ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "server_version"):
+		return fakeRow{vals: []any{"17.2"}}
+	case strings.Contains(sql, "pg_namespace"):
+		return fakeRow{vals: []any{!f.noSchema}}
+	}
+	return fakeRow{err: fmt.Errorf("unexpected query %q", sql)}
+}
+
+func (f *fakeCopyConn) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	switch {
+	case strings.Contains(sql, "pg_sequences"):
+		return &mockRows{rows: [][]any{
+			{"chunks_id_seq", "bigint", int64(1), int64(1), int64(1), int64(9223372036854775807), false, int64(3), "chunks", "id", false},
+			{"documents_id_seq", "bigint", int64(1), int64(1), int64(1), int64(9223372036854775807), false, int64(2), "documents", "id", true},
+			{"unused_seq", "integer", int64(1), int64(1), int64(1), int64(2147483647), true, nil, "", "", false},
+		}}, nil
+	case strings.Contains(sql, "format_type"):
+		return &mockRows{rows: [][]any{
+			{"chunks", "id", "bigint", true, "nextval('reasoner.chunks_id_seq'::regclass)", "", ""},
+			{"chunks", "document_id", "bigint", false, "", "", ""},
+			{"chunks", "body", "text", false, "", "", ""},
+			{"documents", "id", "bigint", true, "", "", "a"},
+			{"documents", "title", "text", true, "", "", ""},
+			{"documents", "search", "tsvector", false, "to_tsvector('english'::regconfig, title)", "s", ""},
+		}}, nil
+	case strings.Contains(sql, "pg_get_constraintdef"):
+		return &mockRows{rows: [][]any{
+			{"chunks", "chunks_pkey", "PRIMARY KEY (id)"},
+			{"documents", "documents_pkey", "PRIMARY KEY (id)"},
+			{"chunks", "chunks_document_id_fkey", "FOREIGN KEY (document_id) REFERENCES reasoner.documents(id)"},
+		}}, nil
+	case strings.Contains(sql, "pg_get_indexdef"):
+		return &mockRows{rows: [][]any{
+			{"CREATE INDEX chunks_document_id_idx ON reasoner.chunks USING btree (document_id)"},
+		}}, nil
+	case strings.Contains(sql, "ANY($2)"):
+		rows := make([][]any, len(f.existing))
+		for i, name := range f.existing {
+			rows[i] = []any{name}
+		}
+		return &mockRows{rows: rows}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", sql)
+}
+
+func (f *fakeCopyConn) CopyTo(_ context.Context, w io.Writer, sql string) (pgconn.CommandTag, error) {
+	for table, data := range f.data {
+		if strings.Contains(sql, `"reasoner"."`+table+`"`) {
+			if _, err := io.WriteString(w, data); err != nil {
+				return pgconn.CommandTag{}, err
+			}
+			return pgconn.NewCommandTag(fmt.Sprintf("COPY %d", strings.Count(data, "\n"))), nil
+		}
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected copy %q", sql)
+}
+
+func (f *fakeCopyConn) CopyFrom(_ context.Context, r io.Reader, sql string) (pgconn.CommandTag, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	f.loaded[sql] = string(data)
+	return pgconn.NewCommandTag(fmt.Sprintf("COPY %d", strings.Count(string(data), "\n"))), nil
+}
+
+func (f *fakeCopyConn) Release() { f.released = true }
+
+func copyClient(conn *fakeCopyConn) *PostgresClient {
+	return &PostgresClient{acquireCopy: func(context.Context) (copyConn, error) { return conn, nil }}
+}
+
+func TestDumpRestoreSchema_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	src := newFakeCopyConn()
+	store := openDir(t)
+	var calls int
+	manifest, err := copyClient(src).DumpSchema(context.Background(), "reasoner", store, func(done, total uint64) {
+		calls++
+		assert.Equal(t, uint64(2), total)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY", "ROLLBACK"}, src.execs)
+	assert.True(t, src.released)
+
+	assert.Equal(t, "17.2", manifest.ServerVersion)
+	assert.Equal(t, []string{"chunks_id_seq", "unused_seq"}, manifest.Sequences)
+	assert.Equal(t, int64(5), manifest.Rows)
+	require.Len(t, manifest.Tables, 2)
+	assert.Equal(t, "chunks", manifest.Tables[0].Name)
+	assert.Equal(t, int64(3), manifest.Tables[0].Rows)
+	assert.Equal(t, "data/chunks.copy", manifest.Tables[0].File)
+	// The generated column is recomputed on restore, not copied.
+	assert.Equal(t, []string{"id", "title"}, manifest.Tables[1].Columns)
+
+	dst := newFakeCopyConn()
+	result, err := copyClient(dst).RestoreSchema(context.Background(), "reasoner", store, RestoreOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &SchemaRestoreResult{Schema: "reasoner", Tables: 2, Rows: 5}, result)
+
+	require.Len(t, dst.execs, 4)
+	assert.Equal(t, "BEGIN", dst.execs[0])
+	assert.Equal(t, "COMMIT", dst.execs[3])
+	pre, post := dst.execs[1], dst.execs[2]
+	assert.Contains(t, pre, `CREATE SEQUENCE "reasoner"."chunks_id_seq" AS bigint`)
+	assert.NotContains(t, pre, "documents_id_seq")
+	assert.Contains(t, pre, `"id" bigint DEFAULT nextval('reasoner.chunks_id_seq'::regclass) NOT NULL`)
+	assert.Contains(t, pre, `"id" bigint GENERATED ALWAYS AS IDENTITY NOT NULL`)
+	assert.Contains(t, pre, `"search" tsvector GENERATED ALWAYS AS (to_tsvector('english'::regconfig, title)) STORED`)
+	assert.Contains(t, pre, `ALTER SEQUENCE "reasoner"."chunks_id_seq" OWNED BY "reasoner"."chunks"."id";`)
+	assert.Less(t, strings.Index(post, "documents_pkey"), strings.Index(post, "chunks_document_id_fkey"))
+	assert.Contains(t, post, "CREATE INDEX chunks_document_id_idx ON reasoner.chunks USING btree (document_id);")
+	assert.Contains(t, post, `SELECT setval('"reasoner"."chunks_id_seq"', 3, true);`)
+	assert.Contains(t, post, `SELECT setval(pg_get_serial_sequence('"reasoner"."documents"', 'id'), 2, true);`)
+	assert.NotContains(t, post, "unused_seq")
+
+	assert.Equal(t, map[string]string{
+		`COPY "reasoner"."chunks" ("id", "document_id", "body") FROM STDIN`: src.data["chunks"],
+		`COPY "reasoner"."documents" ("id", "title") FROM STDIN`:            src.data["documents"],
+	}, dst.loaded)
+}
+
+func TestDumpRestoreSchema_TarArchive(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "reasoner.tar.gz")
+	store, err := archive.Open(path, config.StorageConfig{})
+	require.NoError(t, err)
+	src := newFakeCopyConn()
+	_, err = copyClient(src).DumpSchema(context.Background(), "reasoner", store, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = archive.Open(path, config.StorageConfig{})
+	require.NoError(t, err)
+	dst := newFakeCopyConn()
+	result, err := copyClient(dst).RestoreSchema(context.Background(), "reasoner", store, RestoreOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &SchemaRestoreResult{Schema: "reasoner", Tables: 2, Rows: 5}, result)
+	assert.Len(t, dst.loaded, 2)
+}
+
+func TestRestoreSchema_ExistingTables(t *testing.T) {
+	t.Parallel()
+
+	store := openDir(t)
+	_, err := copyClient(newFakeCopyConn()).DumpSchema(context.Background(), "reasoner", store, nil)
+	require.NoError(t, err)
+
+	dst := newFakeCopyConn()
+	dst.existing = []string{"documents"}
+	_, err = copyClient(dst).RestoreSchema(context.Background(), "reasoner", store, RestoreOptions{}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already has tables documents")
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, dst.execs)
+
+	dst = newFakeCopyConn()
+	dst.existing = []string{"documents"}
+	result, err := copyClient(dst).RestoreSchema(context.Background(), "reasoner", store, RestoreOptions{Replace: true}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.Replaced)
+	require.Len(t, dst.execs, 5)
+	assert.Contains(t, dst.execs[1], `DROP TABLE IF EXISTS "reasoner"."documents" CASCADE;`)
+	assert.Contains(t, dst.execs[1], `DROP SEQUENCE IF EXISTS "reasoner"."chunks_id_seq" CASCADE;`)
+}
+
+func TestRestoreSchema_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := archive.Open(dir, config.StorageConfig{})
+	require.NoError(t, err)
+	_, err = copyClient(newFakeCopyConn()).DumpSchema(context.Background(), "reasoner", store, nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "reasoner", "data", "documents.copy")
+	require.NoError(t, os.WriteFile(path, []byte("1\ttampered\n"), 0o600))
+
+	c := &PostgresClient{acquireCopy: func(context.Context) (copyConn, error) {
+		t.Fatal("database touched before the dump was verified")
+		return nil, nil
+	}}
+	_, err = c.RestoreSchema(context.Background(), "reasoner", store, RestoreOptions{Replace: true}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestDumpSchema_UnknownSchema(t *testing.T) {
+	t.Parallel()
+
+	conn := newFakeCopyConn()
+	conn.noSchema = true
+	_, err := copyClient(conn).DumpSchema(context.Background(), "reasoner", openDir(t), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema reasoner does not exist")
+}
@@ -0,0 +1,200 @@
+package clients
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/orchestrator"
+)
+
+// serverHealth reports the server version against the configured minimum.
+type serverHealth struct {
+	Version    string `json:"version"`
+	VersionNum int    `json:"versionNum"`
+	MinVersion string `json:"minVersion,omitempty"`
+}
+
+// extensionHealth reports an installed or required extension. Available is
+// the version CREATE EXTENSION would install; it is empty when the server
+// does not ship the extension at all.
+type extensionHealth struct {
+	Name      string `json:"name"`
+	Installed string `json:"installed,omitempty"`
+	Available string `json:"available,omitempty"`
+	Required  string `json:"required,omitempty"`
+}
+
+func readServer(ctx context.Context, db pgPool) (*serverHealth, error) {
+	var s serverHealth
+	err := db.QueryRow(ctx, "SELECT current_setting('server_version'), current_setting('server_version_num')::int").
+		Scan(&s.Version, &s.VersionNum)
+	if err != nil {
+		return nil, fmt.Errorf("reading server version: %w", err)
+	}
+	return &s, nil
+}
+
+// readExtensions lists every installed extension plus the required ones,
+// ordered by name.
+func readExtensions(ctx context.Context, db pgPool, required []config.PostgresExtension) ([]extensionHealth, error) {
+	names := make([]string, len(required))
+	minVersions := make(map[string]string, len(required))
+	for i, ext := range required {
+		names[i] = ext.Name
+		minVersions[ext.Name] = ext.MinVersion
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT name, COALESCE(default_version, ''), COALESCE(installed_version, '')
+		FROM pg_available_extensions
+		WHERE installed_version IS NOT NULL OR name = ANY($1)
+		ORDER BY name`, names)
+	if err != nil {
+		return nil, fmt.Errorf("reading extensions: %w", err)
+	}
+	exts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (extensionHealth, error) {
+		var e extensionHealth
+		err := row.Scan(&e.Name, &e.Available, &e.Installed)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading extensions: %w", err)
+	}
+
+	// Required extensions the server does not ship have no catalog row.
+	seen := make(map[string]bool, len(exts))
+	for i := range exts {
+		exts[i].Required = minVersions[exts[i].Name]
+		seen[exts[i].Name] = true
+	}
+	for _, ext := range required {
+		if !seen[ext.Name] {
+			exts = append(exts, extensionHealth{Name: ext.Name, Required: ext.MinVersion})
+		}
+	}
+	return exts, nil
+}
+
+// requirementErrors explains every unmet server or extension requirement.
+func requirementErrors(cfg config.PostgresConfig, server *serverHealth, exts []extensionHealth) []string {
+	var errs []string
+	if cfg.MinServerVersion != "" {
+		// The value was validated when the config was loaded.
+		if minNum, _ := config.ParseServerVersion(cfg.MinServerVersion); server.VersionNum < minNum {
+			errs = append(errs, fmt.Sprintf("server version %s is older than required %s", server.Version, cfg.MinServerVersion))
+		}
+	}
+
+	byName := make(map[string]extensionHealth, len(exts))
+	for _, e := range exts {
+		byName[e.Name] = e
+	}
+	for _, req := range cfg.ExtensionLayout() {
+		e := byName[req.Name]
+		switch {
+		case e.Installed == "" && e.Available == "":
+			errs = append(errs, fmt.Sprintf("extension %s is not available on the server; use an image that ships it", req.Name))
+		case e.Installed == "":
+			errs = append(errs, fmt.Sprintf("extension %s is not installed (available: %s); run CREATE EXTENSION %s or enable create_extensions",
+				req.Name, e.Available, req.Name))
+		case req.MinVersion != "" && compareVersions(e.Installed, req.MinVersion) < 0:
+			if compareVersions(e.Available, req.MinVersion) >= 0 {
+				errs = append(errs, fmt.Sprintf("extension %s %s is older than required %s; run ALTER EXTENSION %s UPDATE (available: %s)",
+					req.Name, e.Installed, req.MinVersion, req.Name, e.Available))
+			} else {
+				errs = append(errs, fmt.Sprintf("extension %s %s is older than required %s and the server only ships %s",
+					req.Name, e.Installed, req.MinVersion, e.Available))
+			}
+		}
+	}
+	return errs
+}
+
+// ensureExtensions creates missing required extensions and updates outdated
+// ones when the server ships a version that satisfies the requirement.
+// Extensions the server cannot provide are left for the probe to report.
+func ensureExtensions(ctx context.Context, admin pgAdmin, required []config.PostgresExtension) ([]orchestrator.ResourceResult, error) {
+	results := make([]orchestrator.ResourceResult, 0, len(required))
+	for _, req := range required {
+		result := orchestrator.ResourceResult{Resource: "extension:" + req.Name, Action: orchestrator.ActionUnchanged}
+		ident := pgx.Identifier{req.Name}.Sanitize()
+
+		var available, installed string
+		err := admin.QueryRow(ctx, `
+			SELECT COALESCE(default_version, ''), COALESCE(installed_version, '')
+			FROM pg_available_extensions WHERE name = $1`, req.Name).Scan(&available, &installed)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			result.Action = orchestrator.ActionConflict
+			result.Detail = "not available on the server"
+			results = append(results, result)
+			continue
+		case err != nil:
+			return results, fmt.Errorf("looking up extension %s: %w", req.Name, err)
+		}
+
+		satisfiable := req.MinVersion == "" || compareVersions(available, req.MinVersion) >= 0
+		switch {
+		case installed == "" && satisfiable:
+			if _, err := admin.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS "+ident); err != nil {
+				return results, fmt.Errorf("creating extension %s: %w", req.Name, err)
+			}
+			result.Action = orchestrator.ActionCreated
+			result.Detail = available
+		case installed != "" && req.MinVersion != "" && compareVersions(installed, req.MinVersion) < 0 && satisfiable:
+			if _, err := admin.Exec(ctx, "ALTER EXTENSION "+ident+" UPDATE"); err != nil {
+				return results, fmt.Errorf("updating extension %s: %w", req.Name, err)
+			}
+			result.Action = orchestrator.ActionUpdated
+			result.Detail = fmt.Sprintf("%s -> %s", installed, available)
+		case !satisfiable:
+			result.Action = orchestrator.ActionConflict
+			result.Detail = fmt.Sprintf("server ships %s, need %s", available, req.MinVersion)
+		default:
+			result.Detail = installed
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// compareVersions orders dotted extension versions numerically part by part,
+// falling back to string order for non-numeric parts such as "1.0beta".
+// Missing parts count as zero, so "0.5" equals "0.5.0".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(orZero(x))
+		yn, yerr := strconv.Atoi(orZero(y))
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				return cmp.Compare(xn, yn)
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
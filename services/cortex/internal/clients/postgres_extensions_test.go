@@ -0,0 +1,151 @@
+package clients
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/orchestrator"
+)
+
+func TestProbe_Requirements(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		db         *mockDB
+		cfg        config.PostgresConfig
+		wantOK     bool
+		wantErrSub string
+	}{
+		{
+			name:   "defaults met",
+			db:     &mockDB{},
+			cfg:    config.PostgresConfig{MinServerVersion: "13"},
+			wantOK: true,
+		},
+		{
+			name:       "server too old",
+			db:         &mockDB{serverVersion: "12.18", serverNum: 120018},
+			cfg:        config.PostgresConfig{MinServerVersion: "13"},
+			wantErrSub: "server version 12.18 is older than required 13",
+		},
+		{
+			name:       "extension not shipped",
+			db:         &mockDB{extensions: [][]any{{"plpgsql", "1.0", "1.0"}}},
+			wantErrSub: "extension vector is not available on the server",
+		},
+		{
+			name:       "extension not installed",
+			db:         &mockDB{extensions: [][]any{{"vector", "0.8.0", ""}}},
+			wantErrSub: "extension vector is not installed (available: 0.8.0)",
+		},
+		{
+			name:       "extension outdated but updatable",
+			db:         &mockDB{extensions: [][]any{{"vector", "0.8.0", "0.4.4"}}},
+			wantErrSub: "extension vector 0.4.4 is older than required 0.5.0; run ALTER EXTENSION vector UPDATE",
+		},
+		{
+			name: "declared extension with no minimum",
+			db:   &mockDB{extensions: [][]any{{"pg_trgm", "1.6", "1.6"}}},
+			cfg: config.PostgresConfig{
+				Extensions: []config.PostgresExtension{{Name: "pg_trgm"}},
+			},
+			wantOK: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tc.db.queryRow = &mockRow{val: 1}
+			client := makeClient(tc.db, nil, NewCircuitBreaker("test-requirements-"+tc.name))
+			client.cfg = tc.cfg
+
+			result := client.Probe(context.Background())
+			assert.Equal(t, tc.wantOK, result.OK, result.Error)
+			if tc.wantErrSub != "" {
+				assert.Contains(t, result.Error, tc.wantErrSub)
+			}
+
+			health, ok := result.Details.(*postgresHealth)
+			require.True(t, ok)
+			require.NotNil(t, health.Server)
+			assert.Equal(t, tc.cfg.MinServerVersion, health.Server.MinVersion)
+			assert.NotEmpty(t, health.Extensions)
+		})
+	}
+}
+
+func TestReadExtensions_ListsInstalledAndRequired(t *testing.T) {
+	t.Parallel()
+	db := &mockDB{extensions: [][]any{{"plpgsql", "1.0", "1.0"}, {"vector", "0.8.0", "0.7.0"}}}
+
+	exts, err := readExtensions(context.Background(), db, []config.PostgresExtension{
+		{Name: "vector", MinVersion: "0.5.0"},
+		{Name: "postgis", MinVersion: "3.4"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []extensionHealth{
+		{Name: "plpgsql", Installed: "1.0", Available: "1.0"},
+		{Name: "vector", Installed: "0.7.0", Available: "0.8.0", Required: "0.5.0"},
+		{Name: "postgis", Required: "3.4"},
+	}, exts)
+}
+
+func TestProvision_Extensions(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	cat.extensions = map[string][2]string{
+		"vector":  {"0.8.0", ""},
+		"pg_trgm": {"1.6", "1.5"},
+		"old":     {"1.0", "1.0"},
+		"current": {"2.1", "2.1"},
+	}
+	cfg := config.PostgresConfig{
+		DB: "arc", CreateExtensions: true,
+		Extensions: []config.PostgresExtension{
+			{Name: "vector", MinVersion: "0.5.0"},
+			{Name: "pg_trgm", MinVersion: "1.6"},
+			{Name: "old", MinVersion: "2.0"},
+			{Name: "current"},
+			{Name: "postgis"},
+		},
+	}
+	c := newProvisionClient(t, cfg, cat)
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []orchestrator.ResourceResult{
+		{Resource: "extension:vector", Action: orchestrator.ActionCreated, Detail: "0.8.0"},
+		{Resource: "extension:pg_trgm", Action: orchestrator.ActionUpdated, Detail: "1.5 -> 1.6"},
+		{Resource: "extension:old", Action: orchestrator.ActionConflict, Detail: "server ships 1.0, need 2.0"},
+		{Resource: "extension:current", Action: orchestrator.ActionUnchanged, Detail: "2.1"},
+		{Resource: "extension:postgis", Action: orchestrator.ActionConflict, Detail: "not available on the server"},
+	}, results)
+	assert.Equal(t, []string{
+		`arc: CREATE EXTENSION IF NOT EXISTS "vector"`,
+		`arc: ALTER EXTENSION "pg_trgm" UPDATE`,
+	}, cat.execs)
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.8.0", "0.5.0", 1},
+		{"0.5", "0.5.0", 0},
+		{"0.10.0", "0.9.1", 1},
+		{"1.0", "1.0.1", -1},
+		{"1.0beta", "1.0alpha", 1},
+		{"", "0.1", -1},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, compareVersions(tc.a, tc.b), "%s vs %s", tc.a, tc.b)
+	}
+}
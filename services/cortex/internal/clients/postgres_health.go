@@ -0,0 +1,150 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// activityHealth reports the server load read by the optional deep checks.
+// Fields of disabled checks are left at zero.
+type activityHealth struct {
+	Connections    int `json:"connections"`
+	MaxConnections int `json:"maxConnections"`
+	// OldestTransactionSec is the age of the oldest open transaction of any
+	// client; LongTransactions counts those older than the threshold.
+	OldestTransactionSec float64         `json:"oldestTransactionSec"`
+	LongTransactions     int             `json:"longTransactions"`
+	DatabaseBytes        int64           `json:"databaseBytes"`
+	Replicas             []replicaHealth `json:"replicas,omitempty"`
+}
+
+// replicaHealth is one row of pg_stat_replication, or a configured standby
+// that is not streaming.
+type replicaHealth struct {
+	Name         string  `json:"name"`
+	State        string  `json:"state"`
+	ReplayLagSec float64 `json:"replayLagSec"`
+	Streaming    bool    `json:"streaming"`
+}
+
+// readActivity runs the queries of every enabled deep check. A failed query
+// does not fail the probe: monitoring views may need pg_monitor, which the
+// bootstrap user is not guaranteed to have, so each failure becomes a
+// warning instead.
+func readActivity(ctx context.Context, db pgPool, cfg config.PostgresHealthConfig) (*activityHealth, []string) {
+	a := &activityHealth{}
+	var warnings []string
+	ran := false
+
+	if cfg.MaxConnectionRatio > 0 {
+		ran = true
+		err := db.QueryRow(ctx, `
+			SELECT (SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend')::int,
+			       current_setting('max_connections')::int`).Scan(&a.Connections, &a.MaxConnections)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("reading connection count: %v", err))
+		}
+	}
+	if cfg.MaxTransactionAge > 0 {
+		ran = true
+		err := db.QueryRow(ctx, `
+			SELECT COALESCE(EXTRACT(EPOCH FROM max(now() - xact_start)), 0)::float8,
+			       (count(*) FILTER (WHERE EXTRACT(EPOCH FROM now() - xact_start) > $1))::int
+			FROM pg_stat_activity
+			WHERE xact_start IS NOT NULL AND backend_type = 'client backend' AND pid <> pg_backend_pid()`,
+			cfg.MaxTransactionAge.Seconds()).Scan(&a.OldestTransactionSec, &a.LongTransactions)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("reading transaction ages: %v", err))
+		}
+	}
+	if cfg.MaxDatabaseBytes > 0 {
+		ran = true
+		if err := db.QueryRow(ctx, "SELECT pg_database_size(current_database())").Scan(&a.DatabaseBytes); err != nil {
+			warnings = append(warnings, fmt.Sprintf("reading database size: %v", err))
+		}
+	}
+	if len(cfg.Replicas) > 0 {
+		ran = true
+		replicas, err := readReplicas(ctx, db, cfg.Replicas)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+		}
+		a.Replicas = replicas
+	}
+
+	if !ran {
+		return nil, nil
+	}
+	return a, warnings
+}
+
+// readReplicas lists the standbys streaming from this server, followed by
+// each configured standby that is missing.
+func readReplicas(ctx context.Context, db pgPool, expected []string) ([]replicaHealth, error) {
+	rows, err := db.Query(ctx, `
+		SELECT application_name, state, COALESCE(EXTRACT(EPOCH FROM replay_lag), 0)::float8
+		FROM pg_stat_replication
+		ORDER BY application_name`)
+	if err != nil {
+		return nil, fmt.Errorf("reading replication status: %w", err)
+	}
+	replicas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (replicaHealth, error) {
+		r := replicaHealth{Streaming: true}
+		err := row.Scan(&r.Name, &r.State, &r.ReplayLagSec)
+		return r, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading replication status: %w", err)
+	}
+	for _, name := range expected {
+		if !slices.ContainsFunc(replicas, func(r replicaHealth) bool { return r.Name == name }) {
+			replicas = append(replicas, replicaHealth{Name: name, State: "missing"})
+		}
+	}
+	return replicas, nil
+}
+
+// evaluateActivity compares the collected load against the configured
+// thresholds and returns one warning per threshold crossed.
+func evaluateActivity(a *activityHealth, cfg config.PostgresHealthConfig) []string {
+	if a == nil {
+		return nil
+	}
+	var warnings []string
+
+	if cfg.MaxConnectionRatio > 0 && a.MaxConnections > 0 {
+		if ratio := float64(a.Connections) / float64(a.MaxConnections); ratio >= cfg.MaxConnectionRatio {
+			warnings = append(warnings, fmt.Sprintf("connections at %.0f%% of max_connections (%d/%d)",
+				ratio*100, a.Connections, a.MaxConnections))
+		}
+	}
+	if cfg.MaxTransactionAge > 0 && a.LongTransactions > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d transaction(s) open longer than %s (oldest %s)",
+			a.LongTransactions, cfg.MaxTransactionAge, secondsDuration(a.OldestTransactionSec)))
+	}
+	if cfg.MaxDatabaseBytes > 0 && a.DatabaseBytes > cfg.MaxDatabaseBytes {
+		warnings = append(warnings, fmt.Sprintf("database size %d bytes exceeds %d", a.DatabaseBytes, cfg.MaxDatabaseBytes))
+	}
+	for _, r := range a.Replicas {
+		if !slices.Contains(cfg.Replicas, r.Name) {
+			continue
+		}
+		switch {
+		case !r.Streaming:
+			warnings = append(warnings, fmt.Sprintf("replica %s is not streaming", r.Name))
+		case cfg.MaxReplicaLag > 0 && r.ReplayLagSec > cfg.MaxReplicaLag.Seconds():
+			warnings = append(warnings, fmt.Sprintf("replica %s replay lag is %s", r.Name, secondsDuration(r.ReplayLagSec)))
+		}
+	}
+	return warnings
+}
+
+func secondsDuration(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second)).Round(time.Second)
+}
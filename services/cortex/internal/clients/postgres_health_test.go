@@ -0,0 +1,131 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// activityDB answers the deep-check queries on top of a healthy mockDB.
+type activityDB struct {
+	mockDB
+	connections  []any // used, max
+	transactions []any // oldest seconds, count over threshold
+	size         int64
+	replicas     [][]any // application_name, state, replay lag seconds
+	replicaErr   error
+}
+
+func (d *activityDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "max_connections"):
+		return fakeRow{vals: d.connections}
+	case strings.Contains(sql, "xact_start"):
+		return fakeRow{vals: d.transactions}
+	case strings.Contains(sql, "pg_database_size"):
+		return fakeRow{vals: []any{d.size}}
+	}
+	return d.mockDB.QueryRow(ctx, sql, args...)
+}
+
+func (d *activityDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if strings.Contains(sql, "pg_stat_replication") {
+		if d.replicaErr != nil {
+			return nil, d.replicaErr
+		}
+		return &mockRows{rows: d.replicas}, nil
+	}
+	return d.mockDB.Query(ctx, sql, args...)
+}
+
+var testHealthConfig = config.PostgresHealthConfig{
+	MaxConnectionRatio: 0.9,
+	MaxTransactionAge:  5 * time.Minute,
+	Replicas:           []string{"replica-a", "replica-b"},
+	MaxReplicaLag:      30 * time.Second,
+	MaxDatabaseBytes:   1 << 30,
+}
+
+func TestProbe_ActivityWithinThresholds(t *testing.T) {
+	t.Parallel()
+
+	db := &activityDB{
+		mockDB:       mockDB{queryRow: &mockRow{val: 1}},
+		connections:  []any{12, 100},
+		transactions: []any{4.5, 0},
+		size:         int64(64 << 20),
+		replicas:     [][]any{{"replica-a", "streaming", 0.2}, {"replica-b", "streaming", 1.0}},
+	}
+	client := makeClient(db, nil, NewCircuitBreaker("test-activity-ok"))
+	client.cfg.Health = testHealthConfig
+
+	result := client.Probe(context.Background())
+	require.True(t, result.OK, result.Error)
+	assert.False(t, result.Degraded)
+	assert.Empty(t, result.Warnings)
+
+	health := result.Details.(*postgresHealth)
+	require.NotNil(t, health.Activity)
+	assert.Equal(t, 12, health.Activity.Connections)
+	assert.Equal(t, int64(64<<20), health.Activity.DatabaseBytes)
+	assert.Len(t, health.Activity.Replicas, 2)
+}
+
+func TestProbe_ActivityDegradesOnThresholds(t *testing.T) {
+	t.Parallel()
+
+	db := &activityDB{
+		mockDB:       mockDB{queryRow: &mockRow{val: 1}},
+		connections:  []any{99, 100},
+		transactions: []any{901.0, 2},
+		size:         int64(2 << 30),
+		replicas:     [][]any{{"replica-a", "catchup", 95.0}, {"analytics", "streaming", 600.0}},
+	}
+	client := makeClient(db, nil, NewCircuitBreaker("test-activity-degraded"))
+	client.cfg.Health = testHealthConfig
+
+	result := client.Probe(context.Background())
+	require.True(t, result.OK, result.Error)
+	assert.True(t, result.Degraded)
+	assert.Equal(t, []string{
+		"connections at 99% of max_connections (99/100)",
+		"2 transaction(s) open longer than 5m0s (oldest 15m1s)",
+		"database size 2147483648 bytes exceeds 1073741824",
+		"replica replica-a replay lag is 1m35s",
+		"replica replica-b is not streaming",
+	}, result.Warnings)
+}
+
+func TestProbe_ActivityQueryFailureDegrades(t *testing.T) {
+	t.Parallel()
+
+	db := &activityDB{
+		mockDB:     mockDB{queryRow: &mockRow{val: 1}},
+		replicaErr: errors.New("permission denied for view pg_stat_replication"),
+	}
+	client := makeClient(db, nil, NewCircuitBreaker("test-activity-query-error"))
+	client.cfg.Health = config.PostgresHealthConfig{Replicas: []string{"replica-a"}, MaxReplicaLag: time.Second}
+
+	result := client.Probe(context.Background())
+	require.True(t, result.OK, result.Error)
+	assert.True(t, result.Degraded)
+	assert.Equal(t, []string{"reading replication status: permission denied for view pg_stat_replication"}, result.Warnings)
+}
+
+func TestProbe_ActivityDisabled(t *testing.T) {
+	t.Parallel()
+
+	client := makeClient(&mockDB{queryRow: &mockRow{val: 1}}, nil, NewCircuitBreaker("test-activity-disabled"))
+
+	result := client.Probe(context.Background())
+	require.True(t, result.OK, result.Error)
+	assert.Nil(t, result.Details.(*postgresHealth).Activity)
+}
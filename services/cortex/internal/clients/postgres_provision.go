@@ -0,0 +1,397 @@
+package clients
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sony/gobreaker"
+
+	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/migrate"
+	"arc-framework/cortex/internal/orchestrator"
+	"arc-framework/cortex/internal/secrets"
+)
+
+// pgAdmin is the subset of *pgxpool.Pool used to provision roles, databases
+// and grants.
+type pgAdmin interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Close()
+}
+
+// secretStore keeps generated role passwords; *secrets.OpenBao implements it.
+type secretStore interface {
+	Read(ctx context.Context, path string) (map[string]string, error)
+	Write(ctx context.Context, path string, data map[string]string) error
+}
+
+// WithSecrets stores generated role passwords under prefix in store and
+// reuses stored passwords on later runs.
+func (c *PostgresClient) WithSecrets(store secretStore, prefix string) *PostgresClient {
+	c.secrets = store
+	c.secretPrefix = prefix
+	return c
+}
+
+// Provision reconciles the declared roles, databases, schemas and grants as
+// the bootstrap user, then the required extensions, then applies pending
+// migrations when Migrations.Auto is set. Roles come first so they can own
+// databases; grants are applied in each role's database. Every step is
+// idempotent, and grants are reasserted on every run so privileges removed
+// from the layout are revoked.
+func (c *PostgresClient) Provision(ctx context.Context) ([]orchestrator.ResourceResult, error) {
+	if !c.cfg.Provision && !c.cfg.CreateExtensions && !c.cfg.Migrations.Auto {
+		return nil, nil
+	}
+
+	var results []orchestrator.ResourceResult
+	_, err := c.cb.Execute(func() (any, error) {
+		admin, err := c.getPool(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.cfg.Provision {
+			if err := c.provisionLayout(ctx, admin, &results); err != nil {
+				return nil, err
+			}
+		}
+		if c.cfg.CreateExtensions {
+			exts, err := ensureExtensions(ctx, admin, c.cfg.ExtensionLayout())
+			results = append(results, exts...)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if c.cfg.Migrations.Auto {
+			if err := c.applyMigrations(ctx, &results); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+
+	if err != nil {
+		if errors.Is(err, gobreaker.ErrOpenState) {
+			return results, fmt.Errorf("circuit open: %w", err)
+		}
+		return results, err
+	}
+	return results, nil
+}
+
+// applyMigrations runs every pending migration and reports each one applied.
+func (c *PostgresClient) applyMigrations(ctx context.Context, results *[]orchestrator.ResourceResult) error {
+	migrations, err := c.migrations()
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+	steps, err := c.migrateUp(ctx, migrations)
+	for _, s := range steps {
+		*results = append(*results, orchestrator.ResourceResult{
+			Resource: fmt.Sprintf("migration:%s/%d_%s", s.Service, s.Version, s.Name),
+			Action:   orchestrator.ActionCreated,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("applying migrations: %w", err)
+	}
+	return nil
+}
+
+// runMigrationsUp applies migrations over a session of the shared pool; the
+// runner's advisory lock is session-scoped.
+func (c *PostgresClient) runMigrationsUp(ctx context.Context, migrations []migrate.Migration) ([]migrate.Step, error) {
+	pool, err := c.Pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+	defer conn.Release()
+	return migrate.NewRunner(conn, migrations).Up(ctx)
+}
+
+func (c *PostgresClient) provisionLayout(ctx context.Context, admin pgAdmin, results *[]orchestrator.ResourceResult) error {
+	roles := c.cfg.RoleLayout()
+	skipped := map[string]bool{}
+	for _, role := range roles {
+		r, err := c.ensureRole(ctx, admin, role)
+		if err != nil {
+			return err
+		}
+		if r.Action == orchestrator.ActionConflict {
+			skipped[role.Name] = true
+		}
+		*results = append(*results, r)
+	}
+	for _, db := range c.cfg.DatabaseLayout() {
+		if skipped[db.Owner] {
+			*results = append(*results, orchestrator.ResourceResult{
+				Resource: "database:" + db.Name, Action: orchestrator.ActionConflict,
+				Detail: "owner role " + db.Owner + " was not created",
+			})
+			continue
+		}
+		r, err := ensureDatabase(ctx, admin, db)
+		if err != nil {
+			return err
+		}
+		*results = append(*results, r)
+	}
+	for _, role := range roles {
+		if skipped[role.Name] {
+			continue
+		}
+		if err := c.grantRole(ctx, admin, role, results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureRole creates the login role or reasserts its password. A configured
+// password wins over one stored in OpenBao; a password is only generated
+// when OpenBao can keep it, so the stored value always matches the role. A
+// new role with no password source is reported as a conflict and not
+// created, since nothing could ever log in as it.
+func (c *PostgresClient) ensureRole(ctx context.Context, db pgAdmin, role config.PostgresRole) (orchestrator.ResourceResult, error) {
+	result := orchestrator.ResourceResult{Resource: "role:" + role.Name}
+
+	var exists bool
+	if err := db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", role.Name).Scan(&exists); err != nil {
+		return result, fmt.Errorf("looking up role %s: %w", role.Name, err)
+	}
+
+	password := role.Password
+	secretPath := path.Join(c.secretPrefix, role.Name)
+	if password == "" && c.secrets != nil {
+		stored, err := c.secrets.Read(ctx, secretPath)
+		switch {
+		case err == nil:
+			password = stored["password"]
+		case !errors.Is(err, secrets.ErrNotFound):
+			return result, fmt.Errorf("reading password for role %s: %w", role.Name, err)
+		}
+	}
+
+	if password == "" && !exists && c.secrets == nil {
+		result.Action = orchestrator.ActionConflict
+		result.Detail = "no password source: set password or password_file, or enable openbao"
+		return result, nil
+	}
+
+	generated := false
+	if password == "" && c.secrets != nil {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return result, err
+		}
+		generated = true
+	}
+
+	ident := pgx.Identifier{role.Name}.Sanitize()
+	var sql string
+	switch {
+	case !exists:
+		sql = fmt.Sprintf("CREATE ROLE %s LOGIN PASSWORD %s", ident, quoteLiteral(password))
+		result.Action = orchestrator.ActionCreated
+	case password != "":
+		sql = fmt.Sprintf("ALTER ROLE %s WITH LOGIN PASSWORD %s", ident, quoteLiteral(password))
+		result.Action = orchestrator.ActionUnchanged
+		if generated {
+			result.Action = orchestrator.ActionUpdated
+			result.Detail = "password rotated"
+		}
+	default:
+		sql = fmt.Sprintf("ALTER ROLE %s WITH LOGIN", ident)
+		result.Action = orchestrator.ActionUnchanged
+	}
+	if _, err := db.Exec(ctx, sql); err != nil {
+		return result, fmt.Errorf("provisioning role %s: %w", role.Name, err)
+	}
+
+	if generated {
+		if err := c.secrets.Write(ctx, secretPath, map[string]string{"username": role.Name, "password": password}); err != nil {
+			return result, fmt.Errorf("storing password for role %s: %w", role.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// ensureDatabase creates db or reassigns it to the declared owner. CREATE
+// DATABASE cannot run in a transaction, which is why this is not a migration.
+func ensureDatabase(ctx context.Context, admin pgAdmin, db config.PostgresDatabase) (orchestrator.ResourceResult, error) {
+	result := orchestrator.ResourceResult{Resource: "database:" + db.Name, Action: orchestrator.ActionUnchanged}
+	ident := pgx.Identifier{db.Name}.Sanitize()
+
+	var owner string
+	err := admin.QueryRow(ctx, "SELECT pg_get_userbyid(datdba) FROM pg_database WHERE datname = $1", db.Name).Scan(&owner)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		sql := "CREATE DATABASE " + ident
+		if db.Owner != "" {
+			sql += " OWNER " + pgx.Identifier{db.Owner}.Sanitize()
+		}
+		if _, err := admin.Exec(ctx, sql); err != nil {
+			return result, fmt.Errorf("creating database %s: %w", db.Name, err)
+		}
+		result.Action = orchestrator.ActionCreated
+	case err != nil:
+		return result, fmt.Errorf("looking up database %s: %w", db.Name, err)
+	case db.Owner != "" && owner != db.Owner:
+		if _, err := admin.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", ident, pgx.Identifier{db.Owner}.Sanitize())); err != nil {
+			return result, fmt.Errorf("changing owner of database %s: %w", db.Name, err)
+		}
+		result.Action = orchestrator.ActionUpdated
+		result.Detail = fmt.Sprintf("owner %s -> %s", owner, db.Owner)
+	}
+	return result, nil
+}
+
+// grantRole lets role connect to its database and applies its schema grants
+// there, opening a second connection when that is not the bootstrap database.
+func (c *PostgresClient) grantRole(ctx context.Context, admin pgAdmin, role config.PostgresRole, results *[]orchestrator.ResourceResult) error {
+	roleIdent := pgx.Identifier{role.Name}.Sanitize()
+	if _, err := admin.Exec(ctx, fmt.Sprintf("GRANT CONNECT ON DATABASE %s TO %s", pgx.Identifier{role.Database}.Sanitize(), roleIdent)); err != nil {
+		return fmt.Errorf("granting connect on %s to %s: %w", role.Database, role.Name, err)
+	}
+	if len(role.Schemas) == 0 {
+		return nil
+	}
+
+	db := admin
+	if role.Database != c.cfg.DB {
+		cfg := c.cfg
+		cfg.DB = role.Database
+		conn, err := c.connectAdmin(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("connecting to database %s: %w", role.Database, err)
+		}
+		defer conn.Close()
+		db = conn
+	}
+
+	for _, grant := range role.Schemas {
+		scope := role.Database + "/" + grant.Schema
+
+		var exists bool
+		if err := db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)", grant.Schema).Scan(&exists); err != nil {
+			return fmt.Errorf("looking up schema %s: %w", scope, err)
+		}
+		schemaResult := orchestrator.ResourceResult{Resource: "schema:" + scope, Action: orchestrator.ActionUnchanged}
+		if !exists {
+			if _, err := db.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{grant.Schema}.Sanitize()); err != nil {
+				return fmt.Errorf("creating schema %s: %w", scope, err)
+			}
+			schemaResult.Action = orchestrator.ActionCreated
+		}
+		*results = append(*results, schemaResult)
+
+		var hadUsage bool
+		if err := db.QueryRow(ctx, "SELECT has_schema_privilege($1, $2, 'USAGE')", role.Name, grant.Schema).Scan(&hadUsage); err != nil {
+			return fmt.Errorf("checking %s privileges on %s: %w", role.Name, scope, err)
+		}
+		if _, err := db.Exec(ctx, grantSQL(role.Name, grant)); err != nil {
+			return fmt.Errorf("granting %s on %s: %w", role.Name, scope, err)
+		}
+		action := orchestrator.ActionUnchanged
+		if !hadUsage {
+			action = orchestrator.ActionCreated
+		}
+		*results = append(*results, orchestrator.ResourceResult{
+			Resource: "grant:" + role.Name + "@" + scope,
+			Action:   action,
+			Detail:   strings.Join(normalizePrivileges(grant.Privileges), ", "),
+		})
+	}
+	return nil
+}
+
+// grantSQL renders the statements that bring role's privileges on the
+// schema to exactly grant.Privileges, for existing tables and for tables the
+// bootstrap user creates later. It runs as one simple-protocol batch.
+func grantSQL(role string, grant config.PostgresSchemaGrant) string {
+	r := pgx.Identifier{role}.Sanitize()
+	s := pgx.Identifier{grant.Schema}.Sanitize()
+	privileges := normalizePrivileges(grant.Privileges)
+	var revoked []string
+	for _, p := range config.PostgresTablePrivileges {
+		if !slices.Contains(privileges, p) {
+			revoked = append(revoked, p)
+		}
+	}
+
+	granted := strings.Join(privileges, ", ")
+	stmts := []string{
+		fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", s, r),
+		fmt.Sprintf("REVOKE CREATE ON SCHEMA %s FROM %s", s, r),
+		fmt.Sprintf("GRANT %s ON ALL TABLES IN SCHEMA %s TO %s", granted, s, r),
+		fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT %s ON TABLES TO %s", s, granted, r),
+	}
+	if len(revoked) > 0 {
+		revokedList := strings.Join(revoked, ", ")
+		stmts = append(stmts,
+			fmt.Sprintf("REVOKE %s ON ALL TABLES IN SCHEMA %s FROM %s", revokedList, s, r),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s REVOKE %s ON TABLES FROM %s", s, revokedList, r),
+		)
+	}
+	if slices.Contains(privileges, "INSERT") {
+		stmts = append(stmts,
+			fmt.Sprintf("GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA %s TO %s", s, r),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT USAGE, SELECT ON SEQUENCES TO %s", s, r),
+		)
+	} else {
+		stmts = append(stmts,
+			fmt.Sprintf("REVOKE ALL ON ALL SEQUENCES IN SCHEMA %s FROM %s", s, r),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s REVOKE ALL ON SEQUENCES FROM %s", s, r),
+		)
+	}
+	return strings.Join(stmts, ";\n") + ";"
+}
+
+// normalizePrivileges upper-cases privileges and orders them as
+// config.PostgresTablePrivileges does.
+func normalizePrivileges(privileges []string) []string {
+	var out []string
+	for _, p := range config.PostgresTablePrivileges {
+		if slices.ContainsFunc(privileges, func(q string) bool { return strings.EqualFold(p, q) }) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// generatePassword returns 32 random characters from the URL-safe base64
+// alphabet.
+func generatePassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// quoteLiteral renders s as a SQL string literal. Role passwords cannot be
+// bound as parameters in CREATE ROLE or ALTER ROLE. Backslashes are literal
+// because standard_conforming_strings is on by default.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// realConnectAdmin opens a short-lived pool as the bootstrap user in a
+// database other than the bootstrap one.
+func realConnectAdmin(ctx context.Context, cfg config.PostgresConfig) (pgAdmin, error) {
+	return OpenPostgresPool(ctx, cfg)
+}
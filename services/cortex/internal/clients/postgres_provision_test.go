@@ -0,0 +1,327 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/migrate"
+	"arc-framework/cortex/internal/orchestrator"
+	"arc-framework/cortex/internal/secrets"
+)
+
+// fakeCatalog answers the catalog lookups made by Provision from in-memory
+// state and records every statement, prefixed with the database it ran in.
+type fakeCatalog struct {
+	mu        sync.Mutex
+	roles     map[string]bool
+	databases map[string]string // name -> owner
+	schemas   map[string]bool   // database/schema
+	usage     map[string]bool   // role@database/schema
+	// extensions maps a name to its default and installed versions.
+	extensions map[string][2]string
+	execs      []string
+}
+
+// catalogConn is a pgAdmin connected to one database of a fakeCatalog.
+type catalogConn struct {
+	cat      *fakeCatalog
+	database string
+}
+
+func (c *catalogConn) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	f := c.cat
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case strings.Contains(sql, "pg_roles"):
+		return fakeRow{vals: []any{f.roles[args[0].(string)]}}
+	case strings.Contains(sql, "pg_database"):
+		owner, ok := f.databases[args[0].(string)]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{vals: []any{owner}}
+	case strings.Contains(sql, "pg_namespace"):
+		return fakeRow{vals: []any{f.schemas[c.database+"/"+args[0].(string)]}}
+	case strings.Contains(sql, "pg_available_extensions"):
+		ext, ok := f.extensions[args[0].(string)]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{vals: []any{ext[0], ext[1]}}
+	case strings.Contains(sql, "has_schema_privilege"):
+		return fakeRow{vals: []any{f.usage[args[0].(string)+"@"+c.database+"/"+args[1].(string)]}}
+	}
+	return fakeRow{err: errors.New("unexpected query: " + sql)}
+}
+
+func (c *catalogConn) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	c.cat.mu.Lock()
+	defer c.cat.mu.Unlock()
+	c.cat.execs = append(c.cat.execs, c.database+": "+sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *catalogConn) Ping(context.Context) error { return nil }
+
+func (c *catalogConn) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query: " + sql)
+}
+
+func (c *catalogConn) Close() {}
+
+// memSecrets is an in-memory secretStore.
+type memSecrets map[string]map[string]string
+
+func (m memSecrets) Read(_ context.Context, path string) (map[string]string, error) {
+	data, ok := m[path]
+	if !ok {
+		return nil, secrets.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m memSecrets) Write(_ context.Context, path string, data map[string]string) error {
+	m[path] = data
+	return nil
+}
+
+// newProvisionClient returns a client whose connections share cat.
+func newProvisionClient(t *testing.T, cfg config.PostgresConfig, cat *fakeCatalog) *PostgresClient {
+	t.Helper()
+	c := NewPostgresClient(cfg, NewCircuitBreaker("test-provision-"+t.Name()))
+	c.connect = func(_ context.Context, cfg config.PostgresConfig) (pgPool, error) {
+		return &catalogConn{cat: cat, database: cfg.DB}, nil
+	}
+	c.connectAdmin = func(_ context.Context, cfg config.PostgresConfig) (pgAdmin, error) {
+		return &catalogConn{cat: cat, database: cfg.DB}, nil
+	}
+	return c
+}
+
+func newCatalog() *fakeCatalog {
+	return &fakeCatalog{
+		roles:     map[string]bool{},
+		databases: map[string]string{"arc": "arc"},
+		schemas:   map[string]bool{},
+		usage:     map[string]bool{},
+	}
+}
+
+func execsContaining(execs []string, sub string) []string {
+	var out []string
+	for _, e := range execs {
+		if strings.Contains(e, sub) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestProvision_Disabled(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	c := newProvisionClient(t, config.PostgresConfig{DB: "arc"}, cat)
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Empty(t, cat.execs)
+}
+
+func TestProvision_AutoMigrations(t *testing.T) {
+	t.Parallel()
+	known := []migrate.Migration{
+		{Version: 2, Name: "rename_schema", Service: "reasoner"},
+		{Version: 3, Name: "enable_pgvector", Service: migrate.EmbeddedService},
+	}
+	tests := []struct {
+		name    string
+		upErr   error
+		applied []migrate.Migration
+		wantErr string
+	}{
+		{name: "applies pending", applied: known},
+		{name: "stops at failure", applied: known[:1], upErr: errors.New("syntax error"),
+			wantErr: "applying migrations: syntax error"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := newProvisionClient(t, config.PostgresConfig{
+				DB: "arc", Migrations: config.PostgresMigrationsConfig{Auto: true},
+			}, newCatalog())
+			c.migrations = func() ([]migrate.Migration, error) { return known, nil }
+			var got []migrate.Migration
+			c.migrateUp = func(_ context.Context, ms []migrate.Migration) ([]migrate.Step, error) {
+				got = ms
+				steps := make([]migrate.Step, len(tc.applied))
+				for i, m := range tc.applied {
+					steps[i] = migrate.Step{Migration: m, Direction: migrate.DirectionUp}
+				}
+				return steps, tc.upErr
+			}
+
+			results, err := c.Provision(context.Background())
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, known, got)
+			want := []orchestrator.ResourceResult{
+				{Resource: "migration:reasoner/2_rename_schema", Action: orchestrator.ActionCreated},
+				{Resource: "migration:platform/3_enable_pgvector", Action: orchestrator.ActionCreated},
+			}
+			assert.Equal(t, want[:len(tc.applied)], results)
+		})
+	}
+}
+
+func TestProvision_DefaultLayoutOnEmptyServer(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	store := memSecrets{}
+	c := newProvisionClient(t, config.PostgresConfig{DB: "arc", User: "arc", Provision: true}, cat)
+	c.WithSecrets(store, "arc/postgres")
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, results, orchestrator.ResourceResult{Resource: "role:reasoner", Action: orchestrator.ActionCreated})
+	assert.Contains(t, results, orchestrator.ResourceResult{Resource: "database:unleash", Action: orchestrator.ActionCreated})
+	assert.Contains(t, results, orchestrator.ResourceResult{Resource: "schema:arc/audit", Action: orchestrator.ActionCreated})
+	assert.Contains(t, results, orchestrator.ResourceResult{
+		Resource: "grant:audit@arc/audit", Action: orchestrator.ActionCreated, Detail: "SELECT, INSERT",
+	})
+	assert.Contains(t, results, orchestrator.ResourceResult{
+		Resource: "grant:reasoner@arc/reasoner", Action: orchestrator.ActionCreated, Detail: "SELECT, INSERT, UPDATE, DELETE",
+	})
+
+	for _, role := range []string{"reasoner", "cortex", "audit"} {
+		secret := store["arc/postgres/"+role]
+		require.NotNil(t, secret, role)
+		assert.Equal(t, role, secret["username"])
+		assert.Len(t, secret["password"], 32)
+		assert.Len(t, execsContaining(cat.execs, `CREATE ROLE "`+role+`" LOGIN PASSWORD '`+secret["password"]+`'`), 1)
+	}
+	assert.Len(t, execsContaining(cat.execs, `CREATE DATABASE "unleash"`), 1)
+
+	// The audit role is append-only: everything but SELECT and INSERT is
+	// revoked, while sequences stay usable for the bigserial id.
+	audit := execsContaining(cat.execs, `"audit" FROM "audit"`)
+	assert.NotEmpty(t, execsContaining(audit, `REVOKE UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON ALL TABLES IN SCHEMA "audit" FROM "audit"`))
+	assert.NotEmpty(t, execsContaining(cat.execs, `GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA "audit" TO "audit"`))
+}
+
+func TestProvision_ReusesStoredPasswords(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	cat.roles["reasoner"] = true
+	cat.schemas["arc/reasoner"] = true
+	cat.usage["reasoner@arc/reasoner"] = true
+	store := memSecrets{"arc/postgres/reasoner": {"username": "reasoner", "password": "stored-pw"}}
+
+	cfg := config.PostgresConfig{
+		DB: "arc", User: "arc", Provision: true,
+		Databases: []config.PostgresDatabase{{Name: "arc"}},
+		Roles:     []config.PostgresRole{{Name: "reasoner", Schemas: []config.PostgresSchemaGrant{{Schema: "reasoner"}}}},
+	}
+	c := newProvisionClient(t, cfg, cat).WithSecrets(store, "arc/postgres")
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []orchestrator.ResourceResult{
+		{Resource: "role:reasoner", Action: orchestrator.ActionUnchanged},
+		{Resource: "database:arc", Action: orchestrator.ActionUnchanged},
+		{Resource: "schema:arc/reasoner", Action: orchestrator.ActionUnchanged},
+		{Resource: "grant:reasoner@arc/reasoner", Action: orchestrator.ActionUnchanged, Detail: "SELECT, INSERT, UPDATE, DELETE"},
+	}, results)
+	assert.Len(t, execsContaining(cat.execs, `ALTER ROLE "reasoner" WITH LOGIN PASSWORD 'stored-pw'`), 1)
+	assert.Equal(t, "stored-pw", store["arc/postgres/reasoner"]["password"])
+}
+
+func TestProvision_ExistingRoleWithoutSecrets(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	cat.roles["cortex"] = true
+	cfg := config.PostgresConfig{
+		DB: "arc", User: "arc", Provision: true,
+		Roles: []config.PostgresRole{{Name: "cortex"}, {Name: "worker"}},
+	}
+	c := newProvisionClient(t, cfg, cat)
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.ResourceResult{Resource: "role:cortex", Action: orchestrator.ActionUnchanged}, results[0])
+	assert.Equal(t, orchestrator.ActionConflict, results[1].Action)
+	assert.Contains(t, results[1].Detail, "no password source")
+	assert.Len(t, execsContaining(cat.execs, `ALTER ROLE "cortex" WITH LOGIN`), 1)
+	assert.Empty(t, execsContaining(cat.execs, `ALTER ROLE "cortex" WITH LOGIN PASSWORD`))
+	assert.Empty(t, execsContaining(cat.execs, `"worker"`))
+}
+
+func TestProvision_SkipsObjectsOfUncreatedRoles(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	cfg := config.PostgresConfig{
+		DB: "arc", User: "arc", Provision: true,
+		Databases: []config.PostgresDatabase{{Name: "unleash", Owner: "unleash"}},
+		Roles: []config.PostgresRole{{
+			Name: "unleash", Database: "unleash",
+			Schemas: []config.PostgresSchemaGrant{{Schema: "public"}},
+		}},
+	}
+	c := newProvisionClient(t, cfg, cat)
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []orchestrator.ResourceResult{
+		{Resource: "role:unleash", Action: orchestrator.ActionConflict,
+			Detail: "no password source: set password or password_file, or enable openbao"},
+		{Resource: "database:unleash", Action: orchestrator.ActionConflict, Detail: "owner role unleash was not created"},
+	}, results)
+	assert.Empty(t, cat.execs)
+}
+
+func TestProvision_OtherDatabaseAndOwner(t *testing.T) {
+	t.Parallel()
+	cat := newCatalog()
+	cat.databases["unleash"] = "arc"
+	cfg := config.PostgresConfig{
+		DB: "arc", User: "arc", Provision: true,
+		Databases: []config.PostgresDatabase{{Name: "unleash", Owner: "unleash"}},
+		Roles: []config.PostgresRole{{
+			Name: "unleash", Database: "unleash", Password: "it's-a-secret",
+			Schemas: []config.PostgresSchemaGrant{{Schema: "public", Privileges: []string{"select"}}},
+		}},
+	}
+	c := newProvisionClient(t, cfg, cat)
+
+	results, err := c.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, results, orchestrator.ResourceResult{Resource: "database:unleash", Action: orchestrator.ActionUpdated, Detail: "owner arc -> unleash"})
+	assert.Contains(t, results, orchestrator.ResourceResult{Resource: "grant:unleash@unleash/public", Action: orchestrator.ActionCreated, Detail: "SELECT"})
+
+	assert.Len(t, execsContaining(cat.execs, `arc: CREATE ROLE "unleash" LOGIN PASSWORD 'it''s-a-secret'`), 1)
+	assert.Len(t, execsContaining(cat.execs, `arc: GRANT CONNECT ON DATABASE "unleash" TO "unleash"`), 1)
+	assert.NotEmpty(t, execsContaining(cat.execs, `unleash: GRANT USAGE ON SCHEMA "public" TO "unleash"`))
+	assert.NotEmpty(t, execsContaining(cat.execs, `REVOKE ALL ON ALL SEQUENCES IN SCHEMA "public" FROM "unleash"`))
+}
+
+func TestGrantSQL_QuotesIdentifiers(t *testing.T) {
+	t.Parallel()
+	sql := grantSQL(`we"ird`, config.PostgresSchemaGrant{Schema: "my schema", Privileges: []string{"SELECT"}})
+	assert.Contains(t, sql, `GRANT USAGE ON SCHEMA "my schema" TO "we""ird"`)
+	assert.Contains(t, sql, `GRANT SELECT ON ALL TABLES IN SCHEMA "my schema" TO "we""ird"`)
+}
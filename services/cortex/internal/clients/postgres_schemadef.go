@@ -0,0 +1,244 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// schemaDef is the part of a schema DumpSchema can rebuild without pg_dump:
+// sequences, ordinary tables with their columns, constraints and indexes.
+type schemaDef struct {
+	schema      string
+	sequences   []sequenceDef
+	tables      []tableDef
+	constraints []constraintDef
+	indexes     []string
+}
+
+type sequenceDef struct {
+	name                             string
+	dataType                         string
+	start, increment, minVal, maxVal int64
+	cycle                            bool
+	// lastValue is nil when the sequence has never been used.
+	lastValue *int64
+	// ownerTable and ownerColumn are set for serial and identity columns;
+	// identity sequences are recreated by the column definition.
+	ownerTable, ownerColumn string
+	identity                bool
+}
+
+type tableDef struct {
+	name    string
+	columns []columnDef
+}
+
+type columnDef struct {
+	name, typ string
+	notNull   bool
+	// expr is the default, or the generation expression when generated is
+	// set ('s' stored, 'v' virtual). identity is 'a' (always) or 'd' (by
+	// default) for identity columns.
+	expr      string
+	generated string
+	identity  string
+}
+
+type constraintDef struct {
+	table, name, def string
+}
+
+// copyColumns lists the columns COPY reads and writes. Generated columns are
+// recomputed by the server, so they are left out.
+func (t tableDef) copyColumns() []string {
+	var out []string
+	for _, c := range t.columns {
+		if c.generated == "" {
+			out = append(out, c.name)
+		}
+	}
+	return out
+}
+
+// readSchemaDef reads schema from the catalog.
+func readSchemaDef(ctx context.Context, conn copyConn, schema string) (*schemaDef, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)", schema).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("looking up schema %s: %w", schema, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("schema %s does not exist", schema)
+	}
+	def := &schemaDef{schema: schema}
+
+	rows, err := conn.Query(ctx, `
+		SELECT s.sequencename, s.data_type::text, s.start_value, s.increment_by, s.min_value, s.max_value,
+		       s.cycle, s.last_value, COALESCE(t.relname, ''), COALESCE(a.attname, ''), COALESCE(d.deptype = 'i', false)
+		FROM pg_sequences s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class sc ON sc.relname = s.sequencename AND sc.relnamespace = n.oid
+		LEFT JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = sc.oid
+		     AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+		LEFT JOIN pg_class t ON t.oid = d.refobjid
+		LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE s.schemaname = $1
+		ORDER BY s.sequencename`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("reading sequences: %w", err)
+	}
+	var seq sequenceDef
+	_, err = pgx.ForEachRow(rows, []any{
+		&seq.name, &seq.dataType, &seq.start, &seq.increment, &seq.minVal, &seq.maxVal,
+		&seq.cycle, &seq.lastValue, &seq.ownerTable, &seq.ownerColumn, &seq.identity,
+	}, func() error {
+		def.sequences = append(def.sequences, seq)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading sequences: %w", err)
+	}
+
+	rows, err = conn.Query(ctx, `
+		SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+		       COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attgenerated::text, a.attidentity::text
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind = 'r' AND NOT c.relispartition
+		ORDER BY c.relname, a.attnum`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("reading tables: %w", err)
+	}
+	var table string
+	var col columnDef
+	_, err = pgx.ForEachRow(rows, []any{&table, &col.name, &col.typ, &col.notNull, &col.expr, &col.generated, &col.identity}, func() error {
+		if n := len(def.tables); n == 0 || def.tables[n-1].name != table {
+			def.tables = append(def.tables, tableDef{name: table})
+		}
+		t := &def.tables[len(def.tables)-1]
+		t.columns = append(t.columns, col)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading tables: %w", err)
+	}
+
+	// Foreign keys sort last so the tables they reference have their
+	// primary keys first.
+	rows, err = conn.Query(ctx, `
+		SELECT c.relname, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind = 'r' AND con.contype IN ('p', 'u', 'c', 'f', 'x') AND con.conislocal
+		ORDER BY con.contype = 'f', c.relname, con.conname`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("reading constraints: %w", err)
+	}
+	var con constraintDef
+	_, err = pgx.ForEachRow(rows, []any{&con.table, &con.name, &con.def}, func() error {
+		def.constraints = append(def.constraints, con)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading constraints: %w", err)
+	}
+
+	// Indexes backing a primary key, unique or exclusion constraint are
+	// created by the constraint.
+	rows, err = conn.Query(ctx, `
+		SELECT pg_get_indexdef(x.indexrelid)
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1 AND t.relkind = 'r'
+		  AND NOT EXISTS (SELECT 1 FROM pg_constraint con
+		                  WHERE con.conindid = x.indexrelid AND con.contype IN ('p', 'u', 'x'))
+		ORDER BY i.relname`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("reading indexes: %w", err)
+	}
+	if def.indexes, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+		return nil, fmt.Errorf("reading indexes: %w", err)
+	}
+	return def, nil
+}
+
+// preData creates the schema, its sequences and tables.
+func (d *schemaDef) preData() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE SCHEMA IF NOT EXISTS %s;\n", pgx.Identifier{d.schema}.Sanitize())
+	for _, s := range d.sequences {
+		if s.identity {
+			continue
+		}
+		cycle := "NO CYCLE"
+		if s.cycle {
+			cycle = "CYCLE"
+		}
+		fmt.Fprintf(&b, "CREATE SEQUENCE %s AS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d %s;\n",
+			d.ident(s.name), s.dataType, s.increment, s.minVal, s.maxVal, s.start, cycle)
+	}
+	for _, t := range d.tables {
+		cols := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			col := pgx.Identifier{c.name}.Sanitize() + " " + c.typ
+			switch {
+			case c.generated == "s":
+				col += " GENERATED ALWAYS AS (" + c.expr + ") STORED"
+			case c.generated != "":
+				col += " GENERATED ALWAYS AS (" + c.expr + ")"
+			case c.identity == "a":
+				col += " GENERATED ALWAYS AS IDENTITY"
+			case c.identity == "d":
+				col += " GENERATED BY DEFAULT AS IDENTITY"
+			case c.expr != "":
+				col += " DEFAULT " + c.expr
+			}
+			if c.notNull {
+				col += " NOT NULL"
+			}
+			cols[i] = "    " + col
+		}
+		fmt.Fprintf(&b, "CREATE TABLE %s (\n%s\n);\n", d.ident(t.name), strings.Join(cols, ",\n"))
+	}
+	for _, s := range d.sequences {
+		if !s.identity && s.ownerTable != "" {
+			fmt.Fprintf(&b, "ALTER SEQUENCE %s OWNED BY %s;\n",
+				d.ident(s.name), pgx.Identifier{d.schema, s.ownerTable, s.ownerColumn}.Sanitize())
+		}
+	}
+	return b.String()
+}
+
+// postData adds constraints and indexes and moves every sequence to the
+// position it had when the dump was taken.
+func (d *schemaDef) postData() string {
+	var b strings.Builder
+	for _, c := range d.constraints {
+		fmt.Fprintf(&b, "ALTER TABLE %s ADD CONSTRAINT %s %s;\n", d.ident(c.table), pgx.Identifier{c.name}.Sanitize(), c.def)
+	}
+	for _, idx := range d.indexes {
+		b.WriteString(idx + ";\n")
+	}
+	for _, s := range d.sequences {
+		if s.lastValue == nil {
+			continue
+		}
+		seq := quoteLiteral(d.ident(s.name))
+		if s.identity {
+			seq = fmt.Sprintf("pg_get_serial_sequence(%s, %s)", quoteLiteral(d.ident(s.ownerTable)), quoteLiteral(s.ownerColumn))
+		}
+		fmt.Fprintf(&b, "SELECT setval(%s, %d, true);\n", seq, *s.lastValue)
+	}
+	return b.String()
+}
+
+func (d *schemaDef) ident(name string) string {
+	return pgx.Identifier{d.schema, name}.Sanitize()
+}
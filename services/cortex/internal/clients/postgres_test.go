@@ -3,13 +3,20 @@ package clients
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/sony/gobreaker"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/dbschema"
+	"arc-framework/cortex/internal/migrate"
 )
 
 // mockRow implements pgx.Row for use in tests.
@@ -32,27 +39,127 @@ func (r *mockRow) Scan(dest ...any) error {
 	return nil
 }
 
-// mockDB implements dbPinger for use in tests.
+// scanValues assigns vals to the pointers in dest, as pgx would for the
+// column types used by the Postgres client.
+func scanValues(dest []any, vals []any) error {
+	if len(dest) != len(vals) {
+		return fmt.Errorf("scan: %d destinations for %d values", len(dest), len(vals))
+	}
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *int:
+			*d = vals[i].(int)
+		case *int64:
+			*d = vals[i].(int64)
+		case **int64:
+			if vals[i] == nil {
+				*d = nil
+			} else {
+				v := vals[i].(int64)
+				*d = &v
+			}
+		case *float64:
+			*d = vals[i].(float64)
+		case *bool:
+			*d = vals[i].(bool)
+		case *string:
+			*d = vals[i].(string)
+		case *[]string:
+			*d = vals[i].([]string)
+		default:
+			return fmt.Errorf("scan: unsupported destination %T", d)
+		}
+	}
+	return nil
+}
+
+// fakeRow implements pgx.Row over fixed values.
+type fakeRow struct {
+	vals []any
+	err  error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return scanValues(dest, r.vals)
+}
+
+// mockRows implements pgx.Rows over fixed rows.
+type mockRows struct {
+	rows [][]any
+	i    int
+}
+
+func (r *mockRows) Close()                                       {}
+func (r *mockRows) Err() error                                   { return nil }
+func (r *mockRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *mockRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *mockRows) Values() ([]any, error)                       { return nil, nil }
+func (r *mockRows) RawValues() [][]byte                          { return nil }
+func (r *mockRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *mockRows) Next() bool {
+	r.i++
+	return r.i <= len(r.rows)
+}
+
+func (r *mockRows) Scan(dest ...any) error { return scanValues(dest, r.rows[r.i-1]) }
+
+// mockDB implements pgPool for use in tests. The server defaults to 17.2
+// with vector 0.8.0 installed, which meets the default requirements.
 type mockDB struct {
 	pingErr  error
 	queryRow pgx.Row
+	applied  []migrate.Applied
 	closed   bool
+
+	serverVersion string
+	serverNum     int
+	extensions    [][]any // name, default_version, installed_version
 }
 
-func (m *mockDB) Ping(_ context.Context) error   { return m.pingErr }
-func (m *mockDB) Close()                         { m.closed = true }
-func (m *mockDB) QueryRow(_ context.Context, _ string, _ ...any) pgx.Row {
+func (m *mockDB) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	if strings.Contains(sql, "pg_available_extensions") {
+		exts := m.extensions
+		if exts == nil {
+			exts = [][]any{{"plpgsql", "1.0", "1.0"}, {"vector", "0.8.0", "0.8.0"}}
+		}
+		return &mockRows{rows: exts}, nil
+	}
+	rows := make([][]any, len(m.applied))
+	for i, a := range m.applied {
+		rows[i] = []any{a.Version, a.Dirty, a.Service}
+	}
+	return &mockRows{rows: rows}, nil
+}
+
+func (m *mockDB) Ping(_ context.Context) error { return m.pingErr }
+func (m *mockDB) Close()                       { m.closed = true }
+func (m *mockDB) Exec(_ context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+func (m *mockDB) QueryRow(_ context.Context, sql string, _ ...any) pgx.Row {
+	if strings.Contains(sql, "server_version") {
+		if m.serverNum == 0 {
+			return fakeRow{vals: []any{"17.2", 170002}}
+		}
+		return fakeRow{vals: []any{m.serverVersion, m.serverNum}}
+	}
 	return m.queryRow
 }
 
 // makeClient returns a PostgresClient with a stubbed connect function.
-func makeClient(db dbPinger, connectErr error, cb *gobreaker.CircuitBreaker) *PostgresClient {
+func makeClient(db pgPool, connectErr error, cb *gobreaker.CircuitBreaker) *PostgresClient {
 	return &PostgresClient{
 		cfg: config.PostgresConfig{},
 		cb:  cb,
-		connect: func(_ context.Context, _ config.PostgresConfig) (dbPinger, error) {
+		connect: func(_ context.Context, _ config.PostgresConfig) (pgPool, error) {
 			return db, connectErr
 		},
+		migrations:   func() ([]migrate.Migration, error) { return nil, nil },
+		expectations: func() ([]dbschema.Expectation, error) { return nil, nil },
 	}
 }
 
@@ -125,6 +232,213 @@ func TestProbe(t *testing.T) {
 	}
 }
 
+func TestProbe_Migrations(t *testing.T) {
+	t.Parallel()
+
+	known := []migrate.Migration{
+		{Version: 1, Name: "init", Service: "platform"},
+		{Version: 2, Name: "pgvector", Service: "platform"},
+		{Version: 10, Name: "chunks", Service: "reasoner"},
+	}
+	tests := []struct {
+		name         string
+		applied      []migrate.Applied
+		wantOK       bool
+		wantErr      string
+		wantWarnings []string
+		wantStates   map[string]string
+	}{
+		{
+			name:       "current",
+			applied:    []migrate.Applied{{Version: 1}, {Version: 2}, {Version: 10}},
+			wantOK:     true,
+			wantStates: map[string]string{"platform": migrate.StateCurrent, "reasoner": migrate.StateCurrent},
+		},
+		{
+			name:    "dirty fails the probe",
+			applied: []migrate.Applied{{Version: 1}, {Version: 2}, {Version: 10, Dirty: true}},
+			wantErr: "dirty migrations: reasoner version 10",
+		},
+		{
+			name:         "behind",
+			applied:      []migrate.Applied{{Version: 1}},
+			wantOK:       true,
+			wantWarnings: []string{"platform migrations behind: at version 1, latest 2 (pending 2); run `cortex migrate up` or enable bootstrap.postgres.migrations.auto", "reasoner migrations behind: at version 0, latest 10 (pending 10); run `cortex migrate up` or enable bootstrap.postgres.migrations.auto"},
+			wantStates:   map[string]string{"platform": migrate.StateBehind, "reasoner": migrate.StateBehind},
+		},
+		{
+			name:         "ahead",
+			applied:      []migrate.Applied{{Version: 1}, {Version: 2}, {Version: 10}, {Version: 11, Service: "reasoner"}},
+			wantOK:       true,
+			wantWarnings: []string{"reasoner migrations ahead: version 11 applied but unknown (latest known 10)"},
+			wantStates:   map[string]string{"platform": migrate.StateCurrent, "reasoner": migrate.StateAhead},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := makeClient(&mockDB{queryRow: &mockRow{val: 1}, applied: tc.applied}, nil, NewCircuitBreaker("test-migrations-"+tc.name))
+			client.migrations = func() ([]migrate.Migration, error) { return known, nil }
+
+			result := client.Probe(context.Background())
+			assert.Equal(t, tc.wantOK, result.OK)
+			assert.Equal(t, tc.wantErr, result.Error)
+			assert.Equal(t, tc.wantWarnings, result.Warnings)
+			assert.Equal(t, len(tc.wantWarnings) > 0, result.Degraded)
+
+			health, ok := result.Details.(*postgresHealth)
+			require.True(t, ok)
+			for _, s := range health.Migrations {
+				if want, ok := tc.wantStates[s.Service]; ok {
+					assert.Equal(t, want, s.State, s.Service)
+				}
+			}
+		})
+	}
+}
+
+func TestProbe_MigrationsLoadError(t *testing.T) {
+	t.Parallel()
+
+	client := makeClient(&mockDB{queryRow: &mockRow{val: 1}}, nil, NewCircuitBreaker("test-migrations-load"))
+	client.migrations = func() ([]migrate.Migration, error) { return nil, errors.New("bad file name") }
+
+	result := client.Probe(context.Background())
+	assert.False(t, result.OK)
+	assert.Equal(t, "loading migrations: bad file name", result.Error)
+}
+
+// catalogDB answers the dbschema catalog queries on top of a healthy mockDB.
+type catalogDB struct {
+	mockDB
+	columns [][]any // schema, table, column, type, not null
+	indexes [][]any // schema, table, index, method, columns, opclasses
+}
+
+func (d *catalogDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	switch {
+	case strings.Contains(sql, "FROM pg_namespace"):
+		return &mockRows{rows: [][]any{{"reasoner"}}}, nil
+	case strings.Contains(sql, "FROM pg_attribute"):
+		return &mockRows{rows: d.columns}, nil
+	case strings.Contains(sql, "FROM pg_index"):
+		return &mockRows{rows: d.indexes}, nil
+	}
+	return d.mockDB.Query(ctx, sql, args...)
+}
+
+func TestProbe_SchemaExpectations(t *testing.T) {
+	t.Parallel()
+
+	exps := []dbschema.Expectation{{Service: "reasoner", Schemas: []dbschema.SchemaSpec{{
+		Name: "reasoner",
+		Tables: []dbschema.TableSpec{{
+			Name:    "knowledge_chunks",
+			Columns: []dbschema.ColumnSpec{{Name: "embedding", Type: "vector(384)"}},
+			Indexes: []dbschema.IndexSpec{{Name: "idx_embedding", Method: "hnsw", OpClasses: []string{"vector_cosine_ops"}, Columns: []string{"embedding"}}},
+		}},
+	}}}}
+	tests := []struct {
+		name    string
+		colType string
+		method  string
+		wantErr string
+	}{
+		{name: "matches", colType: "vector(384)", method: "hnsw"},
+		{
+			name: "mismatch fails the probe", colType: "vector(768)", method: "ivfflat",
+			wantErr: "reasoner schema: column reasoner.knowledge_chunks.embedding is vector(768), want vector(384), " +
+				"index idx_embedding on reasoner.knowledge_chunks uses ivfflat, want hnsw",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := &catalogDB{
+				mockDB:  mockDB{queryRow: &mockRow{val: 1}},
+				columns: [][]any{{"reasoner", "knowledge_chunks", "embedding", tc.colType, false}},
+				indexes: [][]any{{"reasoner", "knowledge_chunks", "idx_embedding", tc.method, []string{"embedding"}, []string{"vector_cosine_ops"}}},
+			}
+			client := makeClient(db, nil, NewCircuitBreaker("test-schema-"+tc.name))
+			client.expectations = func() ([]dbschema.Expectation, error) { return exps, nil }
+
+			result := client.Probe(context.Background())
+			assert.Equal(t, tc.wantErr == "", result.OK)
+			assert.Equal(t, tc.wantErr, result.Error)
+			health := result.Details.(*postgresHealth)
+			require.Len(t, health.Schema, 1)
+			assert.Equal(t, tc.wantErr == "", health.Schema[0].OK)
+		})
+	}
+}
+
+func TestProbe_SharesPoolUntilClose(t *testing.T) {
+	t.Parallel()
+
+	var opened []*mockDB
+	client := makeClient(nil, nil, NewCircuitBreaker("test-shared-pool"))
+	client.connect = func(_ context.Context, _ config.PostgresConfig) (pgPool, error) {
+		if len(opened) == 0 {
+			opened = append(opened, nil)
+			return nil, errors.New("dial error")
+		}
+		db := &mockDB{queryRow: &mockRow{val: 1}}
+		opened = append(opened, db)
+		return db, nil
+	}
+
+	// A failed open is not cached.
+	assert.False(t, client.Probe(context.Background()).OK)
+	for range 3 {
+		assert.True(t, client.Probe(context.Background()).OK)
+	}
+	require.Len(t, opened, 2)
+	assert.False(t, opened[1].closed, "probes must not close the shared pool")
+
+	client.Close()
+	assert.True(t, opened[1].closed)
+	client.Close()
+
+	// The pool is reopened after Close.
+	assert.True(t, client.Probe(context.Background()).OK)
+	assert.Len(t, opened, 3)
+}
+
+func TestReadPoolStats(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, readPoolStats(&mockDB{}))
+
+	pool, err := OpenPostgresPool(context.Background(), config.PostgresConfig{
+		Host: "127.0.0.1", Port: 1, User: "arc", DB: "arc", SSLMode: "disable", MaxConns: 7,
+	})
+	require.NoError(t, err)
+	defer pool.Close()
+
+	stats := readPoolStats(pool)
+	require.NotNil(t, stats)
+	assert.Equal(t, int32(7), stats.MaxConns)
+	assert.Zero(t, stats.TotalConns)
+}
+
+func TestOpenPostgresPool_AppliesPoolSettings(t *testing.T) {
+	t.Parallel()
+
+	pool, err := OpenPostgresPool(context.Background(), config.PostgresConfig{
+		Host: "127.0.0.1", Port: 1, User: "arc", DB: "arc", SSLMode: "disable", MaxConns: 3,
+		HealthCheckPeriod: 15 * time.Second, MaxConnIdleTime: 2 * time.Minute,
+	})
+	require.NoError(t, err)
+	defer pool.Close()
+
+	assert.Equal(t, int32(3), pool.Config().MaxConns)
+	assert.Equal(t, 15*time.Second, pool.Config().HealthCheckPeriod)
+	assert.Equal(t, 2*time.Minute, pool.Config().MaxConnIdleTime)
+}
+
 func TestProbeCircuitBreaker_OpensAfterThreeFailures(t *testing.T) {
 	t.Parallel()
 
@@ -1,14 +1,17 @@
 package clients
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/sony/gobreaker"
+	"golang.org/x/sync/errgroup"
 
 	"arc-framework/cortex/internal/config"
 	"arc-framework/cortex/internal/orchestrator"
@@ -16,79 +19,127 @@ import (
 
 const pulsarProbeName = "arc-streaming"
 
-// topicSpec describes a single partitioned topic to provision.
-type topicSpec struct {
-	namespace  string
-	topic      string
-	partitions int
-}
-
-var requiredNamespaces = []string{"events", "logs", "audit"}
-
-var requiredTopics = []topicSpec{
-	{namespace: "events", topic: "agent-lifecycle", partitions: 3},
-	{namespace: "logs", topic: "application", partitions: 4},
-	{namespace: "audit", topic: "command-log", partitions: 1},
-}
-
 // PulsarClient provisions Pulsar tenants, namespaces, and topics via the
 // Pulsar admin REST API, with a circuit breaker around all outbound calls.
 type PulsarClient struct {
 	adminURL string
 	tenant   string
+	layout   []config.PulsarTenant
 	cb       *gobreaker.CircuitBreaker
 	httpDo   func(req *http.Request) (*http.Response, error)
+
+	// maxRetries and retryBackoff govern retries of 5xx responses;
+	// concurrency bounds parallel namespace and topic creation, and the
+	// parallel stats reads of the probe.
+	maxRetries   int
+	retryBackoff time.Duration
+	concurrency  int
+
+	// backlogThreshold is the default per-subscription backlog above which
+	// the probe is degraded; zero disables the check.
+	backlogThreshold int64
+
+	// canary round-trips a message over the binary protocol; nil when the
+	// data-plane probe is disabled.
+	canary      func(ctx context.Context) (time.Duration, error)
+	canaryTopic string
+
+	// dataPlane is nil without a service URL; dataErr records a TLS or
+	// credential problem that prevents opening it.
+	dataPlane *pulsarDataPlane
+	dataErr   error
 }
 
 // NewPulsarClient constructs a PulsarClient. No HTTP calls are made at
 // construction time; they happen lazily inside Provision and Probe.
 func NewPulsarClient(cfg config.PulsarConfig, cb *gobreaker.CircuitBreaker) *PulsarClient {
-	return &PulsarClient{
+	c := &PulsarClient{
 		adminURL: cfg.AdminURL,
 		tenant:   cfg.Tenant,
+		layout:   cfg.Layout(),
 		cb:       cb,
-		httpDo:   http.DefaultClient.Do,
+
+		maxRetries:   cfg.HTTP.MaxRetries,
+		retryBackoff: cfg.HTTP.RetryBackoff,
+		concurrency:  max(cfg.Concurrency, 1),
+
+		backlogThreshold: cfg.BacklogThreshold,
 	}
-}
 
-// Provision creates the tenant, namespaces, and topics required by the ARC
-// platform. The operation is idempotent: HTTP 409 responses are treated as
-// success. The entire sequence is wrapped in the circuit breaker.
-func (c *PulsarClient) Provision(ctx context.Context) error {
-	_, err := c.cb.Execute(func() (any, error) {
-		if err := c.createTenant(ctx); err != nil {
-			return nil, err
+	// A bad TLS file is reported by every request rather than at startup,
+	// matching how the other clients surface connection problems.
+	auth, err := newPulsarAuth(cfg)
+	canaryEnabled := cfg.Canary.Enabled && cfg.ServiceURL != ""
+	if canaryEnabled {
+		c.canaryTopic = cfg.CanaryTopic()
+	}
+	if err != nil {
+		c.httpDo = func(*http.Request) (*http.Response, error) { return nil, err }
+		c.dataErr = err
+		if canaryEnabled {
+			c.canary = func(context.Context) (time.Duration, error) { return 0, err }
 		}
+		return c
+	}
 
-		for _, ns := range requiredNamespaces {
-			if err := c.createNamespace(ctx, ns); err != nil {
-				return nil, err
-			}
-		}
+	c.httpDo = auth.httpClient().Do
+	if cfg.ServiceURL != "" {
+		c.dataPlane = newPulsarDataPlane(cfg, auth)
+	}
+	if canaryEnabled {
+		c.canary = newCanary(cfg, c.dataPlane).run
+	}
+	return c
+}
 
-		for _, spec := range requiredTopics {
-			if err := c.createTopic(ctx, spec); err != nil {
+// Provision creates the tenants, namespaces, and topics declared in the
+// configured layout, registers topic schemas, and returns the outcome for each
+// tenant, topic and schema. The operation is idempotent: existing resources
+// are left in place, existing tenants get the declared admin roles and
+// allowed clusters, and partitioned topics are grown to the declared count. Schema
+// files are loaded before anything is changed. The admin calls are wrapped in
+// the circuit breaker; partition and schema conflicts are reported after it so
+// a misconfigured layout does not trip the breaker.
+func (c *PulsarClient) Provision(ctx context.Context) ([]orchestrator.ResourceResult, error) {
+	schemas, err := c.loadSchemas()
+	if err != nil {
+		return nil, fmt.Errorf("schema plan: %w", err)
+	}
+
+	var results []orchestrator.ResourceResult
+	_, err = c.cb.Execute(func() (any, error) {
+		for _, tenant := range c.layout {
+			if err := c.provisionTenant(ctx, tenant, &results); err != nil {
 				return nil, err
 			}
 		}
-
+		if err := c.provisionSchemas(ctx, schemas, &results); err != nil {
+			return nil, err
+		}
 		return nil, nil
 	})
 
 	if err != nil {
 		if errors.Is(err, gobreaker.ErrOpenState) {
-			return fmt.Errorf("circuit open: %w", err)
+			return results, fmt.Errorf("circuit open: %w", err)
 		}
-		return err
+		return results, err
 	}
-	return nil
+	return results, conflictError(results)
 }
 
-// Probe checks that the Pulsar admin API is reachable by listing tenants.
+// Probe checks that the Pulsar admin API is reachable by listing tenants,
+// then reports stats for every declared topic and the backlog of every
+// declared subscription. Missing topics and subscriptions, topics whose stats
+// cannot be read, backlogs above the threshold, and consumers attached with an
+// unexpected subscription type mark the probe degraded. When the data-plane canary is enabled it also runs,
+// independently of the admin checks and outside the circuit breaker; its
+// round trip is reported under details.canary while LatencyMs stays the admin
+// API latency, and a failed round trip marks the probe degraded.
 func (c *PulsarClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 	start := time.Now()
 
-	_, err := c.cb.Execute(func() (any, error) {
+	out, err := c.cb.Execute(func() (any, error) {
 		url := fmt.Sprintf("%s/admin/v2/tenants", c.adminURL)
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
@@ -105,79 +156,221 @@ func (c *PulsarClient) Probe(ctx context.Context) orchestrator.ProbeResult {
 			return nil, fmt.Errorf("probe returned HTTP %d", resp.StatusCode)
 		}
 
-		return nil, nil
+		return c.collectHealth(ctx), nil
 	})
 
 	latency := time.Since(start).Milliseconds()
 
-	if err != nil {
-		errMsg := err.Error()
-		if errors.Is(err, gobreaker.ErrOpenState) {
-			errMsg = "circuit open"
-		}
-		return orchestrator.ProbeResult{
-			Name:      pulsarProbeName,
-			OK:        false,
-			LatencyMs: latency,
-			Error:     errMsg,
-		}
+	health, _ := out.(*pulsarHealth)
+	if health == nil {
+		health = &pulsarHealth{}
 	}
+	health.Canary = c.runCanary(ctx)
 
-	return orchestrator.ProbeResult{
+	result := orchestrator.ProbeResult{
 		Name:      pulsarProbeName,
 		OK:        true,
 		LatencyMs: latency,
 	}
+	if len(health.Topics) > 0 || len(health.Subscriptions) > 0 || health.Canary != nil {
+		result.Details = health
+	}
+
+	if err != nil {
+		result.OK = false
+		result.Error = err.Error()
+		if errors.Is(err, gobreaker.ErrOpenState) {
+			result.Error = "circuit open"
+		}
+		return result
+	}
+	result.Warnings = evaluatePulsarHealth(health)
+	if health.Canary != nil && health.Canary.Error != "" {
+		result.Warnings = append(result.Warnings, "canary: "+health.Canary.Error)
+	}
+	result.Degraded = len(result.Warnings) > 0
+	return result
 }
 
-// createTenant issues a PUT to create the configured tenant.
-// 204 = created, 409 = already exists (treated as success).
-func (c *PulsarClient) createTenant(ctx context.Context) error {
-	url := fmt.Sprintf("%s/admin/v2/tenants/%s", c.adminURL, c.tenant)
-	body := []byte(`{"allowedClusters":["standalone"]}`)
+// Close releases the data-plane client, if one was opened.
+func (c *PulsarClient) Close() {
+	if c.dataPlane != nil {
+		c.dataPlane.close()
+	}
+}
+
+// provisionTenant creates a tenant, then its namespaces and their policies,
+// then every topic with its subscriptions. Namespaces and topics are created
+// in parallel, at most c.concurrency at a time; the first failure cancels the
+// rest. Topic outcomes are appended to results in declaration order.
+func (c *PulsarClient) provisionTenant(ctx context.Context, tenant config.PulsarTenant, results *[]orchestrator.ResourceResult) error {
+	result, err := c.reconcileTenant(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	*results = append(*results, result)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for _, ns := range tenant.Namespaces {
+		if gctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			if err := c.createNamespace(gctx, tenant.Name, ns.Name); err != nil {
+				return err
+			}
+			return c.applyNamespacePolicies(gctx, tenant.Name, ns)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	type topicRef struct {
+		namespace string
+		topic     config.PulsarTopic
+	}
+	var topics []topicRef
+	for _, ns := range tenant.Namespaces {
+		for _, topic := range ns.Topics {
+			topics = append(topics, topicRef{ns.Name, topic})
+		}
+	}
 
-	return c.putResource(ctx, url, body, fmt.Sprintf("tenant %s", c.tenant))
+	outcomes := make([][]orchestrator.ResourceResult, len(topics))
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for i, ref := range topics {
+		if gctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			result, err := c.reconcileTopic(gctx, tenant.Name, ref.namespace, ref.topic)
+			if err != nil {
+				return err
+			}
+			outcomes[i] = append(outcomes[i], result)
+			for _, sub := range ref.topic.Subscriptions {
+				result, err := c.createSubscription(gctx, tenant.Name, ref.namespace, ref.topic, sub)
+				if err != nil {
+					return err
+				}
+				outcomes[i] = append(outcomes[i], result)
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	for _, outcome := range outcomes {
+		*results = append(*results, outcome...)
+	}
+	return err
 }
 
-// createNamespace issues a PUT to create a namespace under the configured tenant.
-func (c *PulsarClient) createNamespace(ctx context.Context, namespace string) error {
-	url := fmt.Sprintf("%s/admin/v2/namespaces/%s/%s", c.adminURL, c.tenant, namespace)
-	return c.putResource(ctx, url, nil, fmt.Sprintf("namespace %s/%s", c.tenant, namespace))
+// tenantInfo is the admin v2 TenantInfo body.
+type tenantInfo struct {
+	AdminRoles      []string `json:"adminRoles"`
+	AllowedClusters []string `json:"allowedClusters"`
 }
 
-// createTopic issues a PUT to create a partitioned topic.
-// The request body is the partition count as a plain JSON integer.
-func (c *PulsarClient) createTopic(ctx context.Context, spec topicSpec) error {
-	url := fmt.Sprintf("%s/admin/v2/persistent/%s/%s/%s/partitions",
-		c.adminURL, c.tenant, spec.namespace, spec.topic)
-	body := []byte(fmt.Sprintf("%d", spec.partitions))
+// reconcileTenant creates a tenant or, when it already exists, brings its
+// admin roles and allowed clusters in line with the declaration. A tenant
+// created earlier with other clusters would otherwise keep them, and the
+// broker would then reject its namespaces.
+func (c *PulsarClient) reconcileTenant(ctx context.Context, tenant config.PulsarTenant) (orchestrator.ResourceResult, error) {
+	url := fmt.Sprintf("%s/admin/v2/tenants/%s", c.adminURL, tenant.Name)
+	label := "tenant " + tenant.Name
+	result := orchestrator.ResourceResult{Resource: "tenant:" + tenant.Name}
+
+	want := tenantInfo{
+		AdminRoles:      nonNil(tenant.AdminRoles),
+		AllowedClusters: nonNil(tenant.AllowedClusters),
+	}
+	body, err := json.Marshal(want)
+	if err != nil {
+		return result, fmt.Errorf("encoding %s: %w", label, err)
+	}
 
-	return c.putResource(ctx, url, body,
-		fmt.Sprintf("topic persistent://%s/%s/%s", c.tenant, spec.namespace, spec.topic))
-}
+	created, err := c.createResource(ctx, url, body, label)
+	if err != nil {
+		return result, err
+	}
+	if created {
+		result.Action = orchestrator.ActionCreated
+		return result, nil
+	}
 
-// putResource sends a PUT request with an optional body. HTTP 204 and 409 are
-// treated as success; any other status code is an error.
-func (c *PulsarClient) putResource(ctx context.Context, url string, body []byte, label string) error {
-	var req *http.Request
-	var err error
+	current, err := c.currentTenant(ctx, url, label)
+	if err != nil {
+		return result, err
+	}
+	var changes []string
+	if !sameMembers(current.AdminRoles, want.AdminRoles) {
+		changes = append(changes, fmt.Sprintf("admin roles %v -> %v", current.AdminRoles, want.AdminRoles))
+	}
+	if !sameMembers(current.AllowedClusters, want.AllowedClusters) {
+		changes = append(changes, fmt.Sprintf("allowed clusters %v -> %v", current.AllowedClusters, want.AllowedClusters))
+	}
+	if len(changes) == 0 {
+		result.Action = orchestrator.ActionUnchanged
+		return result, nil
+	}
 
-	if len(body) > 0 {
-		req, err = http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
-	} else {
-		req, err = http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err := c.setPolicy(ctx, http.MethodPost, url, body, label); err != nil {
+		return result, err
 	}
+	result.Action = orchestrator.ActionUpdated
+	result.Detail = strings.Join(changes, "; ")
+	return result, nil
+}
+
+// currentTenant reads the admin roles and allowed clusters of a tenant.
+func (c *PulsarClient) currentTenant(ctx context.Context, url, label string) (tenantInfo, error) {
+	var info tenantInfo
+	resp, err := c.send(ctx, http.MethodGet, url, nil, label)
 	if err != nil {
-		return fmt.Errorf("building request for %s: %w", label, err)
+		return info, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return info, fmt.Errorf("GET %s returned HTTP %d%s", label, resp.StatusCode, pulsarReason(resp.Body))
 	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return info, fmt.Errorf("decoding %s: %w", label, err)
+	}
+	return info, nil
+}
+
+// sameMembers reports whether a and b hold the same strings, ignoring order.
+func sameMembers(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// createNamespace issues a PUT to create a namespace under tenant.
+func (c *PulsarClient) createNamespace(ctx context.Context, tenant, namespace string) error {
+	url := fmt.Sprintf("%s/admin/v2/namespaces/%s/%s", c.adminURL, tenant, namespace)
+	return c.putResource(ctx, url, nil, fmt.Sprintf("namespace %s/%s", tenant, namespace))
+}
 
-	if len(body) > 0 {
-		req.Header.Set("Content-Type", "application/json")
+// nonNil returns s, or an empty slice when s is nil, so it encodes as [].
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
 	}
+	return s
+}
 
-	resp, err := c.httpDo(req)
+// putResource sends a PUT request with an optional body. HTTP 204 and 409 are
+// treated as success; any other status code is an error.
+func (c *PulsarClient) putResource(ctx context.Context, url string, body []byte, label string) error {
+	resp, err := c.send(ctx, http.MethodPut, url, body, label)
 	if err != nil {
-		return fmt.Errorf("PUT %s: %w", label, err)
+		return err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
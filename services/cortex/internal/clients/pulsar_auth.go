@@ -0,0 +1,123 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// pulsarAuth holds the TLS settings and token source shared by the admin
+// HTTP client and the data-plane client. tokens is nil when neither a token
+// nor OAuth2 is configured.
+type pulsarAuth struct {
+	tls    *tls.Config
+	tokens oauth2.TokenSource
+	http   config.PulsarHTTPConfig
+}
+
+// newPulsarAuth resolves TLS and credentials from cfg. OAuth2 client
+// credentials take precedence over a static token.
+func newPulsarAuth(cfg config.PulsarConfig) (*pulsarAuth, error) {
+	tlsCfg, err := buildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar tls: %w", err)
+	}
+	auth := &pulsarAuth{tls: tlsCfg, http: cfg.HTTP}
+
+	switch {
+	case cfg.OAuth2.Enabled():
+		// Token requests share the admin TLS settings.
+		base := &http.Client{Transport: auth.transport()}
+		auth.tokens = oauth2.ReuseTokenSource(nil, &clientCredentialsSource{cfg: cfg.OAuth2, base: base})
+	case cfg.Token != "":
+		auth.tokens = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: cfg.Token, TokenType: "Bearer"})
+	}
+	return auth, nil
+}
+
+// clientCredentialsSource fetches tokens with the OAuth2 client-credentials
+// grant. The token endpoint is discovered from the issuer on first use so a
+// missing identity provider surfaces as a probe error, not a startup failure.
+// Token requests use base so they share the admin TLS settings.
+type clientCredentialsSource struct {
+	cfg  config.PulsarOAuth2Config
+	base *http.Client
+
+	mu  sync.Mutex
+	src oauth2.TokenSource
+}
+
+func (s *clientCredentialsSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.base)
+	if s.src == nil {
+		tokenURL := s.cfg.TokenURL
+		if tokenURL == "" {
+			discovered, err := discoverTokenURL(ctx, s.base, s.cfg.IssuerURL)
+			if err != nil {
+				return nil, err
+			}
+			tokenURL = discovered
+		}
+
+		cc := clientcredentials.Config{
+			ClientID:     s.cfg.ClientID,
+			ClientSecret: s.cfg.ClientSecret,
+			TokenURL:     tokenURL,
+			Scopes:       s.cfg.Scopes,
+		}
+		if s.cfg.Audience != "" {
+			cc.EndpointParams = url.Values{"audience": {s.cfg.Audience}}
+		}
+		s.src = cc.TokenSource(ctx)
+	}
+
+	tok, err := s.src.Token()
+	if err != nil {
+		return nil, fmt.Errorf("pulsar oauth2 token: %w", err)
+	}
+	return tok, nil
+}
+
+// discoverTokenURL reads token_endpoint from the issuer's OpenID
+// configuration.
+func discoverTokenURL(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	u := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("building oauth2 discovery request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 discovery: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth2 discovery %s returned HTTP %d", u, resp.StatusCode)
+	}
+
+	var doc struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("decoding oauth2 discovery document: %w", err)
+	}
+	if doc.TokenEndpoint == "" {
+		return "", fmt.Errorf("oauth2 discovery %s has no token_endpoint", u)
+	}
+	return doc.TokenEndpoint, nil
+}
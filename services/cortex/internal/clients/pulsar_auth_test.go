@@ -0,0 +1,151 @@
+package clients
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// authCheckingAdmin answers GET /admin/v2/tenants only when the request
+// carries the expected Authorization header.
+func authCheckingAdmin(want string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/admin/v2/tenants" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}
+}
+
+func TestPulsarAuth_StaticToken(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(authCheckingAdmin("Bearer s3cret"))
+	defer srv.Close()
+
+	client := NewPulsarClient(config.PulsarConfig{AdminURL: srv.URL, Token: "s3cret"}, NewCircuitBreaker("pulsar-token"))
+	result := client.Probe(context.Background())
+	assert.True(t, result.OK, result.Error)
+
+	anon := NewPulsarClient(config.PulsarConfig{AdminURL: srv.URL}, NewCircuitBreaker("pulsar-anon"))
+	result = anon.Probe(context.Background())
+	assert.False(t, result.OK)
+	assert.Contains(t, result.Error, "HTTP 401")
+}
+
+func TestPulsarAuth_OAuth2ClientCredentials(t *testing.T) {
+	t.Parallel()
+
+	var tokenCalls atomic.Int32
+	var issuer *httptest.Server
+	issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(`{"token_endpoint":"` + issuer.URL + `/oauth/token"}`))
+		case "/oauth/token":
+			tokenCalls.Add(1)
+			require.NoError(t, r.ParseForm())
+			user, pass, _ := r.BasicAuth()
+			if r.PostForm.Get("grant_type") != "client_credentials" || user != "cortex" || pass != "cortex-secret" ||
+				r.PostForm.Get("audience") != "urn:pulsar:arc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"issued-token","token_type":"Bearer","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer issuer.Close()
+
+	admin := httptest.NewServer(authCheckingAdmin("Bearer issued-token"))
+	defer admin.Close()
+
+	cfg := config.PulsarConfig{
+		AdminURL: admin.URL,
+		OAuth2: config.PulsarOAuth2Config{
+			IssuerURL:    issuer.URL,
+			ClientID:     "cortex",
+			ClientSecret: "cortex-secret",
+			Audience:     "urn:pulsar:arc",
+		},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-oauth2"))
+
+	for range 2 {
+		result := client.Probe(context.Background())
+		require.True(t, result.OK, result.Error)
+	}
+	assert.Equal(t, int32(1), tokenCalls.Load(), "token is cached until it expires")
+}
+
+func TestPulsarAuth_OAuth2DiscoveryFailure(t *testing.T) {
+	t.Parallel()
+
+	issuer := httptest.NewServer(http.NotFoundHandler())
+	defer issuer.Close()
+
+	cfg := config.PulsarConfig{
+		AdminURL: "http://127.0.0.1:1",
+		OAuth2:   config.PulsarOAuth2Config{IssuerURL: issuer.URL, ClientID: "cortex", ClientSecret: "x"},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-oauth2-discovery"))
+
+	result := client.Probe(context.Background())
+	assert.False(t, result.OK)
+	assert.Contains(t, result.Error, "oauth2 discovery")
+}
+
+func TestPulsarAuth_TLS(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(authCheckingAdmin(""))
+	t.Cleanup(srv.Close) // parallel subtests outlive the function body
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	tests := []struct {
+		name    string
+		tls     config.TLSConfig
+		wantOK  bool
+		wantErr string
+	}{
+		{name: "trusted CA", tls: config.TLSConfig{CAFile: caFile}, wantOK: true},
+		{name: "insecure skip verify", tls: config.TLSConfig{InsecureSkipVerify: true}, wantOK: true},
+		{name: "system roots reject test cert", wantErr: "certificate"},
+		{name: "unreadable CA", tls: config.TLSConfig{CAFile: "/nonexistent/ca.pem"}, wantErr: "pulsar tls"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.PulsarConfig{AdminURL: srv.URL, TLS: tc.tls}
+			client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-tls-"+tc.name))
+
+			result := client.Probe(context.Background())
+			assert.Equal(t, tc.wantOK, result.OK, result.Error)
+			if tc.wantErr != "" {
+				assert.Contains(t, result.Error, tc.wantErr)
+			}
+		})
+	}
+}
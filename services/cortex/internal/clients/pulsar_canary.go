@@ -0,0 +1,136 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// canaryIDProperty tags each canary message so the consumer can skip
+// messages produced by an earlier probe or another replica.
+const canaryIDProperty = "cortex-canary-id"
+
+// canaryHealth reports the data-plane round trip. RoundTripMs is the time from
+// send to receive of the canary message, excluding connection setup.
+type canaryHealth struct {
+	Topic       string `json:"topic"`
+	RoundTripMs int64  `json:"roundTripMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+// pulsarCanary produces and consumes a message over the binary protocol on
+// the shared data-plane client. Probes are serialised so concurrent health
+// checks do not race on the shared subscription.
+type pulsarCanary struct {
+	dataPlane    *pulsarDataPlane
+	topic        string
+	subscription string
+	timeout      time.Duration
+
+	mu  sync.Mutex
+	seq uint64
+}
+
+func newCanary(cfg config.PulsarConfig, dataPlane *pulsarDataPlane) *pulsarCanary {
+	subscription := cfg.Canary.Subscription
+	if subscription == "" {
+		host, _ := os.Hostname()
+		subscription = "cortex-canary-" + host
+	}
+
+	timeout := cfg.Canary.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &pulsarCanary{
+		dataPlane:    dataPlane,
+		topic:        cfg.CanaryTopic(),
+		subscription: subscription,
+		timeout:      timeout,
+	}
+}
+
+// run subscribes to the canary topic at the latest position, sends a tagged
+// message, and waits for it to come back. The returned duration covers send
+// to receive only.
+func (p *pulsarCanary) run(ctx context.Context) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	client, err := p.dataPlane.get()
+	if err != nil {
+		return 0, err
+	}
+
+	// A non-durable subscription leaves no cursor behind, so canaries from
+	// other replicas never build up a backlog while this one is down.
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topic:                       p.topic,
+		SubscriptionName:            p.subscription,
+		Type:                        pulsar.Exclusive,
+		SubscriptionMode:            pulsar.NonDurable,
+		SubscriptionInitialPosition: pulsar.SubscriptionPositionLatest,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("subscribing to %s: %w", p.topic, err)
+	}
+	defer consumer.Close()
+
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{Topic: p.topic})
+	if err != nil {
+		return 0, fmt.Errorf("creating producer on %s: %w", p.topic, err)
+	}
+	defer producer.Close()
+
+	p.seq++
+	id := p.subscription + "-" + strconv.FormatUint(p.seq, 10)
+
+	sent := time.Now()
+	if _, err := producer.Send(ctx, &pulsar.ProducerMessage{
+		Payload:    []byte(id),
+		Properties: map[string]string{canaryIDProperty: id},
+	}); err != nil {
+		return 0, fmt.Errorf("sending canary: %w", err)
+	}
+
+	for {
+		msg, err := consumer.Receive(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return 0, fmt.Errorf("canary not received within %s", p.timeout)
+			}
+			return 0, fmt.Errorf("receiving canary: %w", err)
+		}
+		_ = consumer.Ack(msg)
+		if msg.Properties()[canaryIDProperty] == id {
+			return time.Since(sent), nil
+		}
+	}
+}
+
+// runCanary runs the data-plane probe, returning nil when it is disabled.
+func (c *PulsarClient) runCanary(ctx context.Context) *canaryHealth {
+	if c.canary == nil {
+		return nil
+	}
+	health := &canaryHealth{Topic: c.canaryTopic}
+	rtt, err := c.canary(ctx)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	health.RoundTripMs = rtt.Milliseconds()
+	return health
+}
@@ -0,0 +1,85 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	pulsarlog "github.com/apache/pulsar-client-go/pulsar/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// pulsarDataPlane lazily opens the binary-protocol client shared by the
+// canary probe and consumers such as the audit worker. Nothing connects
+// until the first caller asks for the client.
+type pulsarDataPlane struct {
+	opts pulsar.ClientOptions
+
+	mu     sync.Mutex
+	client pulsar.Client
+}
+
+func newPulsarDataPlane(cfg config.PulsarConfig, auth *pulsarAuth) *pulsarDataPlane {
+	opts := pulsar.ClientOptions{
+		URL:               cfg.ServiceURL,
+		ConnectionTimeout: cfg.DataPlane.ConnectionTimeout,
+		OperationTimeout:  cfg.DataPlane.OperationTimeout,
+		TLSConfig:         auth.tls,
+		Logger:            pulsarlog.NewLoggerWithSlog(slog.Default()),
+		// A private registry keeps the client's metrics off the default one
+		// and avoids duplicate registration if the client is recreated.
+		MetricsRegisterer: prometheus.NewRegistry(),
+	}
+	if auth.tokens != nil {
+		opts.Authentication = pulsar.NewAuthenticationTokenFromSupplier(func() (string, error) {
+			tok, err := auth.tokens.Token()
+			if err != nil {
+				return "", err
+			}
+			return tok.AccessToken, nil
+		})
+	}
+	return &pulsarDataPlane{opts: opts}
+}
+
+func (d *pulsarDataPlane) get() (pulsar.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == nil {
+		client, err := pulsar.NewClient(d.opts)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s: %w", d.opts.URL, err)
+		}
+		d.client = client
+	}
+	return d.client, nil
+}
+
+func (d *pulsarDataPlane) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client != nil {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+// Subscribe opens a consumer over the binary protocol with the configured
+// credentials and TLS settings. It fails when no service URL is configured.
+func (c *PulsarClient) Subscribe(opts pulsar.ConsumerOptions) (pulsar.Consumer, error) {
+	if c.dataErr != nil {
+		return nil, c.dataErr
+	}
+	if c.dataPlane == nil {
+		return nil, errors.New("pulsar service_url is not configured")
+	}
+	client, err := c.dataPlane.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.Subscribe(opts)
+}
@@ -0,0 +1,93 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// transport returns the admin transport with the configured dial, keep-alive
+// and idle-connection settings. Unset values keep the net/http defaults.
+func (a *pulsarAuth) transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if a.tls != nil {
+		transport.TLSClientConfig = a.tls
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if a.http.DialTimeout > 0 {
+		dialer.Timeout = a.http.DialTimeout
+	}
+	if a.http.KeepAlive > 0 {
+		dialer.KeepAlive = a.http.KeepAlive
+	}
+	transport.DialContext = dialer.DialContext
+
+	if a.http.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = a.http.IdleConnTimeout
+	}
+	if a.http.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = a.http.MaxIdleConnsPerHost
+	}
+	return transport
+}
+
+// httpClient returns the admin API client, adding a bearer token to every
+// request when credentials are configured.
+func (a *pulsarAuth) httpClient() *http.Client {
+	client := &http.Client{Transport: a.transport(), Timeout: a.http.Timeout}
+	if a.tokens != nil {
+		client.Transport = &oauth2.Transport{Source: a.tokens, Base: client.Transport}
+	}
+	return client
+}
+
+// send builds and issues an admin request with an optional JSON body. A 5xx
+// response is retried with exponential backoff up to the configured limit;
+// the last response is returned as-is so callers report its status.
+func (c *PulsarClient) send(ctx context.Context, method, url string, body []byte, label string) (*http.Response, error) {
+	return c.sendRetrying(ctx, method, url, body, label, c.maxRetries)
+}
+
+// sendOnce issues a bodiless request without retries, for health reads.
+func (c *PulsarClient) sendOnce(ctx context.Context, method, url, label string) (*http.Response, error) {
+	return c.sendRetrying(ctx, method, url, nil, label, 0)
+}
+
+func (c *PulsarClient) sendRetrying(ctx context.Context, method, url string, body []byte, label string, maxRetries int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if len(body) > 0 {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reader)
+		if err != nil {
+			return nil, fmt.Errorf("building request for %s: %w", label, err)
+		}
+		if len(body) > 0 {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpDo(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, label, err)
+		}
+		if resp.StatusCode < http.StatusInternalServerError || attempt >= maxRetries {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close() //nolint:errcheck
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s %s: %w", method, label, ctx.Err())
+		case <-time.After(c.retryBackoff << attempt):
+		}
+	}
+}
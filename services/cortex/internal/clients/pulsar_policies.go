@@ -0,0 +1,141 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// retentionPolicies is the admin v2 RetentionPolicies body.
+type retentionPolicies struct {
+	RetentionTimeInMinutes int   `json:"retentionTimeInMinutes"`
+	RetentionSizeInMB      int64 `json:"retentionSizeInMB"`
+}
+
+// backlogQuota is the admin v2 BacklogQuota body. Only one limit is sent per
+// quota type.
+type backlogQuota struct {
+	LimitSize int64  `json:"limitSize,omitempty"`
+	LimitTime int    `json:"limitTime,omitempty"`
+	Policy    string `json:"policy"`
+}
+
+// namespacePolicy is one admin call that sets a single namespace policy.
+type namespacePolicy struct {
+	name   string
+	method string
+	path   string
+	body   any
+}
+
+// namespacePolicyCalls translates declared policies into admin v2 calls. The
+// order is fixed so requests are deterministic.
+func namespacePolicyCalls(p config.PulsarNamespacePolicies) []namespacePolicy {
+	var calls []namespacePolicy
+
+	if r := p.Retention; r != nil {
+		minutes := -1
+		if r.Time >= 0 {
+			minutes = int(r.Time / time.Minute)
+		}
+		size := r.SizeMB
+		if size < 0 {
+			size = -1
+		}
+		calls = append(calls, namespacePolicy{"retention", http.MethodPost, "retention",
+			retentionPolicies{RetentionTimeInMinutes: minutes, RetentionSizeInMB: size}})
+	}
+	if p.MessageTTL != nil {
+		calls = append(calls, namespacePolicy{"message TTL", http.MethodPost, "messageTTL",
+			int(*p.MessageTTL / time.Second)})
+	}
+	if q := p.BacklogQuota; q != nil {
+		policy := q.Policy
+		if policy == "" {
+			policy = "producer_request_hold"
+		}
+		if q.LimitSize > 0 {
+			calls = append(calls, namespacePolicy{"backlog quota", http.MethodPost,
+				"backlogQuota?backlogQuotaType=destination_storage",
+				backlogQuota{LimitSize: q.LimitSize, Policy: policy}})
+		}
+		if q.LimitTime > 0 {
+			calls = append(calls, namespacePolicy{"backlog quota", http.MethodPost,
+				"backlogQuota?backlogQuotaType=message_age",
+				backlogQuota{LimitTime: int(q.LimitTime / time.Second), Policy: policy}})
+		}
+	}
+	if p.Deduplication != nil {
+		calls = append(calls, namespacePolicy{"deduplication", http.MethodPost, "deduplication",
+			*p.Deduplication})
+	}
+	if p.SchemaCompatibility != "" {
+		calls = append(calls, namespacePolicy{"schema compatibility", http.MethodPut, "schemaCompatibilityStrategy",
+			p.SchemaCompatibility})
+	}
+	if p.MaxProducersPerTopic != nil {
+		calls = append(calls, namespacePolicy{"max producers", http.MethodPost, "maxProducersPerTopic",
+			*p.MaxProducersPerTopic})
+	}
+	if p.MaxConsumersPerTopic != nil {
+		calls = append(calls, namespacePolicy{"max consumers", http.MethodPost, "maxConsumersPerTopic",
+			*p.MaxConsumersPerTopic})
+	}
+	return calls
+}
+
+// applyNamespacePolicies sets every declared policy on tenant/ns. The admin
+// endpoints overwrite the current value, so running this on every bootstrap
+// reconciles drift.
+func (c *PulsarClient) applyNamespacePolicies(ctx context.Context, tenant string, ns config.PulsarNamespace) error {
+	for _, call := range namespacePolicyCalls(ns.Policies) {
+		body, err := json.Marshal(call.body)
+		if err != nil {
+			return fmt.Errorf("encoding %s for %s/%s: %w", call.name, tenant, ns.Name, err)
+		}
+
+		url := fmt.Sprintf("%s/admin/v2/namespaces/%s/%s/%s", c.adminURL, tenant, ns.Name, call.path)
+		label := fmt.Sprintf("%s policy on %s/%s", call.name, tenant, ns.Name)
+		if err := c.setPolicy(ctx, call.method, url, body, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPolicy sends a policy update. Any 2xx response is success; otherwise the
+// broker's reason is included in the error.
+func (c *PulsarClient) setPolicy(ctx context.Context, method, url string, body []byte, label string) error {
+	resp, err := c.send(ctx, method, url, body, label)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("%s %s returned HTTP %d%s", method, label, resp.StatusCode, pulsarReason(resp.Body))
+}
+
+// pulsarReason extracts the "reason" field from a Pulsar admin error body,
+// formatted as ": <reason>", or "" when there is none.
+func pulsarReason(body io.Reader) string {
+	data, err := io.ReadAll(io.LimitReader(body, 4096))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	var payload struct {
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(data, &payload) == nil && payload.Reason != "" {
+		return ": " + payload.Reason
+	}
+	return ": " + strings.TrimSpace(string(data))
+}
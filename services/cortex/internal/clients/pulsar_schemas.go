@@ -0,0 +1,225 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"arc-framework/cortex/internal/contracts"
+	"arc-framework/cortex/internal/orchestrator"
+)
+
+// topicSchema is a loaded schema and the topic it belongs to.
+type topicSchema struct {
+	tenant    string
+	namespace string
+	topic     string
+	schema    contracts.Schema
+}
+
+// resource names the schema in ResourceResult, e.g.
+// "schema:arc-system/events/agent-lifecycle". Pulsar keys schemas by topic
+// name regardless of domain and partitioning.
+func (s topicSchema) resource() string {
+	return fmt.Sprintf("schema:%s/%s/%s", s.tenant, s.namespace, s.topic)
+}
+
+// postSchemaPayload is the admin v2 PostSchemaPayload body.
+type postSchemaPayload struct {
+	Type       string            `json:"type"`
+	Schema     string            `json:"schema"`
+	Properties map[string]string `json:"properties"`
+}
+
+// getSchemaResponse is the subset of the admin v2 GetSchemaResponse we use.
+type getSchemaResponse struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// compatibilityResponse is the admin v2 IsCompatibilityResponse body. Broker
+// versions serialise the flag as either "compatibility" or "isCompatibility".
+type compatibilityResponse struct {
+	Compatibility   *bool  `json:"compatibility"`
+	IsCompatibility *bool  `json:"isCompatibility"`
+	Strategy        string `json:"schemaCompatibilityStrategy"`
+}
+
+func (r compatibilityResponse) compatible() bool {
+	if r.IsCompatibility != nil {
+		return *r.IsCompatibility
+	}
+	return r.Compatibility != nil && *r.Compatibility
+}
+
+// loadSchemas reads every schema declared in the layout. All load errors are
+// returned together.
+func (c *PulsarClient) loadSchemas() ([]topicSchema, error) {
+	var schemas []topicSchema
+	var errs []error
+	for _, tenant := range c.layout {
+		for _, ns := range tenant.Namespaces {
+			for _, topic := range ns.Topics {
+				if topic.Schema == nil {
+					continue
+				}
+				s, err := contracts.LoadSchema(topic.Schema.File, topic.Schema.Message, topic.Schema.Type)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("topic %s/%s/%s: %w", tenant.Name, ns.Name, topic.Name, err))
+					continue
+				}
+				schemas = append(schemas, topicSchema{
+					tenant:    tenant.Name,
+					namespace: ns.Name,
+					topic:     topic.Name,
+					schema:    s,
+				})
+			}
+		}
+	}
+	return schemas, errors.Join(errs...)
+}
+
+// provisionSchemas uploads changed schemas. Every changed schema is checked
+// against the broker's compatibility strategy first; if any is incompatible
+// it is reported as a conflict and nothing is uploaded, so a bad contract
+// change never lands half-applied.
+func (c *PulsarClient) provisionSchemas(ctx context.Context, schemas []topicSchema, results *[]orchestrator.ResourceResult) error {
+	type pending struct {
+		s      topicSchema
+		exists bool
+	}
+	var uploads []pending
+	var planned []orchestrator.ResourceResult
+	conflicts := false
+
+	for _, s := range schemas {
+		current, err := c.currentSchema(ctx, s)
+		if err != nil {
+			return err
+		}
+		if current != nil && sameSchema(current, s.schema) {
+			planned = append(planned, orchestrator.ResourceResult{Resource: s.resource(), Action: orchestrator.ActionUnchanged})
+			continue
+		}
+		if current != nil {
+			ok, strategy, err := c.schemaCompatible(ctx, s)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				conflicts = true
+				planned = append(planned, orchestrator.ResourceResult{
+					Resource: s.resource(),
+					Action:   orchestrator.ActionConflict,
+					Detail:   fmt.Sprintf("%s is incompatible with the registered schema under %s", s.schema.Source, strategy),
+				})
+				continue
+			}
+		}
+		uploads = append(uploads, pending{s: s, exists: current != nil})
+	}
+
+	*results = append(*results, planned...)
+	if conflicts {
+		return nil
+	}
+
+	for _, u := range uploads {
+		if err := c.uploadSchema(ctx, u.s); err != nil {
+			return err
+		}
+		action := orchestrator.ActionCreated
+		if u.exists {
+			action = orchestrator.ActionUpdated
+		}
+		*results = append(*results, orchestrator.ResourceResult{Resource: u.s.resource(), Action: action, Detail: u.s.schema.Source})
+	}
+	return nil
+}
+
+func (c *PulsarClient) schemaURL(s topicSchema, suffix string) string {
+	return fmt.Sprintf("%s/admin/v2/schemas/%s/%s/%s/%s", c.adminURL, s.tenant, s.namespace, s.topic, suffix)
+}
+
+func schemaPayload(s topicSchema) ([]byte, error) {
+	return json.Marshal(postSchemaPayload{
+		Type:       s.schema.Type,
+		Schema:     s.schema.Definition,
+		Properties: map[string]string{"source": s.schema.Source},
+	})
+}
+
+// currentSchema returns the latest registered schema, or nil when the topic
+// has none.
+func (c *PulsarClient) currentSchema(ctx context.Context, s topicSchema) (*getSchemaResponse, error) {
+	label := "schema of " + s.resource()
+	resp, err := c.send(ctx, http.MethodGet, c.schemaURL(s, "schema"), nil, label)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("GET %s returned HTTP %d%s", label, resp.StatusCode, pulsarReason(resp.Body))
+	}
+
+	var current getSchemaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&current); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", label, err)
+	}
+	return &current, nil
+}
+
+// schemaCompatible asks the broker whether s can be registered under the
+// namespace's compatibility strategy.
+func (c *PulsarClient) schemaCompatible(ctx context.Context, s topicSchema) (bool, string, error) {
+	body, err := schemaPayload(s)
+	if err != nil {
+		return false, "", fmt.Errorf("encoding %s: %w", s.resource(), err)
+	}
+
+	label := "compatibility check of " + s.resource()
+	resp, err := c.send(ctx, http.MethodPost, c.schemaURL(s, "compatibility"), body, label)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("POST %s returned HTTP %d%s", label, resp.StatusCode, pulsarReason(resp.Body))
+	}
+
+	var out compatibilityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, "", fmt.Errorf("decoding %s: %w", label, err)
+	}
+	return out.compatible(), out.Strategy, nil
+}
+
+func (c *PulsarClient) uploadSchema(ctx context.Context, s topicSchema) error {
+	body, err := schemaPayload(s)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", s.resource(), err)
+	}
+	return c.setPolicy(ctx, http.MethodPost, c.schemaURL(s, "schema"), body, s.resource())
+}
+
+// sameSchema compares type and definition, ignoring JSON formatting.
+func sameSchema(current *getSchemaResponse, want contracts.Schema) bool {
+	if current.Type != want.Type {
+		return false
+	}
+	var a, b any
+	if json.Unmarshal([]byte(current.Data), &a) != nil || json.Unmarshal([]byte(want.Definition), &b) != nil {
+		return current.Data == want.Definition
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,168 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/sync/errgroup"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// topicHealth reports the broker stats of one declared topic. Rates are
+// messages per second; StorageSize is in bytes. For partitioned topics the
+// figures are aggregated across partitions. Backlog maps every subscription
+// on the topic, declared or not, to its message backlog.
+type topicHealth struct {
+	Topic            string           `json:"topic"`
+	Exists           bool             `json:"exists"`
+	Partitions       int              `json:"partitions,omitempty"`
+	MsgRateIn        float64          `json:"msgRateIn"`
+	MsgRateOut       float64          `json:"msgRateOut"`
+	StorageSize      int64            `json:"storageSize"`
+	Producers        int              `json:"producers"`
+	Consumers        int              `json:"consumers"`
+	Backlog          map[string]int64 `json:"backlog,omitempty"`
+	BacklogThreshold int64            `json:"backlogThreshold,omitempty"`
+	// Error is set when the stats could not be read; the other fields are
+	// then unknown.
+	Error string `json:"error,omitempty"`
+}
+
+// topicStats is the subset of admin v2 TopicStats the probe reads. The
+// partitioned-stats endpoint returns the same shape aggregated across
+// partitions.
+type topicStats struct {
+	MsgRateIn     float64                      `json:"msgRateIn"`
+	MsgRateOut    float64                      `json:"msgRateOut"`
+	StorageSize   int64                        `json:"storageSize"`
+	Publishers    []json.RawMessage            `json:"publishers"`
+	Subscriptions map[string]subscriptionStats `json:"subscriptions"`
+}
+
+type subscriptionStats struct {
+	MsgBacklog int64           `json:"msgBacklog"`
+	Type       string          `json:"type"`
+	Consumers  []consumerStats `json:"consumers"`
+}
+
+type consumerStats struct {
+	ConsumerName string `json:"consumerName"`
+}
+
+// collectHealth reads stats for every declared topic, at most c.concurrency
+// at a time, and reports the state of every declared subscription. A failed
+// read is recorded on that topic, leaving its subscriptions unreported,
+// rather than failing the probe. Reads are not retried so a struggling broker
+// cannot stall the probe.
+func (c *PulsarClient) collectHealth(ctx context.Context) *pulsarHealth {
+	type topicRef struct {
+		tenant, namespace string
+		topic             config.PulsarTopic
+	}
+	var refs []topicRef
+	for _, tenant := range c.layout {
+		for _, ns := range tenant.Namespaces {
+			for _, topic := range ns.Topics {
+				refs = append(refs, topicRef{tenant.Name, ns.Name, topic})
+			}
+		}
+	}
+
+	type outcome struct {
+		topic topicHealth
+		subs  []subscriptionHealth
+	}
+	outcomes := make([]outcome, len(refs))
+	var g errgroup.Group
+	g.SetLimit(c.concurrency)
+	for i, ref := range refs {
+		g.Go(func() error {
+			name := fmt.Sprintf("%s://%s/%s/%s", ref.topic.Domain(), ref.tenant, ref.namespace, ref.topic.Name)
+			stats, found, err := c.topicStats(ctx, ref.tenant, ref.namespace, ref.topic)
+			if err != nil {
+				outcomes[i].topic = topicHealth{Topic: name, Partitions: ref.topic.Partitions, Error: err.Error()}
+				return nil
+			}
+			outcomes[i].topic = c.topicHealth(name, ref.topic, stats, found)
+			for _, sub := range ref.topic.Subscriptions {
+				h := subscriptionHealth{Topic: name, Name: sub.Name, ExpectedType: sub.Type}
+				if s, ok := stats.Subscriptions[sub.Name]; ok {
+					h.Exists = true
+					h.Type = s.Type
+					h.Backlog = s.MsgBacklog
+					h.Consumers = len(s.Consumers)
+				}
+				outcomes[i].subs = append(outcomes[i].subs, h)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	health := &pulsarHealth{}
+	for _, o := range outcomes {
+		health.Topics = append(health.Topics, o.topic)
+		health.Subscriptions = append(health.Subscriptions, o.subs...)
+	}
+	return health
+}
+
+// topicHealth summarises stats for one topic. A topic-level backlog threshold
+// overrides the client-wide one.
+func (c *PulsarClient) topicHealth(name string, topic config.PulsarTopic, stats *topicStats, found bool) topicHealth {
+	h := topicHealth{
+		Topic:            name,
+		Exists:           found,
+		Partitions:       topic.Partitions,
+		MsgRateIn:        stats.MsgRateIn,
+		MsgRateOut:       stats.MsgRateOut,
+		StorageSize:      stats.StorageSize,
+		Producers:        len(stats.Publishers),
+		BacklogThreshold: c.backlogThreshold,
+	}
+	if topic.BacklogThreshold > 0 {
+		h.BacklogThreshold = topic.BacklogThreshold
+	}
+	if len(stats.Subscriptions) > 0 {
+		h.Backlog = make(map[string]int64, len(stats.Subscriptions))
+		for sub, s := range stats.Subscriptions {
+			h.Backlog[sub] = s.MsgBacklog
+			h.Consumers += len(s.Consumers)
+		}
+	}
+	return h
+}
+
+// topicStats fetches stats, using partitioned-stats for partitioned topics. A
+// missing topic yields empty stats and found=false so its subscriptions show
+// as absent.
+func (c *PulsarClient) topicStats(ctx context.Context, tenant, namespace string, topic config.PulsarTopic) (stats *topicStats, found bool, err error) {
+	u := topicURL(c.adminURL, tenant, namespace, topic) + "/stats"
+	if topic.Partitions > 0 {
+		u = topicURL(c.adminURL, tenant, namespace, topic) + "/partitioned-stats"
+	}
+
+	label := fmt.Sprintf("stats of %s://%s/%s/%s", topic.Domain(), tenant, namespace, topic.Name)
+	resp, err := c.sendOnce(ctx, http.MethodGet, u, label)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return &topicStats{}, false, nil
+	default:
+		return nil, false, fmt.Errorf("GET %s returned HTTP %d%s", label, resp.StatusCode, pulsarReason(resp.Body))
+	}
+
+	stats = &topicStats{}
+	if err := json.NewDecoder(resp.Body).Decode(stats); err != nil {
+		return nil, false, fmt.Errorf("decoding %s: %w", label, err)
+	}
+	return stats, true, nil
+}
@@ -0,0 +1,118 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+
+	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/orchestrator"
+)
+
+// cursorPosition is the admin v2 ResetCursorData body used to choose where a
+// new subscription starts. (-1, -1) is MessageId.earliest and (max, max) is
+// MessageId.latest.
+type cursorPosition struct {
+	LedgerID       int64 `json:"ledgerId"`
+	EntryID        int64 `json:"entryId"`
+	PartitionIndex int   `json:"partitionIndex"`
+}
+
+var (
+	cursorEarliest = cursorPosition{LedgerID: -1, EntryID: -1, PartitionIndex: -1}
+	cursorLatest   = cursorPosition{LedgerID: math.MaxInt64, EntryID: math.MaxInt64, PartitionIndex: -1}
+)
+
+func topicURL(adminURL, tenant, namespace string, topic config.PulsarTopic) string {
+	return fmt.Sprintf("%s/admin/v2/%s/%s/%s/%s", adminURL, topic.Domain(), tenant, namespace, topic.Name)
+}
+
+func subscriptionResource(tenant, namespace string, topic config.PulsarTopic, sub string) string {
+	return fmt.Sprintf("subscription:%s@%s://%s/%s/%s", sub, topic.Domain(), tenant, namespace, topic.Name)
+}
+
+// createSubscription creates a durable subscription at its initial position.
+// On a partitioned topic the broker creates it on every partition. An
+// existing subscription (409) is left where it is.
+func (c *PulsarClient) createSubscription(ctx context.Context, tenant, namespace string, topic config.PulsarTopic, sub config.PulsarSubscription) (orchestrator.ResourceResult, error) {
+	result := orchestrator.ResourceResult{Resource: subscriptionResource(tenant, namespace, topic, sub.Name)}
+
+	pos := cursorLatest
+	if sub.InitialPosition == "earliest" {
+		pos = cursorEarliest
+	}
+	body, err := json.Marshal(pos)
+	if err != nil {
+		return result, fmt.Errorf("encoding %s: %w", result.Resource, err)
+	}
+
+	u := topicURL(c.adminURL, tenant, namespace, topic) + "/subscription/" + url.PathEscape(sub.Name)
+	created, err := c.createResource(ctx, u, body, result.Resource)
+	if err != nil {
+		return result, err
+	}
+	result.Action = createdOrUnchanged(created)
+	return result, nil
+}
+
+// pulsarHealth is the Details payload of the Pulsar probe.
+type pulsarHealth struct {
+	Topics        []topicHealth        `json:"topics,omitempty"`
+	Subscriptions []subscriptionHealth `json:"subscriptions,omitempty"`
+	Canary        *canaryHealth        `json:"canary,omitempty"`
+}
+
+// subscriptionHealth reports one declared subscription. Type is the type the
+// broker reports once a consumer has attached; ExpectedType is the declared
+// one.
+type subscriptionHealth struct {
+	Topic        string `json:"topic"`
+	Name         string `json:"name"`
+	Exists       bool   `json:"exists"`
+	Type         string `json:"type,omitempty"`
+	ExpectedType string `json:"expectedType,omitempty"`
+	Backlog      int64  `json:"backlog"`
+	Consumers    int    `json:"consumers"`
+}
+
+// evaluatePulsarHealth returns a warning for every missing topic, every
+// subscription whose backlog exceeds its topic's threshold, every missing
+// subscription, and every subscription whose consumers attached with a
+// different type.
+func evaluatePulsarHealth(h *pulsarHealth) []string {
+	if h == nil {
+		return nil
+	}
+	var warnings []string
+	for _, t := range h.Topics {
+		if t.Error != "" {
+			warnings = append(warnings, fmt.Sprintf("topic %s stats unavailable: %s", t.Topic, t.Error))
+			continue
+		}
+		if !t.Exists {
+			warnings = append(warnings, fmt.Sprintf("topic %s does not exist", t.Topic))
+			continue
+		}
+		if t.BacklogThreshold <= 0 {
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(t.Backlog)) {
+			if backlog := t.Backlog[name]; backlog > t.BacklogThreshold {
+				warnings = append(warnings, fmt.Sprintf("subscription %s on %s has a backlog of %d messages (threshold %d)", name, t.Topic, backlog, t.BacklogThreshold))
+			}
+		}
+	}
+	for _, s := range h.Subscriptions {
+		switch {
+		case !s.Exists:
+			warnings = append(warnings, fmt.Sprintf("subscription %s on %s does not exist", s.Name, s.Topic))
+		case s.ExpectedType != "" && s.Consumers > 0 && s.Type != s.ExpectedType:
+			warnings = append(warnings, fmt.Sprintf("subscription %s on %s is %s, declared %s", s.Name, s.Topic, s.Type, s.ExpectedType))
+		}
+	}
+	return warnings
+}
@@ -2,15 +2,23 @@ package clients
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/orchestrator"
 )
 
 // pulsarFixedHandler returns a handler that responds with statusCode to every
@@ -21,6 +29,24 @@ func pulsarFixedHandler(statusCode int) http.HandlerFunc {
 	}
 }
 
+// withPartitions answers GET .../partitions requests with the partition count
+// recorded for the topic's local name (0 when absent) and passes every other
+// request to next.
+func withPartitions(counts map[string]int, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/partitions") {
+			parts := strings.Split(r.URL.Path, "/")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"partitions":%d}`, counts[parts[len(parts)-2]])
+			return
+		}
+		next(w, r)
+	}
+}
+
+// defaultPartitions matches the default layout's topics.
+var defaultPartitions = map[string]int{"agent-lifecycle": 3, "application": 4, "command-log": 1}
+
 // makePulsarClient constructs a PulsarClient wired to the given test server.
 func makePulsarClient(srv *httptest.Server) *PulsarClient {
 	cfg := config.PulsarConfig{
@@ -66,26 +92,94 @@ func TestNewPulsarClient(t *testing.T) {
 func TestProvision_AllNew(t *testing.T) {
 	t.Parallel()
 
-	srv := httptest.NewServer(pulsarFixedHandler(http.StatusNoContent))
+	srv := httptest.NewServer(withPartitions(nil, pulsarFixedHandler(http.StatusNoContent)))
 	defer srv.Close()
 
 	client := makePulsarClient(srv)
-	err := client.Provision(context.Background())
+	results, err := client.Provision(context.Background())
 
 	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, "tenant:arc-system", results[0].Resource)
+	for _, r := range results {
+		assert.Equal(t, orchestrator.ActionCreated, r.Action, r.Resource)
+	}
 }
 
 func TestProvision_AllExisting(t *testing.T) {
 	t.Parallel()
 
-	// 409 on every resource — should be treated as success.
-	srv := httptest.NewServer(pulsarFixedHandler(http.StatusConflict))
+	// 409 on every resource creation — should be treated as success. The
+	// tenant already has the declared settings, so it is not updated. Policy
+	// updates always overwrite and return 204.
+	var tenantPosts atomic.Int32
+	srv := httptest.NewServer(withPartitions(defaultPartitions, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusConflict)
+		case r.URL.Path == "/admin/v2/tenants/arc-system" && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"adminRoles":[],"allowedClusters":["standalone"]}`)
+		case r.URL.Path == "/admin/v2/tenants/arc-system":
+			tenantPosts.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
 	defer srv.Close()
 
 	client := makePulsarClient(srv)
-	err := client.Provision(context.Background())
+	results, err := client.Provision(context.Background())
 
 	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Zero(t, tenantPosts.Load())
+	for _, r := range results {
+		assert.Equal(t, orchestrator.ActionUnchanged, r.Action, r.Resource)
+	}
+}
+
+func TestProvision_UpdatesExistingTenant(t *testing.T) {
+	t.Parallel()
+
+	var posted string
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/v2/tenants/arc-system" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusConflict)
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"adminRoles":[],"allowedClusters":["standalone"]}`)
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			posted = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.PulsarConfig{
+		AdminURL: srv.URL,
+		Tenants: []config.PulsarTenant{{
+			Name: "arc-system", AdminRoles: []string{"cortex"}, AllowedClusters: []string{"prod-east"},
+		}},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-tenant-update"))
+	client.httpDo = srv.Client().Do
+
+	results, err := client.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []orchestrator.ResourceResult{{
+		Resource: "tenant:arc-system",
+		Action:   orchestrator.ActionUpdated,
+		Detail:   "admin roles [] -> [cortex]; allowed clusters [standalone] -> [prod-east]",
+	}}, results)
+	assert.JSONEq(t, `{"adminRoles":["cortex"],"allowedClusters":["prod-east"]}`, posted)
 }
 
 func TestProvision_ServerError(t *testing.T) {
@@ -95,7 +189,7 @@ func TestProvision_ServerError(t *testing.T) {
 	defer srv.Close()
 
 	client := makePulsarClient(srv)
-	err := client.Provision(context.Background())
+	_, err := client.Provision(context.Background())
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "HTTP 500")
@@ -110,14 +204,14 @@ func TestProvision_CircuitBreakerOpensAfterThreeFailures(t *testing.T) {
 	client := makePulsarClientWithCB(srv, "provision-pulsar-cb-open")
 
 	for i := range 3 {
-		err := client.Provision(context.Background())
+		_, err := client.Provision(context.Background())
 		require.Error(t, err, "attempt %d should fail", i+1)
 		assert.NotContains(t, err.Error(), "circuit open",
 			"circuit should not be open yet on attempt %d", i+1)
 	}
 
 	// The 4th call must be rejected by the open circuit breaker.
-	err := client.Provision(context.Background())
+	_, err := client.Provision(context.Background())
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "circuit open")
 }
@@ -126,14 +220,14 @@ func TestProvision_CorrectEndpointsAreCalled(t *testing.T) {
 	t.Parallel()
 
 	var paths []string
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
 		paths = append(paths, r.Method+" "+r.URL.Path)
 		w.WriteHeader(http.StatusNoContent)
 	}))
 	defer srv.Close()
 
 	client := makePulsarClient(srv)
-	err := client.Provision(context.Background())
+	_, err := client.Provision(context.Background())
 	require.NoError(t, err)
 
 	assert.Contains(t, paths, "PUT /admin/v2/tenants/arc-system")
@@ -212,9 +306,824 @@ func TestProvision_StopsOnFirstError(t *testing.T) {
 	defer srv.Close()
 
 	client := makePulsarClient(srv)
-	err := client.Provision(context.Background())
+	_, err := client.Provision(context.Background())
 
 	require.Error(t, err)
 	// Only the tenant creation should have been attempted (1 call) before halting.
 	assert.Equal(t, int32(1), callCount.Load())
 }
+
+func TestProvision_DeclaredLayout(t *testing.T) {
+	t.Parallel()
+
+	type call struct{ method, path, body string }
+	var calls []call
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, call{r.Method, r.URL.Path, string(body)})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	cfg := config.PulsarConfig{
+		AdminURL: srv.URL,
+		Tenant:   "arc-system",
+		Clusters: []string{"prod-east"},
+		Tenants: []config.PulsarTenant{
+			{
+				Name:       "arc-system",
+				AdminRoles: []string{"cortex"},
+				Namespaces: []config.PulsarNamespace{{
+					Name: "events",
+					Topics: []config.PulsarTopic{
+						{Name: "agent-lifecycle", Partitions: 6},
+						{Name: "heartbeat", NonPersistent: true},
+					},
+				}},
+			},
+			{Name: "tenant-b", AllowedClusters: []string{"prod-west"}},
+		},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-layout"))
+	client.httpDo = srv.Client().Do
+
+	_, err := client.Provision(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []call{
+		{"PUT", "/admin/v2/tenants/arc-system", `{"adminRoles":["cortex"],"allowedClusters":["prod-east"]}`},
+		{"PUT", "/admin/v2/namespaces/arc-system/events", ""},
+		{"PUT", "/admin/v2/persistent/arc-system/events/agent-lifecycle/partitions", "6"},
+		{"PUT", "/admin/v2/non-persistent/arc-system/events/heartbeat", ""},
+		{"PUT", "/admin/v2/tenants/tenant-b", `{"adminRoles":[],"allowedClusters":["prod-west"]}`},
+	}, calls)
+}
+
+func TestProvision_AppliesNamespacePolicies(t *testing.T) {
+	t.Parallel()
+
+	type call struct{ method, uri, body string }
+	var calls []call
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Count(r.URL.Path, "/") > 5 { // policy endpoints only
+			calls = append(calls, call{r.Method, r.URL.RequestURI(), string(body)})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ttl := 90 * time.Minute
+	dedup := false
+	maxProducers := 5
+	cfg := config.PulsarConfig{
+		AdminURL: srv.URL,
+		Tenants: []config.PulsarTenant{{
+			Name: "arc-system",
+			Namespaces: []config.PulsarNamespace{{
+				Name: "events",
+				Policies: config.PulsarNamespacePolicies{
+					Retention:            &config.PulsarRetention{Time: 48 * time.Hour, SizeMB: -1},
+					MessageTTL:           &ttl,
+					BacklogQuota:         &config.PulsarBacklogQuota{LimitSize: 1 << 30, LimitTime: time.Hour},
+					Deduplication:        &dedup,
+					SchemaCompatibility:  "BACKWARD",
+					MaxProducersPerTopic: &maxProducers,
+				},
+			}},
+		}},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-policies"))
+	client.httpDo = srv.Client().Do
+
+	_, err := client.Provision(context.Background())
+	require.NoError(t, err)
+
+	ns := "/admin/v2/namespaces/arc-system/events/"
+	assert.Equal(t, []call{
+		{"POST", ns + "retention", `{"retentionTimeInMinutes":2880,"retentionSizeInMB":-1}`},
+		{"POST", ns + "messageTTL", "5400"},
+		{"POST", ns + "backlogQuota?backlogQuotaType=destination_storage", `{"limitSize":1073741824,"policy":"producer_request_hold"}`},
+		{"POST", ns + "backlogQuota?backlogQuotaType=message_age", `{"limitTime":3600,"policy":"producer_request_hold"}`},
+		{"POST", ns + "deduplication", "false"},
+		{"PUT", ns + "schemaCompatibilityStrategy", `"BACKWARD"`},
+		{"POST", ns + "maxProducersPerTopic", "5"},
+	}, calls)
+}
+
+func TestProvision_DefaultAuditPolicies(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		paths = append(paths, r.Method+" "+r.URL.Path+" "+string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := makePulsarClient(srv)
+	_, err := client.Provision(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, paths, `POST /admin/v2/namespaces/arc-system/audit/retention {"retentionTimeInMinutes":-1,"retentionSizeInMB":-1}`)
+	assert.Contains(t, paths, "POST /admin/v2/namespaces/arc-system/audit/deduplication true")
+	assert.Contains(t, paths, "POST /admin/v2/namespaces/arc-system/logs/messageTTL 86400")
+}
+
+func TestProvision_PolicyErrorIncludesReason(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`{"reason":"Retention Quota must exceed configured backlog quota"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := makePulsarClient(srv)
+	_, err := client.Provision(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 412: Retention Quota must exceed configured backlog quota")
+}
+
+func TestProvision_PartitionDrift(t *testing.T) {
+	t.Parallel()
+
+	var posts []string
+	counts := map[string]int{"agent-lifecycle": 1, "application": 8, "command-log": 1, "heartbeat": 2}
+	srv := httptest.NewServer(withPartitions(counts, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			posts = append(posts, r.URL.Path+" "+string(body))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	cfg := config.PulsarConfig{
+		AdminURL: srv.URL,
+		Tenants: []config.PulsarTenant{{
+			Name: "arc-system",
+			Namespaces: []config.PulsarNamespace{{
+				Name: "events",
+				Topics: []config.PulsarTopic{
+					{Name: "agent-lifecycle", Partitions: 3},
+					{Name: "application", Partitions: 4},
+					{Name: "command-log", Partitions: 1},
+					{Name: "heartbeat"},
+				},
+			}},
+		}},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-drift"))
+	client.httpDo = srv.Client().Do
+
+	results, err := client.Provision(context.Background())
+
+	require.Len(t, results, 5)
+	results = results[1:] // tenant
+	assert.Equal(t, orchestrator.ResourceResult{
+		Resource: "persistent://arc-system/events/agent-lifecycle",
+		Action:   orchestrator.ActionUpdated,
+		Detail:   "partitions increased from 1 to 3",
+	}, results[0])
+	assert.Equal(t, orchestrator.ActionConflict, results[1].Action)
+	assert.Contains(t, results[1].Detail, "has 8 partitions, declared 4")
+	assert.Equal(t, orchestrator.ActionUnchanged, results[2].Action)
+	assert.Equal(t, orchestrator.ActionConflict, results[3].Action)
+	assert.Contains(t, results[3].Detail, "declared non-partitioned")
+
+	assert.Equal(t, []string{"/admin/v2/persistent/arc-system/events/agent-lifecycle/partitions 3"}, posts)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "persistent://arc-system/events/application: has 8 partitions")
+	assert.Contains(t, err.Error(), "persistent://arc-system/events/heartbeat: exists as a partitioned topic")
+}
+
+func TestProvision_PartitionedNameTakenByNonPartitioned(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/partitions") {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := makePulsarClient(srv)
+	results, err := client.Provision(context.Background())
+
+	require.Error(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, orchestrator.ActionConflict, results[1].Action)
+	assert.Contains(t, results[1].Detail, "exists as a non-partitioned topic")
+}
+
+func TestProvision_ConflictDoesNotTripBreaker(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(withPartitions(map[string]int{"agent-lifecycle": 9}, pulsarFixedHandler(http.StatusNoContent)))
+	defer srv.Close()
+
+	client := makePulsarClientWithCB(srv, "pulsar-conflict-cb")
+	for range 4 {
+		_, err := client.Provision(context.Background())
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "circuit open")
+	}
+}
+
+// schemaLayout returns a config with one topic whose schema is the given
+// AsyncAPI message from the contracts testdata.
+func schemaLayout(adminURL, message string) config.PulsarConfig {
+	return config.PulsarConfig{
+		AdminURL: adminURL,
+		Tenants: []config.PulsarTenant{{
+			Name: "arc-system",
+			Namespaces: []config.PulsarNamespace{{
+				Name: "events",
+				Topics: []config.PulsarTopic{{
+					Name:       "agent-lifecycle",
+					Partitions: 3,
+					Schema: &config.PulsarTopicSchema{
+						File:    "../contracts/testdata/messages.yaml",
+						Message: message,
+					},
+				}},
+			}},
+		}},
+	}
+}
+
+// fakeSchemaRegistry serves the schemas admin endpoints for a single topic.
+type fakeSchemaRegistry struct {
+	mu         sync.Mutex
+	current    *postSchemaPayload
+	compatible bool
+	uploads    int
+	checks     int
+}
+
+func (f *fakeSchemaRegistry) handler() http.HandlerFunc {
+	return withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		const base = "/admin/v2/schemas/arc-system/events/agent-lifecycle/"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"schema":
+			if f.current == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"version": 0, "type": f.current.Type, "data": f.current.Schema})
+		case r.Method == http.MethodPost && r.URL.Path == base+"compatibility":
+			f.checks++
+			_ = json.NewEncoder(w).Encode(map[string]any{"compatibility": f.compatible, "schemaCompatibilityStrategy": "FULL"})
+		case r.Method == http.MethodPost && r.URL.Path == base+"schema":
+			var p postSchemaPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			f.current = &p
+			f.uploads++
+			_ = json.NewEncoder(w).Encode(map[string]any{"version": f.uploads})
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+func TestProvision_RegistersSchema(t *testing.T) {
+	t.Parallel()
+
+	reg := &fakeSchemaRegistry{compatible: true}
+	srv := httptest.NewServer(reg.handler())
+	defer srv.Close()
+
+	client := NewPulsarClient(schemaLayout(srv.URL, "AgentLifecycle"), NewCircuitBreaker("pulsar-schema"))
+	client.httpDo = srv.Client().Do
+
+	results, err := client.Provision(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "schema:arc-system/events/agent-lifecycle", results[2].Resource)
+	assert.Equal(t, orchestrator.ActionCreated, results[2].Action)
+	require.NotNil(t, reg.current)
+	assert.Equal(t, "JSON", reg.current.Type)
+	assert.Contains(t, reg.current.Schema, `"name":"AgentLifecycle"`)
+	assert.Equal(t, 0, reg.checks, "no compatibility check without a registered schema")
+
+	// Second run: identical schema is left alone.
+	results, err = client.Provision(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.ActionUnchanged, results[2].Action)
+	assert.Equal(t, 1, reg.uploads)
+}
+
+func TestProvision_SchemaChangeChecksCompatibility(t *testing.T) {
+	t.Parallel()
+
+	for _, compatible := range []bool{true, false} {
+		reg := &fakeSchemaRegistry{
+			compatible: compatible,
+			current:    &postSchemaPayload{Type: "JSON", Schema: `{"type":"record","name":"Old","fields":[]}`},
+		}
+		srv := httptest.NewServer(reg.handler())
+
+		client := NewPulsarClient(schemaLayout(srv.URL, "AgentLifecycle"), NewCircuitBreaker("pulsar-schema-compat"))
+		client.httpDo = srv.Client().Do
+
+		results, err := client.Provision(context.Background())
+		srv.Close()
+
+		assert.Equal(t, 1, reg.checks)
+		if compatible {
+			require.NoError(t, err)
+			assert.Equal(t, orchestrator.ActionUpdated, results[2].Action)
+			assert.Equal(t, 1, reg.uploads)
+			continue
+		}
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "incompatible with the registered schema under FULL")
+		assert.Equal(t, orchestrator.ActionConflict, results[2].Action)
+		assert.Equal(t, 0, reg.uploads)
+	}
+}
+
+func TestProvision_SchemaLoadErrorStopsBeforeAdminCalls(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := NewPulsarClient(schemaLayout(srv.URL, "Untyped"), NewCircuitBreaker("pulsar-schema-load"))
+	client.httpDo = srv.Client().Do
+
+	_, err := client.Provision(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema plan")
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func subscriptionLayout(adminURL string) config.PulsarConfig {
+	return config.PulsarConfig{
+		AdminURL: adminURL,
+		Tenants: []config.PulsarTenant{{
+			Name: "arc-system",
+			Namespaces: []config.PulsarNamespace{{
+				Name: "events",
+				Topics: []config.PulsarTopic{{
+					Name:       "agent-lifecycle",
+					Partitions: 3,
+					Subscriptions: []config.PulsarSubscription{
+						{Name: "analytics", Type: "Shared", InitialPosition: "earliest"},
+						{Name: "billing", Type: "Failover"},
+					},
+				}},
+			}},
+		}},
+	}
+}
+
+func TestProvision_CreatesSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	bodies := map[string]string{}
+	var mu sync.Mutex
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/subscription/") {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bodies[r.URL.Path] = string(body)
+			mu.Unlock()
+			if strings.HasSuffix(r.URL.Path, "/billing") {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := NewPulsarClient(subscriptionLayout(srv.URL), NewCircuitBreaker("pulsar-subs"))
+	client.httpDo = srv.Client().Do
+
+	results, err := client.Provision(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, orchestrator.ResourceResult{
+		Resource: "subscription:analytics@persistent://arc-system/events/agent-lifecycle",
+		Action:   orchestrator.ActionCreated,
+	}, results[2])
+	assert.Equal(t, orchestrator.ActionUnchanged, results[3].Action)
+
+	base := "/admin/v2/persistent/arc-system/events/agent-lifecycle/subscription/"
+	assert.JSONEq(t, `{"ledgerId":-1,"entryId":-1,"partitionIndex":-1}`, bodies[base+"analytics"])
+	assert.JSONEq(t, `{"ledgerId":9223372036854775807,"entryId":9223372036854775807,"partitionIndex":-1}`, bodies[base+"billing"])
+}
+
+func TestPulsarProbe_SubscriptionBacklog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		stats        string
+		wantDegraded bool
+		wantWarning  string
+	}{
+		{
+			name: "healthy",
+			stats: `{"subscriptions":{
+				"analytics":{"msgBacklog":42,"type":"Shared","consumers":[{"consumerName":"a"},{"consumerName":"b"}]},
+				"billing":{"msgBacklog":0,"type":"Exclusive","consumers":[]}}}`,
+		},
+		{
+			name:         "missing subscription",
+			stats:        `{"subscriptions":{"analytics":{"msgBacklog":42,"type":"Shared","consumers":[]}}}`,
+			wantDegraded: true,
+			wantWarning:  "subscription billing on persistent://arc-system/events/agent-lifecycle does not exist",
+		},
+		{
+			name: "consumer attached with wrong type",
+			stats: `{"subscriptions":{
+				"analytics":{"msgBacklog":42,"type":"Exclusive","consumers":[{"consumerName":"a"}]},
+				"billing":{"msgBacklog":0,"type":"Failover","consumers":[]}}}`,
+			wantDegraded: true,
+			wantWarning:  "subscription analytics on persistent://arc-system/events/agent-lifecycle is Exclusive, declared Shared",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/admin/v2/tenants":
+					_, _ = w.Write([]byte(`["arc-system"]`))
+				case "/admin/v2/persistent/arc-system/events/agent-lifecycle/partitioned-stats":
+					_, _ = w.Write([]byte(tc.stats))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			client := NewPulsarClient(subscriptionLayout(srv.URL), NewCircuitBreaker("pulsar-sub-health-"+tc.name))
+			client.httpDo = srv.Client().Do
+
+			result := client.Probe(context.Background())
+			require.True(t, result.OK, result.Error)
+			assert.Equal(t, tc.wantDegraded, result.Degraded)
+			if tc.wantWarning != "" {
+				assert.Contains(t, result.Warnings, tc.wantWarning)
+			}
+
+			health, ok := result.Details.(*pulsarHealth)
+			require.True(t, ok)
+			require.Len(t, health.Subscriptions, 2)
+			assert.Equal(t, "analytics", health.Subscriptions[0].Name)
+			assert.Equal(t, int64(42), health.Subscriptions[0].Backlog)
+		})
+	}
+}
+
+func TestPulsarProbe_Canary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		canaryErr error
+	}{
+		{name: "round trip"},
+		{name: "canary fails", canaryErr: errors.New("canary not received within 5s")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/admin/v2/tenants" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				time.Sleep(5 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			client := makePulsarClient(srv)
+			client.canaryTopic = "persistent://arc-system/events/cortex-canary"
+			client.canary = func(context.Context) (time.Duration, error) {
+				return 250 * time.Millisecond, tc.canaryErr
+			}
+
+			result := client.Probe(context.Background())
+
+			assert.True(t, result.OK)
+			assert.Empty(t, result.Error)
+			if tc.canaryErr == nil {
+				assert.NotContains(t, result.Warnings, "canary: canary not received within 5s")
+			} else {
+				assert.True(t, result.Degraded)
+				assert.Contains(t, result.Warnings, "canary: canary not received within 5s")
+			}
+			assert.Less(t, result.LatencyMs, int64(250), "admin latency must not include the canary")
+
+			health, ok := result.Details.(*pulsarHealth)
+			require.True(t, ok)
+			require.NotNil(t, health.Canary)
+			assert.Equal(t, "persistent://arc-system/events/cortex-canary", health.Canary.Topic)
+			if tc.canaryErr == nil {
+				assert.Equal(t, int64(250), health.Canary.RoundTripMs)
+				assert.Empty(t, health.Canary.Error)
+			} else {
+				assert.NotEmpty(t, health.Canary.Error)
+			}
+		})
+	}
+}
+
+func TestNewPulsarClient_CanaryRequiresServiceURL(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.PulsarConfig{
+		AdminURL: "http://localhost:8080",
+		Tenant:   "arc-system",
+		Canary:   config.PulsarCanaryConfig{Enabled: true},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-canary-off"))
+	assert.Nil(t, client.canary)
+
+	cfg.ServiceURL = "pulsar://localhost:6650"
+	cfg.Canary.Timeout = time.Second
+	cfg.DataPlane = config.PulsarDataPlaneConfig{ConnectionTimeout: 10 * time.Second, OperationTimeout: 30 * time.Second}
+	client = NewPulsarClient(cfg, NewCircuitBreaker("pulsar-canary-on"))
+	assert.NotNil(t, client.canary)
+	assert.Equal(t, "persistent://arc-system/events/cortex-canary", client.canaryTopic)
+	// The data-plane client keeps its own timeouts, not the canary's.
+	assert.Equal(t, 10*time.Second, client.dataPlane.opts.ConnectionTimeout)
+	assert.Equal(t, 30*time.Second, client.dataPlane.opts.OperationTimeout)
+	client.Close()
+}
+
+func TestPulsarProbe_TopicStats(t *testing.T) {
+	t.Parallel()
+
+	const stats = `{
+		"msgRateIn": 120.5, "msgRateOut": 80.25, "storageSize": 4096,
+		"publishers": [{"producerName": "p-0"}, {"producerName": "p-1"}],
+		"subscriptions": {
+			"analytics": {"msgBacklog": 42, "type": "Shared", "consumers": [{"consumerName": "a"}, {"consumerName": "b"}]},
+			"billing": {"msgBacklog": 0, "type": "Failover", "consumers": []},
+			"reasoner": {"msgBacklog": 1500, "type": "Shared", "consumers": [{"consumerName": "r"}]}
+		}}`
+
+	tests := []struct {
+		name         string
+		global       int64
+		topic        int64
+		wantDegraded bool
+		wantWarning  string
+	}{
+		{name: "below threshold", global: 10000},
+		{name: "threshold disabled"},
+		{
+			name: "global threshold exceeded", global: 1000, wantDegraded: true,
+			wantWarning: "subscription reasoner on persistent://arc-system/events/agent-lifecycle has a backlog of 1500 messages (threshold 1000)",
+		},
+		{
+			name: "topic threshold overrides global", global: 10000, topic: 10, wantDegraded: true,
+			wantWarning: "subscription analytics on persistent://arc-system/events/agent-lifecycle has a backlog of 42 messages (threshold 10)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/admin/v2/tenants":
+					_, _ = w.Write([]byte(`["arc-system"]`))
+				case "/admin/v2/persistent/arc-system/events/agent-lifecycle/partitioned-stats":
+					_, _ = w.Write([]byte(stats))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg := subscriptionLayout(srv.URL)
+			cfg.BacklogThreshold = tc.global
+			cfg.Tenants[0].Namespaces[0].Topics[0].BacklogThreshold = tc.topic
+			client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-topic-stats-"+tc.name))
+			client.httpDo = srv.Client().Do
+
+			result := client.Probe(context.Background())
+			require.True(t, result.OK, result.Error)
+			assert.Equal(t, tc.wantDegraded, result.Degraded, result.Warnings)
+			if tc.wantWarning != "" {
+				assert.Contains(t, result.Warnings, tc.wantWarning)
+			}
+
+			health, ok := result.Details.(*pulsarHealth)
+			require.True(t, ok)
+			require.Len(t, health.Topics, 1)
+			topic := health.Topics[0]
+			assert.True(t, topic.Exists)
+			assert.Equal(t, 3, topic.Partitions)
+			assert.InDelta(t, 120.5, topic.MsgRateIn, 0.001)
+			assert.InDelta(t, 80.25, topic.MsgRateOut, 0.001)
+			assert.Equal(t, int64(4096), topic.StorageSize)
+			assert.Equal(t, 2, topic.Producers)
+			assert.Equal(t, 3, topic.Consumers)
+			assert.Equal(t, map[string]int64{"analytics": 42, "billing": 0, "reasoner": 1500}, topic.Backlog)
+		})
+	}
+}
+
+func TestPulsarProbe_MissingTopicDegrades(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin/v2/tenants" {
+			_, _ = w.Write([]byte(`["arc-system"]`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := makePulsarClient(srv)
+	result := client.Probe(context.Background())
+
+	require.True(t, result.OK, result.Error)
+	assert.True(t, result.Degraded)
+	assert.Contains(t, result.Warnings, "topic persistent://arc-system/logs/application does not exist")
+}
+
+func TestPulsarProbe_StatsErrorDegradesOneTopic(t *testing.T) {
+	t.Parallel()
+
+	var statsCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin/v2/tenants":
+			_, _ = w.Write([]byte(`["arc-system"]`))
+		case "/admin/v2/persistent/arc-system/logs/application/partitioned-stats":
+			statsCalls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := makePulsarClient(srv)
+	client.maxRetries, client.retryBackoff = 3, time.Millisecond
+	result := client.Probe(context.Background())
+
+	require.True(t, result.OK, result.Error)
+	assert.True(t, result.Degraded)
+	assert.Equal(t, int32(1), statsCalls.Load(), "health reads are not retried")
+	assert.Equal(t, []string{
+		"topic persistent://arc-system/logs/application stats unavailable: " +
+			"GET stats of persistent://arc-system/logs/application returned HTTP 503",
+	}, result.Warnings)
+
+	health := result.Details.(*pulsarHealth)
+	require.Len(t, health.Topics, 3)
+	assert.True(t, health.Topics[0].Exists)
+	assert.False(t, health.Topics[1].Exists)
+	assert.Contains(t, health.Topics[1].Error, "HTTP 503")
+	assert.True(t, health.Topics[2].Exists)
+}
+
+func TestProvision_RetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		failures  int32
+		wantErr   string
+		wantCalls int32
+	}{
+		{name: "recovers after broker starts", failures: 2, wantCalls: 3},
+		{name: "gives up after max retries", failures: 10, wantErr: "HTTP 503", wantCalls: 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var tenantCalls atomic.Int32
+			srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/admin/v2/tenants/arc-system" && tenantCalls.Add(1) <= tc.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg := config.PulsarConfig{
+				AdminURL: srv.URL,
+				Tenant:   "arc-system",
+				HTTP:     config.PulsarHTTPConfig{MaxRetries: 3, RetryBackoff: time.Millisecond},
+			}
+			client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-retry-"+tc.name))
+
+			_, err := client.Provision(context.Background())
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantCalls, tenantCalls.Load())
+		})
+	}
+}
+
+func TestProvision_BoundedConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(withPartitions(nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/persistent/") {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			inFlight.Add(-1)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var topics []config.PulsarTopic
+	for i := range 8 {
+		topics = append(topics, config.PulsarTopic{Name: fmt.Sprintf("topic-%d", i)})
+	}
+	cfg := config.PulsarConfig{
+		AdminURL:    srv.URL,
+		Concurrency: 3,
+		Tenants: []config.PulsarTenant{{
+			Name:       "arc-system",
+			Namespaces: []config.PulsarNamespace{{Name: "events", Topics: topics}},
+		}},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-concurrency"))
+	client.httpDo = srv.Client().Do
+
+	results, err := client.Provision(context.Background())
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Greater(t, peak.Load(), int32(1), "topics should be created in parallel")
+	require.Len(t, results, 9)
+	for i, r := range results[1:] {
+		assert.Equal(t, fmt.Sprintf("persistent://arc-system/events/topic-%d", i), r.Resource)
+	}
+}
+
+func TestProvision_RequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := config.PulsarConfig{
+		AdminURL: srv.URL,
+		Tenant:   "arc-system",
+		HTTP:     config.PulsarHTTPConfig{Timeout: 50 * time.Millisecond},
+	}
+	client := NewPulsarClient(cfg, NewCircuitBreaker("pulsar-timeout"))
+
+	_, err := client.Provision(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Client.Timeout")
+}
@@ -0,0 +1,148 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"arc-framework/cortex/internal/config"
+	"arc-framework/cortex/internal/orchestrator"
+)
+
+// partitionedMetadata is the admin v2 PartitionedTopicMetadata body.
+type partitionedMetadata struct {
+	Partitions int `json:"partitions"`
+}
+
+// reconcileTopic brings a topic in line with its declaration. The existing
+// partition count is read first:
+//   - missing topics are created;
+//   - partitioned topics below the declared count are grown;
+//   - topics above the declared count, or with the wrong shape (partitioned
+//     vs non-partitioned), are reported as conflicts because Pulsar cannot
+//     shrink or convert a topic in place.
+func (c *PulsarClient) reconcileTopic(ctx context.Context, tenant, namespace string, topic config.PulsarTopic) (orchestrator.ResourceResult, error) {
+	name := fmt.Sprintf("%s://%s/%s/%s", topic.Domain(), tenant, namespace, topic.Name)
+	base := fmt.Sprintf("%s/admin/v2/%s/%s/%s/%s", c.adminURL, topic.Domain(), tenant, namespace, topic.Name)
+	result := orchestrator.ResourceResult{Resource: name}
+
+	current, err := c.partitionCount(ctx, base, name)
+	if err != nil {
+		return result, err
+	}
+
+	want := topic.Partitions
+	switch {
+	case want == 0 && current > 0:
+		result.Action = orchestrator.ActionConflict
+		result.Detail = fmt.Sprintf("exists as a partitioned topic with %d partitions, declared non-partitioned", current)
+		return result, nil
+
+	case want == 0:
+		created, err := c.createResource(ctx, base, nil, "topic "+name)
+		if err != nil {
+			return result, err
+		}
+		result.Action = createdOrUnchanged(created)
+		return result, nil
+
+	case current == 0:
+		created, err := c.createResource(ctx, base+"/partitions", []byte(strconv.Itoa(want)), "topic "+name)
+		if err != nil {
+			return result, err
+		}
+		if !created {
+			// Metadata said 0 partitions but the name is taken: it is a
+			// non-partitioned topic.
+			result.Action = orchestrator.ActionConflict
+			result.Detail = fmt.Sprintf("exists as a non-partitioned topic, declared %d partitions", want)
+			return result, nil
+		}
+		result.Action = orchestrator.ActionCreated
+		return result, nil
+
+	case current < want:
+		label := "partitions of topic " + name
+		if err := c.setPolicy(ctx, http.MethodPost, base+"/partitions", []byte(strconv.Itoa(want)), label); err != nil {
+			return result, err
+		}
+		result.Action = orchestrator.ActionUpdated
+		result.Detail = fmt.Sprintf("partitions increased from %d to %d", current, want)
+		return result, nil
+
+	case current > want:
+		result.Action = orchestrator.ActionConflict
+		result.Detail = fmt.Sprintf("has %d partitions, declared %d; Pulsar cannot reduce partitions", current, want)
+		return result, nil
+
+	default:
+		result.Action = orchestrator.ActionUnchanged
+		return result, nil
+	}
+}
+
+// partitionCount reads the partitioned metadata of a topic. Zero means the
+// topic is non-partitioned or does not exist; a 404 is treated the same way
+// because brokers differ in how they answer for unknown topics.
+func (c *PulsarClient) partitionCount(ctx context.Context, base, name string) (int, error) {
+	label := "partitioned metadata of " + name
+	resp, err := c.send(ctx, http.MethodGet, base+"/partitions", nil, label)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("GET %s returned HTTP %d%s", label, resp.StatusCode, pulsarReason(resp.Body))
+	}
+
+	var meta partitionedMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return 0, fmt.Errorf("decoding %s: %w", label, err)
+	}
+	return meta.Partitions, nil
+}
+
+// createResource sends a PUT and reports whether the resource was created
+// (204) or already existed (409).
+func (c *PulsarClient) createResource(ctx context.Context, url string, body []byte, label string) (bool, error) {
+	resp, err := c.send(ctx, http.MethodPut, url, body, label)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, fmt.Errorf("PUT %s returned HTTP %d%s", label, resp.StatusCode, pulsarReason(resp.Body))
+	}
+}
+
+func createdOrUnchanged(created bool) string {
+	if created {
+		return orchestrator.ActionCreated
+	}
+	return orchestrator.ActionUnchanged
+}
+
+// conflictError joins one error per conflicting resource, or returns nil.
+func conflictError(results []orchestrator.ResourceResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Action == orchestrator.ActionConflict {
+			errs = append(errs, fmt.Errorf("%s: %s", r.Resource, r.Detail))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package clients
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+func TestBuildTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name       string
+		cfg        config.TLSConfig
+		wantNil    bool
+		wantErrSub string
+	}{
+		{
+			name:    "disabled returns nil",
+			cfg:     config.TLSConfig{},
+			wantNil: true,
+		},
+		{
+			name: "server name and insecure skip",
+			cfg:  config.TLSConfig{ServerName: "arc-messaging", InsecureSkipVerify: true},
+		},
+		{
+			name:       "missing CA file",
+			cfg:        config.TLSConfig{CAFile: "/nonexistent/ca.pem"},
+			wantErrSub: "reading CA file",
+		},
+		{
+			name:       "CA file without certificates",
+			cfg:        config.TLSConfig{CAFile: notPEM},
+			wantErrSub: "no certificates found",
+		},
+		{
+			name:       "cert without key",
+			cfg:        config.TLSConfig{CertFile: "/tmp/client.pem"},
+			wantErrSub: "both cert_file and key_file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := buildTLSConfig(tc.cfg)
+			if tc.wantErrSub != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErrSub)
+				return
+			}
+			require.NoError(t, err)
+			if tc.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, tc.cfg.ServerName, got.ServerName)
+			assert.Equal(t, tc.cfg.InsecureSkipVerify, got.InsecureSkipVerify)
+		})
+	}
+}
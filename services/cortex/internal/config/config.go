@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +17,9 @@ type Config struct {
 	Server    ServerConfig    `mapstructure:"server"`
 	Telemetry TelemetryConfig `mapstructure:"telemetry"`
 	Bootstrap BootstrapConfig `mapstructure:"bootstrap"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Audit     AuditConfig     `mapstructure:"audit"`
+	OpenBao   OpenBaoConfig   `mapstructure:"openbao"`
 }
 
 type ServerConfig struct {
@@ -46,16 +53,215 @@ type PostgresConfig struct {
 	DB       string `mapstructure:"db"`
 	SSLMode  string `mapstructure:"ssl_mode"`
 	MaxConns int32  `mapstructure:"max_conns"`
+
+	// SSLRootCert verifies the server (with ssl_mode verify-ca or
+	// verify-full); SSLCert and SSLKey present a client certificate.
+	SSLRootCert string `mapstructure:"ssl_root_cert"`
+	SSLCert     string `mapstructure:"ssl_cert"`
+	SSLKey      string `mapstructure:"ssl_key"`
+
+	// Hosts lists host[:port] entries tried in order, replacing Host and
+	// Port; TargetSessionAttrs (e.g. read-write) picks the member of a
+	// primary/replica pair to use.
+	Hosts              []string      `mapstructure:"hosts"`
+	TargetSessionAttrs string        `mapstructure:"target_session_attrs"`
+	ApplicationName    string        `mapstructure:"application_name"`
+	ConnectTimeout     time.Duration `mapstructure:"connect_timeout"`
+
+	// DSN, when set, is used verbatim instead of the fields above. Host,
+	// Port, User and DB are filled from it at Load time.
+	DSN string `mapstructure:"dsn"`
+
+	// HealthCheckPeriod is how often idle pool connections are checked;
+	// MaxConnIdleTime closes connections idle for longer. Zero keeps the
+	// pgxpool defaults.
+	HealthCheckPeriod time.Duration `mapstructure:"health_check_period"`
+	MaxConnIdleTime   time.Duration `mapstructure:"max_conn_idle_time"`
+
+	Migrations   PostgresMigrationsConfig   `mapstructure:"migrations"`
+	Expectations PostgresExpectationsConfig `mapstructure:"expectations"`
+	Seeds        PostgresSeedsConfig        `mapstructure:"seeds"`
+
+	// Provision enables reconciling Databases and Roles during bootstrap. It
+	// is off by default because new roles need a password source (a
+	// configured password or OpenBao). Empty lists fall back to the platform
+	// defaults; see RoleLayout.
+	Provision bool               `mapstructure:"provision"`
+	Databases []PostgresDatabase `mapstructure:"databases"`
+	Roles     []PostgresRole     `mapstructure:"roles"`
+
+	// MinServerVersion is the oldest accepted server, as major or
+	// major.minor. Extensions must be installed in DB at or above their
+	// MinVersion; missing ones are created, and outdated ones updated, when
+	// CreateExtensions is set and the server ships a suitable version.
+	MinServerVersion string              `mapstructure:"min_server_version"`
+	Extensions       []PostgresExtension `mapstructure:"extensions"`
+	CreateExtensions bool                `mapstructure:"create_extensions"`
+
+	Health PostgresHealthConfig `mapstructure:"health"`
+}
+
+// PostgresHealthConfig holds the thresholds that mark the Postgres probe
+// degraded. A zero value disables the corresponding check and its query.
+// Replicas lists the application_name of each standby expected to stream
+// from the primary; MaxReplicaLag applies to those standbys only.
+type PostgresHealthConfig struct {
+	MaxConnectionRatio float64       `mapstructure:"max_connection_ratio"`
+	MaxTransactionAge  time.Duration `mapstructure:"max_transaction_age"`
+	Replicas           []string      `mapstructure:"replicas"`
+	MaxReplicaLag      time.Duration `mapstructure:"max_replica_lag"`
+	MaxDatabaseBytes   int64         `mapstructure:"max_database_bytes"`
+}
+
+// PostgresMigrationsConfig selects the migration sources used by
+// `cortex migrate`. Embedded enables the platform migrations compiled into
+// Cortex; Dirs adds per-service directories as service=path entries. Auto
+// applies every pending migration during the Postgres bootstrap phase, so a
+// default deployment converges instead of staying degraded until someone
+// runs `cortex migrate up`.
+type PostgresMigrationsConfig struct {
+	Embedded bool     `mapstructure:"embedded"`
+	Dirs     []string `mapstructure:"dirs"`
+	Auto     bool     `mapstructure:"auto"`
+}
+
+// PostgresExpectationsConfig selects the schema expectations checked by the
+// Postgres probe. Embedded enables the expectations compiled into Cortex
+// (the reasoner RAG schema); Files adds one YAML or JSON file per service.
+type PostgresExpectationsConfig struct {
+	Embedded bool     `mapstructure:"embedded"`
+	Files    []string `mapstructure:"files"`
 }
 
+// PostgresSeedsConfig selects the seeds applied by `cortex seed apply`.
+// Embedded enables the sample data compiled into Cortex, meant for
+// development environments; Files adds seed files and directories of them.
+type PostgresSeedsConfig struct {
+	Embedded bool     `mapstructure:"embedded"`
+	Files    []string `mapstructure:"files"`
+}
+
+// NATSConfig holds the arc-messaging connection settings. At most one of the
+// credential sources (user/password, token, nkey seed, creds file) should be
+// set; the *_file variants are read once at Load time so secrets can be
+// mounted from files instead of passed through the environment.
 type NATSConfig struct {
-	URL string `mapstructure:"url"`
+	URL          string           `mapstructure:"url"`
+	User         string           `mapstructure:"user"`
+	Password     string           `mapstructure:"password"`
+	PasswordFile string           `mapstructure:"password_file"`
+	Token        string           `mapstructure:"token"`
+	TokenFile    string           `mapstructure:"token_file"`
+	NKeySeedFile string           `mapstructure:"nkey_seed_file"`
+	CredsFile    string           `mapstructure:"creds_file"`
+	TLS          TLSConfig        `mapstructure:"tls"`
+	Health       NATSHealthConfig `mapstructure:"health"`
+
+	// Contracts lists AsyncAPI documents whose NATS channels must be captured
+	// by a provisioned stream; channels declaring x-nats-stream add their
+	// subject to that stream. CoreSubjects lists subjects that are served by
+	// core NATS only and are intentionally left without a stream.
+	Contracts    []string `mapstructure:"contracts"`
+	CoreSubjects []string `mapstructure:"core_subjects"`
+
+	// Embedded starts an in-process nats-server with JetStream for local
+	// development; URL is then replaced by the embedded server's address.
+	// Only `cortex server` and `cortex bootstrap` start it; the operator
+	// subcommands connect to the running one at EmbeddedURL.
+	// The server requires the configured user/password or token; nkey,
+	// creds and TLS settings cannot be combined with it.
+	// An empty EmbeddedStoreDir uses a temp dir removed on shutdown, and
+	// EmbeddedInMemory provisions streams with memory storage instead.
+	Embedded         bool   `mapstructure:"embedded"`
+	EmbeddedHost     string `mapstructure:"embedded_host"`
+	EmbeddedPort     int    `mapstructure:"embedded_port"`
+	EmbeddedStoreDir string `mapstructure:"embedded_store_dir"`
+	EmbeddedInMemory bool   `mapstructure:"embedded_in_memory"`
+}
+
+// validate rejects client settings the embedded server cannot honour, so
+// Cortex never connects to it with credentials or TLS it does not check.
+func (c NATSConfig) validate() error {
+	if !c.Embedded {
+		return nil
+	}
+	var errs []error
+	if c.NKeySeedFile != "" {
+		errs = append(errs, errors.New("embedded server does not support nkey_seed_file"))
+	}
+	if c.CredsFile != "" {
+		errs = append(errs, errors.New("embedded server does not support creds_file"))
+	}
+	if c.TLS.Enabled() {
+		errs = append(errs, errors.New("embedded server does not support tls"))
+	}
+	return errors.Join(errs...)
+}
+
+// EmbeddedURL is the client URL of an embedded server started from this
+// config by another Cortex process. A wildcard host is reached over
+// loopback.
+func (c NATSConfig) EmbeddedURL() string {
+	host := c.EmbeddedHost
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "nats://" + net.JoinHostPort(host, strconv.Itoa(c.EmbeddedPort))
+}
+
+// NATSHealthConfig holds the thresholds that mark the NATS probe degraded.
+// A zero value disables the corresponding check.
+type NATSHealthConfig struct {
+	MaxStorageRatio    float64       `mapstructure:"max_storage_ratio"`
+	MaxMemoryRatio     float64       `mapstructure:"max_memory_ratio"`
+	MaxConsumerPending uint64        `mapstructure:"max_consumer_pending"`
+	MaxMessageAge      time.Duration `mapstructure:"max_message_age"`
+}
+
+// TLSConfig describes client-side TLS settings shared by the infrastructure
+// clients. TLS is enabled when any CA, certificate, or server name is set.
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// Enabled reports whether any TLS option has been configured.
+func (t TLSConfig) Enabled() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerName != "" || t.InsecureSkipVerify
 }
 
 type PulsarConfig struct {
 	AdminURL   string `mapstructure:"admin_url"`
 	ServiceURL string `mapstructure:"service_url"`
-	Tenant     string `mapstructure:"tenant"`
+	// Tenant is the platform tenant. When Tenants is empty the default
+	// events/logs/audit layout is provisioned under it.
+	Tenant string `mapstructure:"tenant"`
+	// Clusters is the allowedClusters list used by tenants that do not set
+	// their own.
+	Clusters []string       `mapstructure:"clusters"`
+	Tenants  []PulsarTenant `mapstructure:"tenants"`
+	// Token is sent as a bearer token on admin requests. OAuth2 takes
+	// precedence when configured.
+	Token     string             `mapstructure:"token"`
+	TokenFile string             `mapstructure:"token_file"`
+	OAuth2    PulsarOAuth2Config `mapstructure:"oauth2"`
+	TLS       TLSConfig          `mapstructure:"tls"`
+	HTTP      PulsarHTTPConfig   `mapstructure:"http"`
+	// DataPlane tunes the binary-protocol client on ServiceURL shared by the
+	// canary and the audit worker.
+	DataPlane PulsarDataPlaneConfig `mapstructure:"data_plane"`
+	// Concurrency bounds how many namespaces or topics are created at once
+	// after their tenant exists. Values below one provision sequentially.
+	Concurrency int `mapstructure:"concurrency"`
+	// Canary configures the data-plane probe against ServiceURL.
+	Canary PulsarCanaryConfig `mapstructure:"canary"`
+	// BacklogThreshold degrades the probe when any subscription on a
+	// declared topic has more messages in its backlog. Zero disables the
+	// check; topics may set their own.
+	BacklogThreshold int64 `mapstructure:"backlog_threshold"`
 }
 
 type RedisConfig struct {
@@ -65,6 +271,49 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// StorageConfig points at the S3-compatible object store (arc-storage, MinIO)
+// used when a backup or restore location is an s3:// URL.
+type StorageConfig struct {
+	Endpoint      string `mapstructure:"endpoint"`
+	AccessKey     string `mapstructure:"access_key"`
+	SecretKey     string `mapstructure:"secret_key"`
+	SecretKeyFile string `mapstructure:"secret_key_file"`
+	Region        string `mapstructure:"region"`
+	UseSSL        bool   `mapstructure:"use_ssl"`
+}
+
+// OpenBaoConfig points at the KV version 2 engine of arc-vault (OpenBao).
+// Postgres role passwords are stored at <mount>/data/<postgres_path>/<role>
+// with the keys username and password.
+type OpenBaoConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Address      string        `mapstructure:"address"`
+	Token        string        `mapstructure:"token"`
+	TokenFile    string        `mapstructure:"token_file"`
+	Mount        string        `mapstructure:"mount"`
+	PostgresPath string        `mapstructure:"postgres_path"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+// AuditConfig enables the audit worker, which copies records from the Pulsar
+// audit topic into the append-only audit.events table in arc-persistence and
+// serves them at GET /api/v1/audit. Topic defaults to
+// persistent://<tenant>/audit/command-log.
+type AuditConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Topic        string `mapstructure:"topic"`
+	Subscription string `mapstructure:"subscription"`
+}
+
+// AuditTopic returns the configured audit topic or the default under the
+// platform tenant.
+func (c *Config) AuditTopic() string {
+	if c.Audit.Topic != "" {
+		return c.Audit.Topic
+	}
+	return fmt.Sprintf("persistent://%s/audit/command-log", c.Bootstrap.Pulsar.Tenant)
+}
+
 // Load reads config from the optional YAML file at path, then overlays
 // environment variables with the CORTEX_ prefix (e.g. CORTEX_SERVER_PORT).
 func Load(path string) (*Config, error) {
@@ -88,9 +337,75 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("unmarshalling config: %w", err)
 	}
 
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Bootstrap.NATS.validate(); err != nil {
+		return nil, fmt.Errorf("nats: %w", err)
+	}
+
+	if err := cfg.Bootstrap.Pulsar.validate(); err != nil {
+		return nil, fmt.Errorf("pulsar layout: %w", err)
+	}
+
+	if err := cfg.Bootstrap.Postgres.applyDSN(); err != nil {
+		return nil, fmt.Errorf("postgres dsn: %w", err)
+	}
+
+	if err := cfg.Bootstrap.Postgres.validate(); err != nil {
+		return nil, fmt.Errorf("postgres layout: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// resolveSecrets replaces secret values with the contents of their *_file
+// counterparts. A file always wins over an inline value.
+func (c *Config) resolveSecrets() error {
+	nats := &c.Bootstrap.NATS
+	if err := readSecretFile(nats.PasswordFile, &nats.Password); err != nil {
+		return fmt.Errorf("nats password: %w", err)
+	}
+	if err := readSecretFile(nats.TokenFile, &nats.Token); err != nil {
+		return fmt.Errorf("nats token: %w", err)
+	}
+	pulsar := &c.Bootstrap.Pulsar
+	if err := readSecretFile(pulsar.TokenFile, &pulsar.Token); err != nil {
+		return fmt.Errorf("pulsar token: %w", err)
+	}
+	if err := readSecretFile(pulsar.OAuth2.ClientSecretFile, &pulsar.OAuth2.ClientSecret); err != nil {
+		return fmt.Errorf("pulsar oauth2 client secret: %w", err)
+	}
+	if err := readSecretFile(c.Storage.SecretKeyFile, &c.Storage.SecretKey); err != nil {
+		return fmt.Errorf("storage secret key: %w", err)
+	}
+	if err := readSecretFile(c.OpenBao.TokenFile, &c.OpenBao.Token); err != nil {
+		return fmt.Errorf("openbao token: %w", err)
+	}
+	for i := range c.Bootstrap.Postgres.Roles {
+		role := &c.Bootstrap.Postgres.Roles[i]
+		if err := readSecretFile(role.PasswordFile, &role.Password); err != nil {
+			return fmt.Errorf("postgres role %s password: %w", role.Name, err)
+		}
+	}
+	return nil
+}
+
+// readSecretFile loads path into dst, trimming surrounding whitespace so files
+// written with a trailing newline work. An empty path leaves dst unchanged.
+func readSecretFile(path string, dst *string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading secret file %s: %w", path, err)
+	}
+	*dst = strings.TrimSpace(string(data))
+	return nil
+}
+
 func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.port", 8081)
 	v.SetDefault("server.read_timeout", 10*time.Second)
@@ -112,14 +427,111 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("bootstrap.postgres.db", "arc")
 	v.SetDefault("bootstrap.postgres.ssl_mode", "disable")
 	v.SetDefault("bootstrap.postgres.max_conns", 25)
+	v.SetDefault("bootstrap.postgres.ssl_root_cert", "")
+	v.SetDefault("bootstrap.postgres.ssl_cert", "")
+	v.SetDefault("bootstrap.postgres.ssl_key", "")
+	v.SetDefault("bootstrap.postgres.hosts", []string{})
+	v.SetDefault("bootstrap.postgres.target_session_attrs", "")
+	v.SetDefault("bootstrap.postgres.application_name", "arc-cortex")
+	v.SetDefault("bootstrap.postgres.connect_timeout", 10*time.Second)
+	v.SetDefault("bootstrap.postgres.dsn", "")
+	v.SetDefault("bootstrap.postgres.health_check_period", time.Minute)
+	v.SetDefault("bootstrap.postgres.max_conn_idle_time", 30*time.Minute)
+	v.SetDefault("bootstrap.postgres.migrations.embedded", true)
+	v.SetDefault("bootstrap.postgres.migrations.dirs", []string{})
+	v.SetDefault("bootstrap.postgres.migrations.auto", true)
+	v.SetDefault("bootstrap.postgres.expectations.embedded", true)
+	v.SetDefault("bootstrap.postgres.expectations.files", []string{})
+	v.SetDefault("bootstrap.postgres.seeds.embedded", false)
+	v.SetDefault("bootstrap.postgres.seeds.files", []string{})
+	v.SetDefault("bootstrap.postgres.provision", false)
+	v.SetDefault("bootstrap.postgres.min_server_version", "13")
+	v.SetDefault("bootstrap.postgres.create_extensions", true)
+	v.SetDefault("bootstrap.postgres.health.max_connection_ratio", 0)
+	v.SetDefault("bootstrap.postgres.health.max_transaction_age", 0)
+	v.SetDefault("bootstrap.postgres.health.replicas", []string{})
+	v.SetDefault("bootstrap.postgres.health.max_replica_lag", 30*time.Second)
+	v.SetDefault("bootstrap.postgres.health.max_database_bytes", 0)
 
 	v.SetDefault("bootstrap.nats.url", "nats://arc-messaging:4222")
+	v.SetDefault("bootstrap.nats.user", "")
+	v.SetDefault("bootstrap.nats.password", "")
+	v.SetDefault("bootstrap.nats.password_file", "")
+	v.SetDefault("bootstrap.nats.token", "")
+	v.SetDefault("bootstrap.nats.token_file", "")
+	v.SetDefault("bootstrap.nats.nkey_seed_file", "")
+	v.SetDefault("bootstrap.nats.creds_file", "")
+	v.SetDefault("bootstrap.nats.tls.ca_file", "")
+	v.SetDefault("bootstrap.nats.tls.cert_file", "")
+	v.SetDefault("bootstrap.nats.tls.key_file", "")
+	v.SetDefault("bootstrap.nats.tls.server_name", "")
+	v.SetDefault("bootstrap.nats.tls.insecure_skip_verify", false)
+	v.SetDefault("bootstrap.nats.health.max_storage_ratio", 0.9)
+	v.SetDefault("bootstrap.nats.health.max_memory_ratio", 0.9)
+	v.SetDefault("bootstrap.nats.health.max_consumer_pending", 10000)
+	v.SetDefault("bootstrap.nats.health.max_message_age", 0)
+	v.SetDefault("bootstrap.nats.contracts", []string{})
+	v.SetDefault("bootstrap.nats.core_subjects", []string{})
+	v.SetDefault("bootstrap.nats.embedded", false)
+	v.SetDefault("bootstrap.nats.embedded_host", "127.0.0.1")
+	v.SetDefault("bootstrap.nats.embedded_port", 4222)
+	v.SetDefault("bootstrap.nats.embedded_store_dir", "")
+	v.SetDefault("bootstrap.nats.embedded_in_memory", false)
 
 	v.SetDefault("bootstrap.pulsar.admin_url", "http://arc-streaming:8080")
 	v.SetDefault("bootstrap.pulsar.service_url", "pulsar://arc-streaming:6650")
 	v.SetDefault("bootstrap.pulsar.tenant", "arc-system")
+	v.SetDefault("bootstrap.pulsar.clusters", []string{"standalone"})
+	v.SetDefault("bootstrap.pulsar.token", "")
+	v.SetDefault("bootstrap.pulsar.token_file", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.issuer_url", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.token_url", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.client_id", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.client_secret", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.client_secret_file", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.audience", "")
+	v.SetDefault("bootstrap.pulsar.oauth2.scopes", []string{})
+	v.SetDefault("bootstrap.pulsar.tls.ca_file", "")
+	v.SetDefault("bootstrap.pulsar.tls.cert_file", "")
+	v.SetDefault("bootstrap.pulsar.tls.key_file", "")
+	v.SetDefault("bootstrap.pulsar.tls.server_name", "")
+	v.SetDefault("bootstrap.pulsar.tls.insecure_skip_verify", false)
+	v.SetDefault("bootstrap.pulsar.http.timeout", "30s")
+	v.SetDefault("bootstrap.pulsar.http.dial_timeout", "5s")
+	v.SetDefault("bootstrap.pulsar.http.keep_alive", "30s")
+	v.SetDefault("bootstrap.pulsar.http.idle_conn_timeout", "90s")
+	v.SetDefault("bootstrap.pulsar.http.max_idle_conns_per_host", 8)
+	v.SetDefault("bootstrap.pulsar.http.max_retries", 3)
+	v.SetDefault("bootstrap.pulsar.http.retry_backoff", "500ms")
+	v.SetDefault("bootstrap.pulsar.data_plane.connection_timeout", "10s")
+	v.SetDefault("bootstrap.pulsar.data_plane.operation_timeout", "30s")
+	v.SetDefault("bootstrap.pulsar.concurrency", 8)
+	v.SetDefault("bootstrap.pulsar.canary.enabled", false)
+	v.SetDefault("bootstrap.pulsar.canary.topic", "")
+	v.SetDefault("bootstrap.pulsar.canary.subscription", "")
+	v.SetDefault("bootstrap.pulsar.canary.timeout", "5s")
+	v.SetDefault("bootstrap.pulsar.backlog_threshold", 100000)
 
 	v.SetDefault("bootstrap.redis.host", "arc-cache")
 	v.SetDefault("bootstrap.redis.port", 6379)
 	v.SetDefault("bootstrap.redis.db", 0)
+
+	v.SetDefault("storage.endpoint", "arc-storage:9000")
+	v.SetDefault("storage.access_key", "arc")
+	v.SetDefault("storage.secret_key", "")
+	v.SetDefault("storage.secret_key_file", "")
+	v.SetDefault("storage.region", "us-east-1")
+	v.SetDefault("storage.use_ssl", false)
+
+	v.SetDefault("audit.enabled", false)
+	v.SetDefault("audit.topic", "")
+	v.SetDefault("audit.subscription", "cortex-audit")
+
+	v.SetDefault("openbao.enabled", false)
+	v.SetDefault("openbao.address", "http://arc-vault:8200")
+	v.SetDefault("openbao.token", "")
+	v.SetDefault("openbao.token_file", "")
+	v.SetDefault("openbao.mount", "secret")
+	v.SetDefault("openbao.postgres_path", "arc/postgres")
+	v.SetDefault("openbao.timeout", 10*time.Second)
 }
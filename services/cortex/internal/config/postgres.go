@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgresDatabase declares a database in arc-persistence. Owner, when set,
+// must be a declared role or an existing one; the database is reassigned to
+// it if it already exists with another owner.
+type PostgresDatabase struct {
+	Name  string `mapstructure:"name"`
+	Owner string `mapstructure:"owner"`
+}
+
+// PostgresRole declares a login role for one service. Database defaults to
+// PostgresConfig.DB. Password and PasswordFile pin the password; otherwise,
+// when OpenBao is enabled, one is generated, stored there and read back on
+// later runs so the role keeps the same password. Without either source a
+// new role is not created.
+type PostgresRole struct {
+	Name         string                `mapstructure:"name"`
+	Database     string                `mapstructure:"database"`
+	Password     string                `mapstructure:"password"`
+	PasswordFile string                `mapstructure:"password_file"`
+	Schemas      []PostgresSchemaGrant `mapstructure:"schemas"`
+}
+
+// PostgresSchemaGrant gives a role access to one schema, which is created if
+// missing and stays owned by the bootstrap user. Privileges are table
+// privileges granted on existing tables and, through default privileges, on
+// tables the bootstrap user creates later; they default to
+// PostgresDefaultTablePrivileges. Sequences are usable when INSERT is granted.
+type PostgresSchemaGrant struct {
+	Schema     string   `mapstructure:"schema"`
+	Privileges []string `mapstructure:"privileges"`
+}
+
+// PostgresDefaultTablePrivileges is the read-write grant used when a schema
+// grant lists no privileges.
+var PostgresDefaultTablePrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE"}
+
+// PostgresTablePrivileges lists the table privileges a schema grant may use.
+var PostgresTablePrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+
+// PostgresExtension requires an extension at MinVersion or newer. An empty
+// MinVersion accepts any installed version.
+type PostgresExtension struct {
+	Name       string `mapstructure:"name"`
+	MinVersion string `mapstructure:"min_version"`
+}
+
+// defaultPostgresExtensions covers the reasoner RAG schema: pgvector 0.5.0 is
+// the first release with HNSW indexes. gen_random_uuid is built into
+// Postgres 13 and later, which the default MinServerVersion requires.
+var defaultPostgresExtensions = []PostgresExtension{{Name: "vector", MinVersion: "0.5.0"}}
+
+// ExtensionLayout returns the required extensions, or the defaults when none
+// are declared.
+func (c PostgresConfig) ExtensionLayout() []PostgresExtension {
+	if len(c.Extensions) == 0 {
+		return defaultPostgresExtensions
+	}
+	return c.Extensions
+}
+
+// defaultPostgresRoles gives each platform service its own login role limited
+// to its schema. Audit events are append-only, so the audit role cannot
+// change or delete them.
+var defaultPostgresRoles = []PostgresRole{
+	{Name: "reasoner", Schemas: []PostgresSchemaGrant{{Schema: "reasoner"}}},
+	{Name: "cortex", Schemas: []PostgresSchemaGrant{{Schema: "cortex"}}},
+	{Name: "audit", Schemas: []PostgresSchemaGrant{{Schema: "audit", Privileges: []string{"SELECT", "INSERT"}}}},
+}
+
+// defaultPostgresDatabases replaces persistence/initdb/002, which only runs on
+// the first boot of an empty data directory.
+var defaultPostgresDatabases = []PostgresDatabase{{Name: "unleash"}}
+
+// RoleLayout returns the roles to provision with Database and privilege
+// defaults applied. When no roles are declared it returns the default
+// per-service roles.
+func (c PostgresConfig) RoleLayout() []PostgresRole {
+	roles := c.Roles
+	if len(roles) == 0 {
+		roles = defaultPostgresRoles
+	}
+	out := make([]PostgresRole, len(roles))
+	for i, r := range roles {
+		if r.Database == "" {
+			r.Database = c.DB
+		}
+		grants := make([]PostgresSchemaGrant, len(r.Schemas))
+		for j, g := range r.Schemas {
+			if len(g.Privileges) == 0 {
+				g.Privileges = PostgresDefaultTablePrivileges
+			}
+			grants[j] = g
+		}
+		r.Schemas = grants
+		out[i] = r
+	}
+	return out
+}
+
+// DatabaseLayout returns the databases to provision, or the defaults when
+// none are declared.
+func (c PostgresConfig) DatabaseLayout() []PostgresDatabase {
+	if len(c.Databases) == 0 {
+		return defaultPostgresDatabases
+	}
+	return c.Databases
+}
+
+// PostgresTargetSessionAttrs lists the accepted target_session_attrs values.
+var PostgresTargetSessionAttrs = []string{"any", "read-write", "read-only", "primary", "standby", "prefer-standby"}
+
+// HostList returns the hosts to connect to as host:port, applying Port to
+// Hosts entries without one.
+func (c PostgresConfig) HostList() ([]string, error) {
+	if len(c.Hosts) == 0 {
+		return []string{net.JoinHostPort(c.Host, strconv.Itoa(c.Port))}, nil
+	}
+	out := make([]string, len(c.Hosts))
+	for i, h := range c.Hosts {
+		host, port := h, strconv.Itoa(c.Port)
+		if strings.HasPrefix(h, "[") || strings.Count(h, ":") == 1 {
+			var err error
+			if host, port, err = net.SplitHostPort(h); err != nil {
+				// A bracketed IPv6 address without a port.
+				host, port = strings.Trim(h, "[]"), strconv.Itoa(c.Port)
+			}
+		}
+		if host == "" {
+			return nil, fmt.Errorf("hosts[%d]: host is required", i)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("hosts[%d]: invalid port %q", i, port)
+		}
+		out[i] = net.JoinHostPort(host, port)
+	}
+	return out, nil
+}
+
+// applyDSN fills Host, Port, User and DB from DSN so that logs and the
+// provisioning layout see the connection actually used.
+func (c *PostgresConfig) applyDSN() error {
+	if c.DSN == "" {
+		return nil
+	}
+	parsed, err := pgconn.ParseConfig(c.DSN)
+	if err != nil {
+		return err
+	}
+	c.Host, c.Port = parsed.Host, int(parsed.Port)
+	c.User, c.DB = parsed.User, parsed.Database
+	return nil
+}
+
+// validate reports missing names, duplicates and unknown privileges in the
+// declared layout. All problems are returned together.
+func (c PostgresConfig) validate() error {
+	var errs []error
+	if c.DSN == "" {
+		if _, err := c.HostList(); err != nil {
+			errs = append(errs, err)
+		}
+		if (c.SSLCert == "") != (c.SSLKey == "") {
+			errs = append(errs, errors.New("ssl_cert and ssl_key must be set together"))
+		}
+		if c.TargetSessionAttrs != "" && !slices.Contains(PostgresTargetSessionAttrs, c.TargetSessionAttrs) {
+			errs = append(errs, fmt.Errorf("target_session_attrs %q must be one of %s",
+				c.TargetSessionAttrs, strings.Join(PostgresTargetSessionAttrs, ", ")))
+		}
+	}
+
+	databases := map[string]bool{}
+	for i, db := range c.Databases {
+		if db.Name == "" {
+			errs = append(errs, fmt.Errorf("databases[%d]: name is required", i))
+			continue
+		}
+		if databases[db.Name] {
+			errs = append(errs, fmt.Errorf("database %s declared twice", db.Name))
+		}
+		databases[db.Name] = true
+	}
+
+	if c.MinServerVersion != "" {
+		if _, err := ParseServerVersion(c.MinServerVersion); err != nil {
+			errs = append(errs, fmt.Errorf("min_server_version: %w", err))
+		}
+	}
+	extensions := map[string]bool{}
+	for i, ext := range c.Extensions {
+		if ext.Name == "" {
+			errs = append(errs, fmt.Errorf("extensions[%d]: name is required", i))
+			continue
+		}
+		if extensions[ext.Name] {
+			errs = append(errs, fmt.Errorf("extension %s declared twice", ext.Name))
+		}
+		extensions[ext.Name] = true
+	}
+
+	roles := map[string]bool{}
+	for i, r := range c.Roles {
+		if r.Name == "" {
+			errs = append(errs, fmt.Errorf("roles[%d]: name is required", i))
+			continue
+		}
+		if roles[r.Name] {
+			errs = append(errs, fmt.Errorf("role %s declared twice", r.Name))
+		}
+		roles[r.Name] = true
+		if r.Name == c.User {
+			errs = append(errs, fmt.Errorf("role %s is the bootstrap user", r.Name))
+		}
+
+		schemas := map[string]bool{}
+		for j, g := range r.Schemas {
+			if g.Schema == "" {
+				errs = append(errs, fmt.Errorf("role %s schemas[%d]: schema is required", r.Name, j))
+				continue
+			}
+			if schemas[g.Schema] {
+				errs = append(errs, fmt.Errorf("role %s: schema %s granted twice", r.Name, g.Schema))
+			}
+			schemas[g.Schema] = true
+			for _, p := range g.Privileges {
+				if !slices.Contains(PostgresTablePrivileges, strings.ToUpper(p)) {
+					errs = append(errs, fmt.Errorf("role %s schema %s: unknown privilege %q", r.Name, g.Schema, p))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseServerVersion converts a major or major.minor Postgres version into
+// the server_version_num form, e.g. "16.2" into 160002.
+func ParseServerVersion(v string) (int, error) {
+	major, minor, hasMinor := strings.Cut(strings.TrimSpace(v), ".")
+	m, err := strconv.Atoi(major)
+	if err != nil || m < 10 {
+		return 0, fmt.Errorf("invalid server version %q: want major or major.minor, 10 or later", v)
+	}
+	n := 0
+	if hasMinor {
+		if n, err = strconv.Atoi(minor); err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid server version %q: want major or major.minor, 10 or later", v)
+		}
+	}
+	return m*10000 + n, nil
+}
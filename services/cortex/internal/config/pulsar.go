@@ -0,0 +1,367 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PulsarOAuth2Config enables the OAuth2 client-credentials flow for admin
+// requests. TokenURL is discovered from IssuerURL's OpenID configuration when
+// empty. Audience is sent as the "audience" token request parameter, which
+// Pulsar's OAuth2 plugin and most identity providers expect.
+type PulsarOAuth2Config struct {
+	IssuerURL        string   `mapstructure:"issuer_url"`
+	TokenURL         string   `mapstructure:"token_url"`
+	ClientID         string   `mapstructure:"client_id"`
+	ClientSecret     string   `mapstructure:"client_secret"`
+	ClientSecretFile string   `mapstructure:"client_secret_file"`
+	Audience         string   `mapstructure:"audience"`
+	Scopes           []string `mapstructure:"scopes"`
+}
+
+// Enabled reports whether OAuth2 is configured.
+func (o PulsarOAuth2Config) Enabled() bool {
+	return o.ClientID != ""
+}
+
+// PulsarHTTPConfig tunes the admin API client. Timeout bounds a single
+// request including reading the body. Requests answered with a 5xx status are
+// retried up to MaxRetries times with exponential backoff starting at
+// RetryBackoff, which covers a broker that is still starting. Zero values
+// keep the net/http defaults; MaxRetries zero disables retries.
+type PulsarHTTPConfig struct {
+	Timeout             time.Duration `mapstructure:"timeout"`
+	DialTimeout         time.Duration `mapstructure:"dial_timeout"`
+	KeepAlive           time.Duration `mapstructure:"keep_alive"`
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"`
+	MaxRetries          int           `mapstructure:"max_retries"`
+	RetryBackoff        time.Duration `mapstructure:"retry_backoff"`
+}
+
+// PulsarDataPlaneConfig tunes the binary-protocol client. ConnectionTimeout
+// bounds establishing a broker connection and OperationTimeout a producer,
+// consumer or lookup request; zero values keep the client defaults.
+type PulsarDataPlaneConfig struct {
+	ConnectionTimeout time.Duration `mapstructure:"connection_timeout"`
+	OperationTimeout  time.Duration `mapstructure:"operation_timeout"`
+}
+
+// PulsarCanaryConfig configures the data-plane probe, which produces and
+// consumes a message on a dedicated topic over ServiceURL. It is off by
+// default and a failed round trip only marks the Pulsar probe degraded.
+// Topic defaults to persistent://<tenant>/events/cortex-canary and relies on
+// topic auto-creation unless it is declared in the layout. Subscription
+// defaults to cortex-canary-<hostname> so replicas do not steal each other's
+// messages.
+type PulsarCanaryConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Topic        string        `mapstructure:"topic"`
+	Subscription string        `mapstructure:"subscription"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+// CanaryTopic returns the configured canary topic or the default under the
+// platform tenant.
+func (c PulsarConfig) CanaryTopic() string {
+	if c.Canary.Topic != "" {
+		return c.Canary.Topic
+	}
+	return fmt.Sprintf("persistent://%s/events/cortex-canary", c.Tenant)
+}
+
+// PulsarTenant declares a tenant and everything provisioned beneath it.
+type PulsarTenant struct {
+	Name       string   `mapstructure:"name"`
+	AdminRoles []string `mapstructure:"admin_roles"`
+	// AllowedClusters falls back to PulsarConfig.Clusters when empty.
+	AllowedClusters []string          `mapstructure:"allowed_clusters"`
+	Namespaces      []PulsarNamespace `mapstructure:"namespaces"`
+}
+
+// PulsarNamespace declares a namespace within a tenant.
+type PulsarNamespace struct {
+	Name     string                  `mapstructure:"name"`
+	Policies PulsarNamespacePolicies `mapstructure:"policies"`
+	Topics   []PulsarTopic           `mapstructure:"topics"`
+}
+
+// PulsarNamespacePolicies are applied on every bootstrap so drift made through
+// pulsar-admin is reverted. Unset (nil or empty) fields leave the broker
+// value untouched.
+type PulsarNamespacePolicies struct {
+	Retention    *PulsarRetention    `mapstructure:"retention"`
+	MessageTTL   *time.Duration      `mapstructure:"message_ttl"`
+	BacklogQuota *PulsarBacklogQuota `mapstructure:"backlog_quota"`
+	// Deduplication enables broker-side producer deduplication.
+	Deduplication *bool `mapstructure:"deduplication"`
+	// SchemaCompatibility is one of PulsarSchemaCompatibilityStrategies.
+	SchemaCompatibility  string `mapstructure:"schema_compatibility"`
+	MaxProducersPerTopic *int   `mapstructure:"max_producers_per_topic"`
+	MaxConsumersPerTopic *int   `mapstructure:"max_consumers_per_topic"`
+}
+
+// PulsarRetention keeps acknowledged messages for Time or until SizeMB is
+// reached. A negative value means unlimited; Pulsar requires both to be
+// zero (no retention) or both non-zero, and counts Time in whole minutes.
+type PulsarRetention struct {
+	Time   time.Duration `mapstructure:"time"`
+	SizeMB int64         `mapstructure:"size_mb"`
+}
+
+// PulsarBacklogQuota bounds unacknowledged backlog by size (bytes) and/or age.
+// Policy is one of PulsarBacklogQuotaPolicies.
+type PulsarBacklogQuota struct {
+	LimitSize int64         `mapstructure:"limit_size"`
+	LimitTime time.Duration `mapstructure:"limit_time"`
+	Policy    string        `mapstructure:"policy"`
+}
+
+// PulsarSchemaCompatibilityStrategies lists the values Pulsar accepts for a
+// namespace schema compatibility strategy.
+var PulsarSchemaCompatibilityStrategies = []string{
+	"UNDEFINED", "ALWAYS_INCOMPATIBLE", "ALWAYS_COMPATIBLE",
+	"BACKWARD", "FORWARD", "FULL",
+	"BACKWARD_TRANSITIVE", "FORWARD_TRANSITIVE", "FULL_TRANSITIVE",
+}
+
+// PulsarBacklogQuotaPolicies lists the retention policies Pulsar accepts when
+// a backlog quota is exceeded.
+var PulsarBacklogQuotaPolicies = []string{
+	"producer_request_hold", "producer_exception", "consumer_backlog_eviction",
+}
+
+// PulsarTopic declares a topic within a namespace. Partitions of zero creates
+// a non-partitioned topic.
+type PulsarTopic struct {
+	Name          string             `mapstructure:"name"`
+	Partitions    int                `mapstructure:"partitions"`
+	NonPersistent bool               `mapstructure:"non_persistent"`
+	Schema        *PulsarTopicSchema `mapstructure:"schema"`
+	// Subscriptions are created ahead of consumers so messages published
+	// before the first consumer attaches are retained. Persistent topics only.
+	Subscriptions []PulsarSubscription `mapstructure:"subscriptions"`
+	// BacklogThreshold overrides PulsarConfig.BacklogThreshold for this
+	// topic when positive.
+	BacklogThreshold int64 `mapstructure:"backlog_threshold"`
+}
+
+// PulsarSubscription declares a durable subscription on a topic. Type is the
+// subscription type consumers are expected to attach with; Pulsar fixes the
+// type when the first consumer connects, so it is checked in deep health
+// rather than set at creation. InitialPosition is earliest or latest
+// (default).
+type PulsarSubscription struct {
+	Name            string `mapstructure:"name"`
+	Type            string `mapstructure:"type"`
+	InitialPosition string `mapstructure:"initial_position"`
+}
+
+// PulsarSubscriptionTypes lists the subscription types Pulsar supports.
+var PulsarSubscriptionTypes = []string{"Exclusive", "Shared", "Failover", "Key_Shared"}
+
+// PulsarTopicSchema points at the schema registered for a topic. File is a
+// JSON Schema, an Avro schema (.avsc) or, when Message is set, an AsyncAPI
+// document whose components.messages[Message] payload is used. Type is JSON
+// or AVRO and defaults to the format of the source.
+type PulsarTopicSchema struct {
+	File    string `mapstructure:"file"`
+	Message string `mapstructure:"message"`
+	Type    string `mapstructure:"type"`
+}
+
+// Domain returns the topic domain used in admin paths and topic names.
+func (t PulsarTopic) Domain() string {
+	if t.NonPersistent {
+		return "non-persistent"
+	}
+	return "persistent"
+}
+
+// defaultPulsarNamespaces is the platform layout from the cortex setup spec,
+// provisioned under PulsarConfig.Tenant when no tenants are declared.
+// Audit history is kept forever and deduplicated; application logs expire
+// after a day if nobody consumes them.
+var defaultPulsarNamespaces = []PulsarNamespace{
+	{
+		Name:   "events",
+		Topics: []PulsarTopic{{Name: "agent-lifecycle", Partitions: 3}},
+	},
+	{
+		Name:     "logs",
+		Policies: PulsarNamespacePolicies{MessageTTL: ptr(24 * time.Hour)},
+		Topics:   []PulsarTopic{{Name: "application", Partitions: 4}},
+	},
+	{
+		Name: "audit",
+		Policies: PulsarNamespacePolicies{
+			Retention:     &PulsarRetention{Time: -1, SizeMB: -1},
+			Deduplication: ptr(true),
+		},
+		Topics: []PulsarTopic{{Name: "command-log", Partitions: 1}},
+	},
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// Layout returns the tenants to provision with cluster defaults applied. When
+// no tenants are declared it returns the default layout under Tenant.
+func (c PulsarConfig) Layout() []PulsarTenant {
+	clusters := c.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{"standalone"}
+	}
+
+	tenants := c.Tenants
+	if len(tenants) == 0 {
+		tenants = []PulsarTenant{{Name: c.Tenant, Namespaces: defaultPulsarNamespaces}}
+	}
+
+	out := make([]PulsarTenant, len(tenants))
+	for i, t := range tenants {
+		if len(t.AllowedClusters) == 0 {
+			t.AllowedClusters = clusters
+		}
+		out[i] = t
+	}
+	return out
+}
+
+// validate reports incomplete OAuth2 settings and missing names, negative
+// partition counts or thresholds and duplicates in the declared layout. All problems are
+// returned together.
+func (c PulsarConfig) validate() error {
+	var errs []error
+	if c.Concurrency < 0 {
+		errs = append(errs, errors.New("concurrency must not be negative"))
+	}
+	if c.HTTP.MaxRetries < 0 {
+		errs = append(errs, errors.New("http.max_retries must not be negative"))
+	}
+	if c.BacklogThreshold < 0 {
+		errs = append(errs, errors.New("backlog_threshold must not be negative"))
+	}
+	if c.OAuth2.Enabled() {
+		if c.OAuth2.IssuerURL == "" && c.OAuth2.TokenURL == "" {
+			errs = append(errs, errors.New("oauth2 needs issuer_url or token_url"))
+		}
+		if c.OAuth2.ClientSecret == "" {
+			errs = append(errs, errors.New("oauth2 needs client_secret or client_secret_file"))
+		}
+	}
+	tenants := map[string]bool{}
+	for i, t := range c.Tenants {
+		if t.Name == "" {
+			errs = append(errs, fmt.Errorf("tenants[%d]: name is required", i))
+			continue
+		}
+		if tenants[t.Name] {
+			errs = append(errs, fmt.Errorf("tenant %s declared twice", t.Name))
+		}
+		tenants[t.Name] = true
+
+		namespaces := map[string]bool{}
+		for j, ns := range t.Namespaces {
+			if ns.Name == "" {
+				errs = append(errs, fmt.Errorf("tenant %s namespaces[%d]: name is required", t.Name, j))
+				continue
+			}
+			if namespaces[ns.Name] {
+				errs = append(errs, fmt.Errorf("namespace %s/%s declared twice", t.Name, ns.Name))
+			}
+			namespaces[ns.Name] = true
+			if err := ns.Policies.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("namespace %s/%s: %w", t.Name, ns.Name, err))
+			}
+
+			topics := map[string]bool{}
+			for k, topic := range ns.Topics {
+				if topic.Name == "" {
+					errs = append(errs, fmt.Errorf("namespace %s/%s topics[%d]: name is required", t.Name, ns.Name, k))
+					continue
+				}
+				key := topic.Domain() + "://" + topic.Name
+				if topics[key] {
+					errs = append(errs, fmt.Errorf("topic %s://%s/%s/%s declared twice", topic.Domain(), t.Name, ns.Name, topic.Name))
+				}
+				topics[key] = true
+				if topic.Partitions < 0 {
+					errs = append(errs, fmt.Errorf("topic %s/%s/%s: partitions must not be negative", t.Name, ns.Name, topic.Name))
+				}
+				if topic.BacklogThreshold < 0 {
+					errs = append(errs, fmt.Errorf("topic %s/%s/%s: backlog_threshold must not be negative", t.Name, ns.Name, topic.Name))
+				}
+				errs = append(errs, validateSubscriptions(t.Name+"/"+ns.Name+"/"+topic.Name, topic)...)
+				if sc := topic.Schema; sc != nil {
+					if sc.File == "" {
+						errs = append(errs, fmt.Errorf("topic %s/%s/%s: schema file is required", t.Name, ns.Name, topic.Name))
+					}
+					if sc.Type != "" && sc.Type != "JSON" && sc.Type != "AVRO" {
+						errs = append(errs, fmt.Errorf("topic %s/%s/%s: schema type must be JSON or AVRO, got %q", t.Name, ns.Name, topic.Name, sc.Type))
+					}
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p PulsarNamespacePolicies) validate() error {
+	var errs []error
+	if p.SchemaCompatibility != "" && !slices.Contains(PulsarSchemaCompatibilityStrategies, p.SchemaCompatibility) {
+		errs = append(errs, fmt.Errorf("unknown schema_compatibility %q", p.SchemaCompatibility))
+	}
+	if r := p.Retention; r != nil {
+		if r.Time > 0 && r.Time < time.Minute {
+			errs = append(errs, fmt.Errorf("retention time %s is under a minute, Pulsar's retention unit", r.Time))
+		}
+		if (r.Time == 0) != (r.SizeMB == 0) {
+			errs = append(errs, errors.New("retention time and size_mb must both be zero or both be non-zero"))
+		}
+	}
+	if p.MessageTTL != nil && *p.MessageTTL < 0 {
+		errs = append(errs, errors.New("message_ttl must not be negative"))
+	}
+	if q := p.BacklogQuota; q != nil {
+		if q.Policy != "" && !slices.Contains(PulsarBacklogQuotaPolicies, q.Policy) {
+			errs = append(errs, fmt.Errorf("unknown backlog_quota policy %q", q.Policy))
+		}
+		if q.LimitSize <= 0 && q.LimitTime <= 0 {
+			errs = append(errs, errors.New("backlog_quota needs limit_size or limit_time"))
+		}
+	}
+	if p.MaxProducersPerTopic != nil && *p.MaxProducersPerTopic < 0 {
+		errs = append(errs, errors.New("max_producers_per_topic must not be negative"))
+	}
+	if p.MaxConsumersPerTopic != nil && *p.MaxConsumersPerTopic < 0 {
+		errs = append(errs, errors.New("max_consumers_per_topic must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func validateSubscriptions(topicName string, topic PulsarTopic) []error {
+	var errs []error
+	if topic.NonPersistent && len(topic.Subscriptions) > 0 {
+		errs = append(errs, fmt.Errorf("topic %s: non-persistent topics cannot have pre-created subscriptions", topicName))
+	}
+	seen := map[string]bool{}
+	for i, sub := range topic.Subscriptions {
+		if sub.Name == "" {
+			errs = append(errs, fmt.Errorf("topic %s subscriptions[%d]: name is required", topicName, i))
+			continue
+		}
+		if seen[sub.Name] {
+			errs = append(errs, fmt.Errorf("topic %s: subscription %s declared twice", topicName, sub.Name))
+		}
+		seen[sub.Name] = true
+		if sub.Type != "" && !slices.Contains(PulsarSubscriptionTypes, sub.Type) {
+			errs = append(errs, fmt.Errorf("topic %s subscription %s: unknown type %q", topicName, sub.Name, sub.Type))
+		}
+		if sub.InitialPosition != "" && sub.InitialPosition != "earliest" && sub.InitialPosition != "latest" {
+			errs = append(errs, fmt.Errorf("topic %s subscription %s: initial_position must be earliest or latest, got %q", topicName, sub.Name, sub.InitialPosition))
+		}
+	}
+	return errs
+}
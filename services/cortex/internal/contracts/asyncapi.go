@@ -0,0 +1,217 @@
+// Package contracts reads the AsyncAPI documents published by A.R.C. services
+// so Cortex can derive the messaging resources those services expect.
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// paramPattern matches an AsyncAPI channel parameter such as {request_id}.
+var paramPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+// Channel is one AsyncAPI channel resolved to a concrete address and the
+// protocols of the servers it is bound to. CoreOnly is set by the
+// x-nats-core-only extension on the channel or on any of its servers and means
+// no JetStream stream is expected to capture the subject. Stream is set by
+// the x-nats-stream extension on the channel.
+type Channel struct {
+	ID        string
+	Address   string
+	Protocols []string
+	CoreOnly  bool
+	Stream    *NATSStream
+	Source    string
+}
+
+// NATSStream names the JetStream stream that must capture a channel's subject.
+// Channels naming the same stream share it. Retention is "limits", "interest"
+// or "workqueue"; empty Retention and zero MaxAge leave the choice to Cortex.
+type NATSStream struct {
+	Name      string
+	Retention string
+	MaxAge    time.Duration
+}
+
+// HasProtocol reports whether the channel is bound to a server using proto.
+func (c Channel) HasProtocol(proto string) bool {
+	for _, p := range c.Protocols {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
+
+// NATSSubject returns the channel address as a NATS subject, with every token
+// that contains a parameter replaced by the single-token wildcard "*".
+func (c Channel) NATSSubject() string {
+	tokens := strings.Split(c.Address, ".")
+	for i, tok := range tokens {
+		if paramPattern.MatchString(tok) {
+			tokens[i] = "*"
+		}
+	}
+	return strings.Join(tokens, ".")
+}
+
+type rawDoc struct {
+	AsyncAPI string                `yaml:"asyncapi"`
+	Servers  map[string]rawServer  `yaml:"servers"`
+	Channels map[string]rawChannel `yaml:"channels"`
+}
+
+type rawServer struct {
+	Protocol string `yaml:"protocol"`
+	CoreOnly bool   `yaml:"x-nats-core-only"`
+}
+
+type rawChannel struct {
+	Address  *string     `yaml:"address"`
+	Servers  []yaml.Node `yaml:"servers"`
+	CoreOnly bool        `yaml:"x-nats-core-only"`
+	Stream   *rawStream  `yaml:"x-nats-stream"`
+}
+
+type rawStream struct {
+	Name      string `yaml:"name"`
+	Retention string `yaml:"retention"`
+	MaxAge    string `yaml:"max_age"`
+}
+
+// LoadAsyncAPI parses the AsyncAPI 2.x or 3.x document at path and returns its
+// channels sorted by ID. In 2.x the channel key is the address; in 3.x the
+// address field is used and channels with a null address are skipped.
+func LoadAsyncAPI(path string) ([]Channel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading asyncapi document %s: %w", path, err)
+	}
+
+	var doc rawDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing asyncapi document %s: %w", path, err)
+	}
+	if doc.AsyncAPI == "" {
+		return nil, fmt.Errorf("%s is not an asyncapi document", path)
+	}
+	v3 := strings.HasPrefix(doc.AsyncAPI, "3.")
+
+	ids := make([]string, 0, len(doc.Channels))
+	for id := range doc.Channels {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	channels := make([]Channel, 0, len(ids))
+	for _, id := range ids {
+		raw := doc.Channels[id]
+
+		address := id
+		if v3 {
+			if raw.Address == nil {
+				continue
+			}
+			address = *raw.Address
+		}
+
+		names, err := serverNames(raw.Servers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: channel %s: %w", path, id, err)
+		}
+		if len(names) == 0 {
+			// A channel without explicit servers is available on all of them.
+			for name := range doc.Servers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+
+		ch := Channel{ID: id, Address: address, CoreOnly: raw.CoreOnly, Source: path}
+		if raw.Stream != nil {
+			if ch.Stream, err = raw.Stream.resolve(); err != nil {
+				return nil, fmt.Errorf("%s: channel %s: x-nats-stream: %w", path, id, err)
+			}
+		}
+		for _, name := range names {
+			srv, ok := doc.Servers[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: channel %s references unknown server %q", path, id, name)
+			}
+			ch.Protocols = append(ch.Protocols, srv.Protocol)
+			if srv.CoreOnly {
+				ch.CoreOnly = true
+			}
+		}
+		channels = append(channels, ch)
+	}
+
+	return channels, nil
+}
+
+func (r rawStream) resolve() (*NATSStream, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	switch r.Retention {
+	case "", "limits", "interest", "workqueue":
+	default:
+		return nil, fmt.Errorf("unknown retention %q", r.Retention)
+	}
+	s := &NATSStream{Name: r.Name, Retention: r.Retention}
+	if r.MaxAge != "" {
+		d, err := time.ParseDuration(r.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("max_age: %w", err)
+		}
+		s.MaxAge = d
+	}
+	return s, nil
+}
+
+// LoadAll loads every document in paths and concatenates their channels.
+func LoadAll(paths []string) ([]Channel, error) {
+	var all []Channel
+	for _, p := range paths {
+		chs, err := LoadAsyncAPI(p)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, chs...)
+	}
+	return all, nil
+}
+
+// serverNames resolves a channel's servers list. AsyncAPI 2.x lists server
+// names as plain strings; 3.x lists {$ref: '#/servers/<name>'} objects.
+func serverNames(nodes []yaml.Node) ([]string, error) {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			names = append(names, n.Value)
+		case yaml.MappingNode:
+			var ref struct {
+				Ref string `yaml:"$ref"`
+			}
+			if err := n.Decode(&ref); err != nil {
+				return nil, fmt.Errorf("decoding server reference: %w", err)
+			}
+			name, ok := strings.CutPrefix(ref.Ref, "#/servers/")
+			if !ok {
+				return nil, fmt.Errorf("unsupported server reference %q", ref.Ref)
+			}
+			names = append(names, name)
+		default:
+			return nil, fmt.Errorf("unexpected server entry at line %d", n.Line)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,107 @@
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAsyncAPI_V3(t *testing.T) {
+	t.Parallel()
+
+	channels, err := LoadAsyncAPI("testdata/v3.yaml")
+	require.NoError(t, err)
+
+	// "dynamic" has a null address and is skipped.
+	require.Len(t, channels, 4)
+
+	byID := make(map[string]Channel, len(channels))
+	for _, ch := range channels {
+		byID[ch.ID] = ch
+	}
+
+	cmd := byID["agentCommand"]
+	assert.True(t, cmd.HasProtocol("nats"))
+	assert.False(t, cmd.CoreOnly)
+	assert.Equal(t, "agent.*.cmd", cmd.NATSSubject())
+	assert.Nil(t, cmd.Stream)
+
+	assert.Equal(t, &NATSStream{Name: "REASONER_REQUESTS", Retention: "limits", MaxAge: 168 * time.Hour},
+		byID["requeue"].Stream)
+
+	stream := byID["stream"]
+	assert.True(t, stream.CoreOnly, "inherited from the realtime server")
+	assert.Equal(t, "arc.reasoner.stream.*", stream.NATSSubject())
+
+	assert.True(t, byID["events"].HasProtocol("pulsar"))
+	assert.False(t, byID["events"].HasProtocol("nats"))
+}
+
+func TestLoadAsyncAPI_V2UsesChannelKeyAsAddress(t *testing.T) {
+	t.Parallel()
+
+	channels, err := LoadAsyncAPI("testdata/v2.yaml")
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+
+	assert.Equal(t, "arc.voice.session.started", channels[0].Address)
+	assert.Equal(t, []string{"pulsar"}, channels[0].Protocols)
+}
+
+func TestLoadAsyncAPI_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	notAsync := filepath.Join(dir, "openapi.yaml")
+	require.NoError(t, os.WriteFile(notAsync, []byte("openapi: 3.1.0\n"), 0o600))
+	badRef := filepath.Join(dir, "bad-ref.yaml")
+	require.NoError(t, os.WriteFile(badRef, []byte(`asyncapi: 3.0.0
+channels:
+  c:
+    address: a.b
+    servers:
+      - $ref: '#/servers/missing'
+`), 0o600))
+	badStream := filepath.Join(dir, "bad-stream.yaml")
+	require.NoError(t, os.WriteFile(badStream, []byte(`asyncapi: 3.0.0
+channels:
+  c:
+    address: a.b
+    x-nats-stream:
+      name: S
+      retention: forever
+`), 0o600))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantErrSub string
+	}{
+		{name: "missing file", path: filepath.Join(dir, "nope.yaml"), wantErrSub: "reading asyncapi document"},
+		{name: "not asyncapi", path: notAsync, wantErrSub: "not an asyncapi document"},
+		{name: "unknown server", path: badRef, wantErrSub: `unknown server "missing"`},
+		{name: "bad stream", path: badStream, wantErrSub: `channel c: x-nats-stream: unknown retention "forever"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadAsyncAPI(tc.path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErrSub)
+		})
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	t.Parallel()
+
+	channels, err := LoadAll([]string{"testdata/v3.yaml", "testdata/v2.yaml"})
+	require.NoError(t, err)
+	assert.Len(t, channels, 5)
+}
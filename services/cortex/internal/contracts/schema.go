@@ -0,0 +1,418 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Schema types understood by the Pulsar schema registry.
+const (
+	SchemaJSON = "JSON"
+	SchemaAvro = "AVRO"
+)
+
+// avroName matches a valid Avro record or field name.
+var avroName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Schema is a message schema ready for upload to the Pulsar schema registry.
+// Definition is always an Avro schema in JSON form: Pulsar describes JSON
+// topics with Avro record definitions too, so JSON Schema payloads are
+// converted.
+type Schema struct {
+	Type       string
+	Definition string
+	Source     string
+}
+
+// LoadSchema reads a message schema from path. When message is set, path is an
+// AsyncAPI document and the payload of components.messages[message] is used;
+// otherwise the whole file is the schema. Avro is detected from an .avsc
+// extension, an Avro schemaFormat or a top-level "type: record"; anything else
+// is treated as JSON Schema. typ overrides the Pulsar schema type, which
+// otherwise follows the source format.
+func LoadSchema(path, message, typ string) (Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Schema{}, fmt.Errorf("reading schema %s: %w", path, err)
+	}
+
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Schema{}, fmt.Errorf("parsing schema %s: %w", path, err)
+	}
+
+	source := path
+	payload := doc
+	format := ""
+	name := recordName(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if message != "" {
+		source = path + "#/components/messages/" + message
+		payload, format, err = messagePayload(doc, message)
+		if err != nil {
+			return Schema{}, fmt.Errorf("%s: %w", source, err)
+		}
+		name = recordName(message)
+	} else if strings.EqualFold(filepath.Ext(path), ".avsc") {
+		format = "avro"
+	}
+
+	payload, err = resolveRefs(payload, doc, nil)
+	if err != nil {
+		return Schema{}, fmt.Errorf("%s: %w", source, err)
+	}
+
+	avro := strings.Contains(strings.ToLower(format), "avro")
+	if m, ok := payload.(map[string]any); ok && m["type"] == "record" {
+		avro = true
+	}
+
+	definition := payload
+	if !avro {
+		if m, ok := payload.(map[string]any); ok {
+			if title, ok := m["title"].(string); ok && message == "" {
+				name = recordName(title)
+			}
+		}
+		conv := &avroConverter{names: map[string]int{}}
+		definition, err = conv.convert(name, payload, "")
+		if err != nil {
+			return Schema{}, fmt.Errorf("%s: converting to Avro: %w", source, err)
+		}
+	}
+
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return Schema{}, fmt.Errorf("%s: encoding schema: %w", source, err)
+	}
+
+	if typ == "" {
+		typ = SchemaJSON
+		if avro {
+			typ = SchemaAvro
+		}
+	}
+	return Schema{Type: typ, Definition: string(encoded), Source: source}, nil
+}
+
+// messagePayload returns the payload and schema format of a components
+// message, following a $ref on the message itself. AsyncAPI 3 multi-format
+// payloads ({schemaFormat, schema}) are unwrapped.
+func messagePayload(doc any, message string) (any, string, error) {
+	msg, err := pointer(doc, "/components/messages/"+escapePointer(message))
+	if err != nil {
+		return nil, "", err
+	}
+	msg, err = resolveRefs(msg, doc, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	m, ok := msg.(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("message %s is not an object", message)
+	}
+
+	payload, ok := m["payload"]
+	if !ok {
+		return nil, "", fmt.Errorf("message %s has no payload", message)
+	}
+	format, _ := m["schemaFormat"].(string)
+	if p, ok := payload.(map[string]any); ok {
+		if f, ok := p["schemaFormat"].(string); ok {
+			if schema, ok := p["schema"]; ok {
+				return schema, f, nil
+			}
+		}
+	}
+	return payload, format, nil
+}
+
+// resolveRefs returns node with every local {"$ref": "#/..."} replaced by its
+// target. Cycles and references to other documents are errors.
+func resolveRefs(node, doc any, stack []string) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			target, ok := strings.CutPrefix(ref, "#")
+			if !ok {
+				return nil, fmt.Errorf("unsupported external $ref %q", ref)
+			}
+			for _, s := range stack {
+				if s == ref {
+					return nil, fmt.Errorf("circular $ref %q", ref)
+				}
+			}
+			resolved, err := pointer(doc, target)
+			if err != nil {
+				return nil, err
+			}
+			return resolveRefs(resolved, doc, append(stack, ref))
+		}
+		out := make(map[string]any, len(n))
+		for k, v := range n {
+			r, err := resolveRefs(v, doc, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(n))
+		for i, v := range n {
+			r, err := resolveRefs(v, doc, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return node, nil
+	}
+}
+
+// pointer resolves a JSON pointer such as /components/schemas/Foo.
+func pointer(doc any, ptr string) (any, error) {
+	cur := doc
+	for _, tok := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		if tok == "" {
+			continue
+		}
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("$ref target #%s not found", ptr)
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("$ref target #%s not found", ptr)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("$ref target #%s not found", ptr)
+		}
+	}
+	return cur, nil
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// avroConverter turns JSON Schema into an Avro schema. Nested objects become
+// named records, so names tracks record names already used.
+type avroConverter struct {
+	names map[string]int
+}
+
+// convert maps one JSON Schema node. path is used in error messages.
+func (c *avroConverter) convert(name string, node any, path string) (any, error) {
+	s, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema is not an object", displayPath(path))
+	}
+
+	var alternatives []any
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if list, ok := s[key].([]any); ok {
+			for i, alt := range list {
+				t, err := c.convert(name, alt, fmt.Sprintf("%s/%s/%d", path, key, i))
+				if err != nil {
+					return nil, err
+				}
+				alternatives = append(alternatives, t)
+			}
+		}
+	}
+	if alternatives != nil {
+		return union(alternatives...), nil
+	}
+
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				types = append(types, str)
+			}
+		}
+	case nil:
+		if _, ok := s["properties"]; ok {
+			types = []string{"object"}
+		}
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("%s: schema has no type", displayPath(path))
+	}
+
+	var branches []any
+	for _, t := range types {
+		b, err := c.convertType(name, t, s, path)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, b)
+	}
+	if nullable, _ := s["nullable"].(bool); nullable {
+		branches = append(branches, "null")
+	}
+	if len(branches) == 1 {
+		return branches[0], nil
+	}
+	return union(branches...), nil
+}
+
+func (c *avroConverter) convertType(name, typ string, s map[string]any, path string) (any, error) {
+	switch typ {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "long", nil
+	case "number":
+		return "double", nil
+	case "boolean":
+		return "boolean", nil
+	case "null":
+		return "null", nil
+	case "array":
+		items, ok := s["items"]
+		if !ok {
+			return nil, fmt.Errorf("%s: array has no items", displayPath(path))
+		}
+		t, err := c.convert(name+"Item", items, path+"/items")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": t}, nil
+	case "object":
+		props, _ := s["properties"].(map[string]any)
+		if len(props) == 0 {
+			values := any("string")
+			if ap, ok := s["additionalProperties"].(map[string]any); ok {
+				v, err := c.convert(name+"Value", ap, path+"/additionalProperties")
+				if err != nil {
+					return nil, err
+				}
+				values = v
+			}
+			return map[string]any{"type": "map", "values": values}, nil
+		}
+		return c.record(name, s, props, path)
+	default:
+		return nil, fmt.Errorf("%s: unsupported type %q", displayPath(path), typ)
+	}
+}
+
+// record converts an object with properties. Fields are sorted by name so the
+// definition is stable; fields that are not required become nullable with a
+// null default.
+func (c *avroConverter) record(name string, s, props map[string]any, path string) (any, error) {
+	if !avroName.MatchString(name) {
+		return nil, fmt.Errorf("%s: %q is not a valid Avro record name", displayPath(path), name)
+	}
+	c.names[name]++
+	if n := c.names[name]; n > 1 {
+		name += strconv.Itoa(n)
+	}
+
+	required := map[string]bool{}
+	if list, ok := s["required"].([]any); ok {
+		for _, r := range list {
+			if str, ok := r.(string); ok {
+				required[str] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]any, 0, len(keys))
+	for _, k := range keys {
+		if !avroName.MatchString(k) {
+			return nil, fmt.Errorf("%s: property %q is not a valid Avro field name", displayPath(path), k)
+		}
+		t, err := c.convert(recordName(k), props[k], path+"/properties/"+k)
+		if err != nil {
+			return nil, err
+		}
+		field := map[string]any{"name": k, "type": t}
+		if !required[k] {
+			field["type"] = union("null", t)
+			field["default"] = nil
+		}
+		fields = append(fields, field)
+	}
+
+	return map[string]any{"type": "record", "name": name, "fields": fields}, nil
+}
+
+// union flattens and de-duplicates branches into an Avro union. "null" is
+// moved first so a null default is valid.
+func union(branches ...any) any {
+	var out []any
+	seen := map[string]bool{}
+	add := func(b any) {
+		key, _ := json.Marshal(b)
+		if !seen[string(key)] {
+			seen[string(key)] = true
+			out = append(out, b)
+		}
+	}
+	for _, b := range branches {
+		if list, ok := b.([]any); ok {
+			for _, x := range list {
+				add(x)
+			}
+			continue
+		}
+		add(b)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i] == "null" && out[j] != "null" })
+	if len(out) == 1 {
+		return out[0]
+	}
+	return out
+}
+
+// recordName turns a property or file name into a PascalCase Avro name.
+func recordName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '-' || r == '.' || r == ' ':
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "#"
+	}
+	return "#" + path
+}
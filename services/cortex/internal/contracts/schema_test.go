@@ -0,0 +1,130 @@
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSchema_JSONSchemaMessage(t *testing.T) {
+	t.Parallel()
+
+	s, err := LoadSchema("testdata/messages.yaml", "AgentLifecycle", "")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaJSON, s.Type)
+	assert.Equal(t, "testdata/messages.yaml#/components/messages/AgentLifecycle", s.Source)
+
+	want := `{
+	  "type": "record",
+	  "name": "AgentLifecycle",
+	  "fields": [
+	    {"name": "agent_id", "type": "string"},
+	    {"name": "error", "type": ["null", "string"], "default": null},
+	    {"name": "labels", "type": ["null", {"type": "map", "values": "string"}], "default": null},
+	    {"name": "meta", "type": ["null", {"type": "record", "name": "Meta", "fields": [
+	      {"name": "host", "type": "string"},
+	      {"name": "load", "type": ["null", "double", "string"], "default": null}
+	    ]}], "default": null},
+	    {"name": "restarts", "type": ["null", "long"], "default": null},
+	    {"name": "state", "type": "string"},
+	    {"name": "tags", "type": ["null", {"type": "array", "items": "string"}], "default": null}
+	  ]
+	}`
+	assert.JSONEq(t, want, s.Definition)
+}
+
+func TestLoadSchema_MessageRef(t *testing.T) {
+	t.Parallel()
+
+	alias, err := LoadSchema("testdata/messages.yaml", "Alias", "")
+	require.NoError(t, err)
+	orig, err := LoadSchema("testdata/messages.yaml", "AgentLifecycle", "")
+	require.NoError(t, err)
+
+	var a, o map[string]any
+	require.NoError(t, json.Unmarshal([]byte(alias.Definition), &a))
+	require.NoError(t, json.Unmarshal([]byte(orig.Definition), &o))
+	assert.Equal(t, "Alias", a["name"])
+	assert.Equal(t, o["fields"], a["fields"])
+}
+
+func TestLoadSchema_Avro(t *testing.T) {
+	t.Parallel()
+
+	heartbeat := `{"type": "record", "name": "Heartbeat", "fields": [{"name": "ts", "type": "long"}]}`
+
+	tests := []struct {
+		name, path, message, typ string
+		wantType, wantDef        string
+	}{
+		{
+			name: "schemaFormat on message", path: "testdata/messages.yaml", message: "AuditRecord",
+			wantType: SchemaAvro,
+			wantDef:  `{"type": "record", "name": "AuditRecord", "fields": [{"name": "actor", "type": "string"}]}`,
+		},
+		{
+			name: "asyncapi 3 multi-format payload", path: "testdata/v3-multiformat.yaml", message: "Heartbeat",
+			wantType: SchemaAvro, wantDef: heartbeat,
+		},
+		{
+			name: "avsc file", path: "testdata/heartbeat.avsc",
+			wantType: SchemaAvro, wantDef: heartbeat,
+		},
+		{
+			name: "avro definition registered as JSON", path: "testdata/heartbeat.avsc", typ: SchemaJSON,
+			wantType: SchemaJSON, wantDef: heartbeat,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := LoadSchema(tc.path, tc.message, tc.typ)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantType, s.Type)
+			assert.JSONEq(t, tc.wantDef, s.Definition)
+		})
+	}
+}
+
+func TestLoadSchema_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, message, wantErr string
+	}{
+		{name: "unknown message", message: "Nope", wantErr: "#/components/messages/Nope not found"},
+		{name: "circular ref", message: "Loop", wantErr: "circular $ref"},
+		{name: "untyped payload", message: "Untyped", wantErr: "schema has no type"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadSchema("testdata/messages.yaml", tc.message, "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+// TestLoadSchema_ReasonerContract converts the Pulsar event payloads the
+// reasoner publishes, so contract edits that cannot be registered fail here.
+func TestLoadSchema_ReasonerContract(t *testing.T) {
+	t.Parallel()
+
+	for _, msg := range []string{"RequestReceivedEvent", "InferenceCompletedEvent"} {
+		s, err := LoadSchema("../../../reasoner/contracts/asyncapi.yaml", msg, "")
+		require.NoError(t, err, msg)
+		assert.Equal(t, SchemaJSON, s.Type)
+
+		var def map[string]any
+		require.NoError(t, json.Unmarshal([]byte(s.Definition), &def))
+		assert.Equal(t, msg, def["name"])
+		assert.NotEmpty(t, def["fields"])
+	}
+}
@@ -0,0 +1,109 @@
+package dbschema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier runs a query; *pgxpool.Pool and *pgx.Conn satisfy it.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Catalog is the part of the system catalog the expectations are compared
+// with. Tables and indexes are keyed by "schema.table".
+type Catalog struct {
+	Schemas map[string]bool
+	Columns map[string][]Column
+	Indexes map[string][]Index
+}
+
+// Column is a table column as reported by format_type.
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+}
+
+// Index is an index with its access method, key columns and operator
+// classes in key order. Expression keys have an empty column name.
+type Index struct {
+	Name      string
+	Method    string
+	Columns   []string
+	OpClasses []string
+}
+
+// ReadCatalog reads the schemas, table columns and indexes of the given
+// schemas.
+func ReadCatalog(ctx context.Context, q Querier, schemas []string) (*Catalog, error) {
+	cat := &Catalog{
+		Schemas: map[string]bool{},
+		Columns: map[string][]Column{},
+		Indexes: map[string][]Index{},
+	}
+
+	rows, err := q.Query(ctx, "SELECT nspname FROM pg_namespace WHERE nspname = ANY($1)", schemas)
+	if err != nil {
+		return nil, fmt.Errorf("reading schemas: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("reading schemas: %w", err)
+	}
+	for _, name := range names {
+		cat.Schemas[name] = true
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ANY($1) AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY n.nspname, c.relname, a.attnum`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+	var schema, table string
+	var col Column
+	_, err = pgx.ForEachRow(rows, []any{&schema, &table, &col.Name, &col.Type, &col.NotNull}, func() error {
+		cat.Columns[schema+"."+table] = append(cat.Columns[schema+"."+table], col)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT n.nspname, t.relname, i.relname, am.amname,
+		       ARRAY(SELECT COALESCE(a.attname, '')
+		             FROM unnest(x.indkey::int2[]) WITH ORDINALITY k(attnum, ord)
+		             LEFT JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord)::text[],
+		       ARRAY(SELECT o.opcname
+		             FROM unnest(x.indclass::oid[]) WITH ORDINALITY c(oid, ord)
+		             JOIN pg_opclass o ON o.oid = c.oid
+		             ORDER BY c.ord)::text[]
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_am am ON am.oid = i.relam
+		WHERE n.nspname = ANY($1)
+		ORDER BY n.nspname, t.relname, i.relname`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("reading indexes: %w", err)
+	}
+	var idx Index
+	_, err = pgx.ForEachRow(rows, []any{&schema, &table, &idx.Name, &idx.Method, &idx.Columns, &idx.OpClasses}, func() error {
+		cat.Indexes[schema+"."+table] = append(cat.Indexes[schema+"."+table], idx)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading indexes: %w", err)
+	}
+	return cat, nil
+}
@@ -0,0 +1,211 @@
+// Package dbschema checks the persisted Postgres schema against what each
+// service expects: schemas, tables, columns with their types, and indexes
+// with their access method, operator classes and vector dimensions.
+//
+// Expectations are YAML or JSON files, one service per file. The reasoner
+// expectation is embedded in this package; services add their own through
+// bootstrap.postgres.expectations.files.
+package dbschema
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"arc-framework/cortex/internal/config"
+)
+
+//go:embed expectations/*.yaml
+var embedded embed.FS
+
+// Expectation is the schema one service requires.
+type Expectation struct {
+	Service string       `yaml:"service"`
+	Schemas []SchemaSpec `yaml:"schemas"`
+}
+
+// SchemaSpec requires a schema and the listed tables in it. Tables not
+// listed are ignored.
+type SchemaSpec struct {
+	Name   string      `yaml:"name"`
+	Tables []TableSpec `yaml:"tables"`
+}
+
+// TableSpec requires a table with at least the listed columns and indexes.
+type TableSpec struct {
+	Name    string       `yaml:"name"`
+	Columns []ColumnSpec `yaml:"columns"`
+	Indexes []IndexSpec  `yaml:"indexes"`
+}
+
+// ColumnSpec requires a column. Type is compared with format_type output,
+// such as "text", "timestamp with time zone" or "vector(384)"; common
+// aliases like timestamptz and int8 are accepted. An empty Type accepts any
+// type, and NotNull false accepts nullable and NOT NULL columns alike.
+type ColumnSpec struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`
+	NotNull bool   `yaml:"not_null"`
+}
+
+// IndexSpec requires an index over Columns, in order. Without a Name any
+// index on the table that matches is accepted. Method is the access method
+// (btree, gin, hnsw, ivfflat); OpClasses lists the operator class of each
+// column. Dimensions requires every indexed vector column to have that many
+// dimensions. Empty fields are not checked.
+type IndexSpec struct {
+	Name       string   `yaml:"name"`
+	Method     string   `yaml:"method"`
+	Columns    []string `yaml:"columns"`
+	OpClasses  []string `yaml:"opclasses"`
+	Dimensions int      `yaml:"dimensions"`
+}
+
+// Embedded returns the expectations compiled into Cortex, ordered by file
+// name.
+func Embedded() ([]Expectation, error) {
+	entries, err := fs.ReadDir(embedded, "expectations")
+	if err != nil {
+		return nil, err
+	}
+	var out []Expectation
+	for _, e := range entries {
+		data, err := fs.ReadFile(embedded, "expectations/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		exp, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("embedded expectation %s: %w", e.Name(), err)
+		}
+		out = append(out, exp)
+	}
+	return out, nil
+}
+
+// Load returns the expectations selected by cfg: the embedded ones when
+// enabled, followed by each configured file. A service declared twice is an
+// error.
+func Load(cfg config.PostgresExpectationsConfig) ([]Expectation, error) {
+	var out []Expectation
+	if cfg.Embedded {
+		exps, err := Embedded()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, exps...)
+	}
+	for _, path := range cfg.Files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading schema expectation: %w", err)
+		}
+		exp, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("schema expectation %s: %w", path, err)
+		}
+		out = append(out, exp)
+	}
+
+	seen := map[string]bool{}
+	for _, exp := range out {
+		if seen[exp.Service] {
+			return nil, fmt.Errorf("schema expectation for service %s declared twice", exp.Service)
+		}
+		seen[exp.Service] = true
+	}
+	return out, nil
+}
+
+// Parse decodes one YAML or JSON expectation and checks it is complete.
+// Unknown fields are rejected so a misspelled key is not silently ignored.
+func Parse(data []byte) (Expectation, error) {
+	var exp Expectation
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&exp); err != nil && !errors.Is(err, io.EOF) {
+		return Expectation{}, err
+	}
+	return exp, exp.validate()
+}
+
+func (e Expectation) validate() error {
+	var errs []error
+	if e.Service == "" {
+		errs = append(errs, errors.New("service is required"))
+	}
+	for i, s := range e.Schemas {
+		if s.Name == "" {
+			errs = append(errs, fmt.Errorf("schemas[%d]: name is required", i))
+		}
+		for j, t := range s.Tables {
+			if t.Name == "" {
+				errs = append(errs, fmt.Errorf("schema %s tables[%d]: name is required", s.Name, j))
+				continue
+			}
+			for k, c := range t.Columns {
+				if c.Name == "" {
+					errs = append(errs, fmt.Errorf("table %s.%s columns[%d]: name is required", s.Name, t.Name, k))
+				}
+			}
+			for k, idx := range t.Indexes {
+				if idx.Name == "" && len(idx.Columns) == 0 {
+					errs = append(errs, fmt.Errorf("table %s.%s indexes[%d]: name or columns is required", s.Name, t.Name, k))
+				}
+				if len(idx.OpClasses) > 0 && len(idx.OpClasses) != len(idx.Columns) {
+					errs = append(errs, fmt.Errorf("table %s.%s indexes[%d]: opclasses must list one class per column", s.Name, t.Name, k))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Schemas returns the distinct schema names referenced by exps.
+func Schemas(exps []Expectation) []string {
+	var out []string
+	for _, exp := range exps {
+		for _, s := range exp.Schemas {
+			if !slices.Contains(out, s.Name) {
+				out = append(out, s.Name)
+			}
+		}
+	}
+	return out
+}
+
+// typeAliases maps common shorthands to the names format_type reports.
+var typeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"int2":        "smallint",
+	"bool":        "boolean",
+	"float8":      "double precision",
+	"float4":      "real",
+	"varchar":     "character varying",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+}
+
+// normalizeType lowercases t, removes spaces inside a type modifier and
+// resolves aliases.
+func normalizeType(t string) string {
+	base, mod, hasMod := strings.Cut(strings.ToLower(strings.TrimSpace(t)), "(")
+	base = strings.TrimSpace(base)
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	if hasMod {
+		return base + "(" + strings.ReplaceAll(mod, " ", "")
+	}
+	return base
+}
@@ -0,0 +1,78 @@
+package dbschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+func TestEmbedded_ReasonerRAGSchema(t *testing.T) {
+	t.Parallel()
+
+	exps, err := Embedded()
+	require.NoError(t, err)
+	require.Len(t, exps, 1)
+	assert.Equal(t, "reasoner", exps[0].Service)
+	assert.Equal(t, []string{"reasoner"}, Schemas(exps))
+
+	var chunks TableSpec
+	for _, tbl := range exps[0].Schemas[0].Tables {
+		if tbl.Name == "knowledge_chunks" {
+			chunks = tbl
+		}
+	}
+	assert.Contains(t, chunks.Columns, ColumnSpec{Name: "embedding", Type: "vector(384)"})
+	assert.Contains(t, chunks.Indexes, IndexSpec{
+		Name: "idx_knowledge_chunks_embedding", Method: "hnsw", Columns: []string{"embedding"},
+		OpClasses: []string{"vector_cosine_ops"}, Dimensions: 384,
+	})
+}
+
+func TestParse_RejectsIncompleteExpectations(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte("service: x\nschemas:\n  - name: x\n    tables:\n      - name: t\n        colums: []\n"))
+	assert.ErrorContains(t, err, "field colums not found")
+
+	_, err = Parse([]byte(`
+schemas:
+  - tables:
+      - name: t
+        indexes:
+          - method: hnsw
+          - columns: [a, b]
+            opclasses: [x]
+`))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "service is required")
+	assert.ErrorContains(t, err, "schemas[0]: name is required")
+	assert.ErrorContains(t, err, "indexes[0]: name or columns is required")
+	assert.ErrorContains(t, err, "indexes[1]: opclasses must list one class per column")
+}
+
+func TestLoad_FilesAndDuplicates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	audit := filepath.Join(dir, "audit.json")
+	require.NoError(t, os.WriteFile(audit, []byte(`{"service": "audit", "schemas": [{"name": "audit", "tables": [{"name": "events"}]}]}`), 0o600))
+
+	exps, err := Load(config.PostgresExpectationsConfig{Embedded: true, Files: []string{audit}})
+	require.NoError(t, err)
+	require.Len(t, exps, 2)
+	assert.Equal(t, "audit", exps[1].Service)
+	assert.Equal(t, []string{"reasoner", "audit"}, Schemas(exps))
+
+	dup := filepath.Join(dir, "reasoner.yaml")
+	require.NoError(t, os.WriteFile(dup, []byte("service: reasoner\n"), 0o600))
+	_, err = Load(config.PostgresExpectationsConfig{Embedded: true, Files: []string{dup}})
+	assert.ErrorContains(t, err, "service reasoner declared twice")
+
+	_, err = Load(config.PostgresExpectationsConfig{Files: []string{filepath.Join(dir, "missing.yaml")}})
+	assert.ErrorContains(t, err, "reading schema expectation")
+}
@@ -0,0 +1,137 @@
+package dbschema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Result reports whether the catalog meets one service's expectation.
+type Result struct {
+	Service  string   `json:"service"`
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Verify compares every expectation with cat and returns one Result per
+// service, in the order given. Each problem names the object and what was
+// found, e.g. "column reasoner.knowledge_chunks.embedding is vector(768),
+// want vector(384)".
+func Verify(exps []Expectation, cat *Catalog) []Result {
+	results := make([]Result, 0, len(exps))
+	for _, exp := range exps {
+		var problems []string
+		for _, s := range exp.Schemas {
+			if !cat.Schemas[s.Name] {
+				problems = append(problems, fmt.Sprintf("schema %s is missing", s.Name))
+				continue
+			}
+			for _, t := range s.Tables {
+				problems = append(problems, verifyTable(s.Name+"."+t.Name, t, cat)...)
+			}
+		}
+		results = append(results, Result{Service: exp.Service, OK: len(problems) == 0, Problems: problems})
+	}
+	return results
+}
+
+func verifyTable(key string, t TableSpec, cat *Catalog) []string {
+	columns, ok := cat.Columns[key]
+	if !ok {
+		return []string{fmt.Sprintf("table %s is missing", key)}
+	}
+
+	var problems []string
+	for _, want := range t.Columns {
+		i := slices.IndexFunc(columns, func(c Column) bool { return c.Name == want.Name })
+		if i < 0 {
+			problems = append(problems, fmt.Sprintf("column %s.%s is missing", key, want.Name))
+			continue
+		}
+		got := columns[i]
+		if want.Type != "" && normalizeType(got.Type) != normalizeType(want.Type) {
+			problems = append(problems, fmt.Sprintf("column %s.%s is %s, want %s", key, want.Name, got.Type, want.Type))
+		}
+		if want.NotNull && !got.NotNull {
+			problems = append(problems, fmt.Sprintf("column %s.%s is nullable, want NOT NULL", key, want.Name))
+		}
+	}
+
+	for _, want := range t.Indexes {
+		problems = append(problems, verifyIndex(key, want, columns, cat.Indexes[key])...)
+	}
+	return problems
+}
+
+func verifyIndex(key string, want IndexSpec, columns []Column, indexes []Index) []string {
+	if want.Name == "" {
+		for _, idx := range indexes {
+			if len(indexMismatches(want, idx, columns)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("no %s matches %s", describeIndex(want), key)}
+	}
+
+	i := slices.IndexFunc(indexes, func(idx Index) bool { return idx.Name == want.Name })
+	if i < 0 {
+		return []string{fmt.Sprintf("index %s on %s is missing", want.Name, key)}
+	}
+	mismatches := indexMismatches(want, indexes[i], columns)
+	for j, m := range mismatches {
+		mismatches[j] = fmt.Sprintf("index %s on %s %s", want.Name, key, m)
+	}
+	return mismatches
+}
+
+// indexMismatches lists how idx differs from want.
+func indexMismatches(want IndexSpec, idx Index, columns []Column) []string {
+	var out []string
+	if want.Method != "" && !strings.EqualFold(idx.Method, want.Method) {
+		out = append(out, fmt.Sprintf("uses %s, want %s", idx.Method, want.Method))
+	}
+	if len(want.Columns) > 0 && !slices.Equal(idx.Columns, want.Columns) {
+		out = append(out, fmt.Sprintf("covers (%s), want (%s)", strings.Join(idx.Columns, ", "), strings.Join(want.Columns, ", ")))
+	}
+	if len(want.OpClasses) > 0 && !slices.Equal(idx.OpClasses, want.OpClasses) {
+		out = append(out, fmt.Sprintf("has operator classes (%s), want (%s)", strings.Join(idx.OpClasses, ", "), strings.Join(want.OpClasses, ", ")))
+	}
+	if want.Dimensions > 0 {
+		for _, name := range idx.Columns {
+			i := slices.IndexFunc(columns, func(c Column) bool { return c.Name == name })
+			if i < 0 {
+				continue
+			}
+			if dims, ok := vectorDimensions(columns[i].Type); ok && dims != want.Dimensions {
+				out = append(out, fmt.Sprintf("indexes %d dimensions on %s, want %d", dims, name, want.Dimensions))
+			}
+		}
+	}
+	return out
+}
+
+func describeIndex(want IndexSpec) string {
+	desc := "index"
+	if want.Method != "" {
+		desc = want.Method + " index"
+	}
+	if len(want.Columns) > 0 {
+		desc += " on (" + strings.Join(want.Columns, ", ") + ")"
+	}
+	return desc
+}
+
+var vectorTypeRe = regexp.MustCompile(`^(?:vector|halfvec|sparsevec)\((\d+)\)$`)
+
+// vectorDimensions returns n for a pgvector type such as vector(n). ok is
+// false for other types and for vector columns declared without dimensions.
+func vectorDimensions(typ string) (int, bool) {
+	m := vectorTypeRe.FindStringSubmatch(normalizeType(typ))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
@@ -0,0 +1,124 @@
+package dbschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reasonerCatalog mirrors what migration 004 creates, limited to the columns
+// and indexes the embedded expectation checks.
+func reasonerCatalog() *Catalog {
+	return &Catalog{
+		Schemas: map[string]bool{"reasoner": true},
+		Columns: map[string][]Column{
+			"reasoner.vector_stores": {
+				{Name: "id", Type: "text", NotNull: true}, {Name: "name", Type: "text", NotNull: true},
+				{Name: "file_count", Type: "integer", NotNull: true},
+			},
+			"reasoner.knowledge_files": {
+				{Name: "id", Type: "text", NotNull: true}, {Name: "filename", Type: "text", NotNull: true},
+				{Name: "bytes", Type: "bigint", NotNull: true}, {Name: "minio_key", Type: "text", NotNull: true},
+				{Name: "status", Type: "text", NotNull: true},
+			},
+			"reasoner.vector_store_files": {
+				{Name: "vector_store_id", Type: "text", NotNull: true}, {Name: "file_id", Type: "text", NotNull: true},
+				{Name: "status", Type: "text", NotNull: true}, {Name: "chunk_count", Type: "integer"},
+			},
+			"reasoner.knowledge_chunks": {
+				{Name: "id", Type: "uuid", NotNull: true}, {Name: "vector_store_id", Type: "text", NotNull: true},
+				{Name: "file_id", Type: "text", NotNull: true}, {Name: "chunk_index", Type: "integer", NotNull: true},
+				{Name: "content", Type: "text", NotNull: true}, {Name: "embedding", Type: "vector(384)"},
+				{Name: "fts_vector", Type: "tsvector"},
+			},
+		},
+		Indexes: map[string][]Index{
+			"reasoner.knowledge_chunks": {
+				{Name: "idx_knowledge_chunks_embedding", Method: "hnsw", Columns: []string{"embedding"}, OpClasses: []string{"vector_cosine_ops"}},
+				{Name: "idx_knowledge_chunks_fts", Method: "gin", Columns: []string{"fts_vector"}, OpClasses: []string{"tsvector_ops"}},
+				{Name: "idx_knowledge_chunks_vs_id", Method: "btree", Columns: []string{"vector_store_id"}, OpClasses: []string{"text_ops"}},
+				{Name: "knowledge_chunks_pkey", Method: "btree", Columns: []string{"id"}, OpClasses: []string{"uuid_ops"}},
+			},
+		},
+	}
+}
+
+func TestVerify_ReasonerSchemaMatches(t *testing.T) {
+	t.Parallel()
+
+	exps, err := Embedded()
+	require.NoError(t, err)
+	assert.Equal(t, []Result{{Service: "reasoner", OK: true}}, Verify(exps, reasonerCatalog()))
+}
+
+func TestVerify_ReportsEveryMismatch(t *testing.T) {
+	t.Parallel()
+
+	exps, err := Embedded()
+	require.NoError(t, err)
+
+	cat := reasonerCatalog()
+	delete(cat.Columns, "reasoner.vector_store_files")
+	chunks := cat.Columns["reasoner.knowledge_chunks"]
+	chunks[5].Type = "vector(768)"
+	chunks[4].NotNull = false
+	cat.Columns["reasoner.knowledge_chunks"] = chunks[:6] // drop fts_vector
+	cat.Indexes["reasoner.knowledge_chunks"] = []Index{
+		{Name: "idx_knowledge_chunks_embedding", Method: "ivfflat", Columns: []string{"embedding"}, OpClasses: []string{"vector_l2_ops"}},
+		{Name: "idx_knowledge_chunks_vs_id", Method: "btree", Columns: []string{"vector_store_id"}, OpClasses: []string{"text_ops"}},
+	}
+
+	results := Verify(exps, cat)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].OK)
+	assert.Equal(t, []string{
+		"table reasoner.vector_store_files is missing",
+		"column reasoner.knowledge_chunks.content is nullable, want NOT NULL",
+		"column reasoner.knowledge_chunks.embedding is vector(768), want vector(384)",
+		"column reasoner.knowledge_chunks.fts_vector is missing",
+		"index idx_knowledge_chunks_embedding on reasoner.knowledge_chunks uses ivfflat, want hnsw",
+		"index idx_knowledge_chunks_embedding on reasoner.knowledge_chunks has operator classes (vector_l2_ops), want (vector_cosine_ops)",
+		"index idx_knowledge_chunks_embedding on reasoner.knowledge_chunks indexes 768 dimensions on embedding, want 384",
+		"index idx_knowledge_chunks_fts on reasoner.knowledge_chunks is missing",
+	}, results[0].Problems)
+}
+
+func TestVerify_MissingSchemaAndUnnamedIndex(t *testing.T) {
+	t.Parallel()
+
+	exps := []Expectation{
+		{Service: "audit", Schemas: []SchemaSpec{{Name: "audit"}}},
+		{Service: "reasoner", Schemas: []SchemaSpec{{Name: "reasoner", Tables: []TableSpec{{
+			Name: "knowledge_chunks",
+			Indexes: []IndexSpec{
+				{Method: "hnsw", Columns: []string{"embedding"}, Dimensions: 384},
+				{Method: "gin", Columns: []string{"content"}},
+			},
+		}}}}},
+	}
+	results := Verify(exps, reasonerCatalog())
+	assert.Equal(t, []Result{
+		{Service: "audit", Problems: []string{"schema audit is missing"}},
+		{Service: "reasoner", Problems: []string{"no gin index on (content) matches reasoner.knowledge_chunks"}},
+	}, results)
+}
+
+func TestNormalizeType(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"timestamptz":              "timestamp with time zone",
+		"INT8":                     "bigint",
+		"vector( 384 )":            "vector(384)",
+		"varchar(64)":              "character varying(64)",
+		"timestamp with time zone": "timestamp with time zone",
+	} {
+		assert.Equal(t, want, normalizeType(in), in)
+	}
+	dims, ok := vectorDimensions("halfvec(1536)")
+	assert.True(t, ok)
+	assert.Equal(t, 1536, dims)
+	_, ok = vectorDimensions("vector")
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"slices"
+	"sort"
+)
+
+// Service states reported by Check. A dirty service uses StateDirty.
+const (
+	StateCurrent = "current"
+	// StateBehind means the service has migrations that are not applied.
+	StateBehind = "behind"
+	// StateAhead means schema_migrations records versions for the service
+	// that no loaded source declares.
+	StateAhead = "ahead"
+)
+
+// UnassignedService groups recorded versions that no source declares and
+// that carry no service name.
+const UnassignedService = "unassigned"
+
+// ServiceState compares one service's migrations with schema_migrations.
+// Current is the highest applied version of the service and Latest the
+// highest version its source declares.
+type ServiceState struct {
+	Service string  `json:"service"`
+	State   string  `json:"state"`
+	Current int64   `json:"current"`
+	Latest  int64   `json:"latest"`
+	Pending []int64 `json:"pending,omitempty"`
+	Dirty   []int64 `json:"dirty,omitempty"`
+	Unknown []int64 `json:"unknown,omitempty"`
+}
+
+// Check reports the state of every service, ordered by service name. Dirty
+// takes precedence over behind, and behind over ahead.
+func Check(migrations []Migration, applied []Applied) []ServiceState {
+	states := map[string]*ServiceState{}
+	state := func(service string) *ServiceState {
+		s := states[service]
+		if s == nil {
+			s = &ServiceState{Service: service}
+			states[service] = s
+		}
+		return s
+	}
+
+	rows := map[int64]Applied{}
+	for _, a := range applied {
+		rows[a.Version] = a
+	}
+	for _, m := range migrations {
+		s := state(m.Service)
+		s.Latest = max(s.Latest, m.Version)
+		a, ok := rows[m.Version]
+		if !ok {
+			s.Pending = append(s.Pending, m.Version)
+			continue
+		}
+		delete(rows, m.Version)
+		s.Current = max(s.Current, m.Version)
+		if a.Dirty {
+			s.Dirty = append(s.Dirty, m.Version)
+		}
+	}
+	for _, a := range rows {
+		service := a.Service
+		if service == "" {
+			service = UnassignedService
+		}
+		s := state(service)
+		s.Current = max(s.Current, a.Version)
+		s.Unknown = append(s.Unknown, a.Version)
+		if a.Dirty {
+			s.Dirty = append(s.Dirty, a.Version)
+		}
+	}
+
+	out := make([]ServiceState, 0, len(states))
+	for _, s := range states {
+		slices.Sort(s.Unknown)
+		slices.Sort(s.Dirty)
+		switch {
+		case len(s.Dirty) > 0:
+			s.State = StateDirty
+		case len(s.Pending) > 0:
+			s.State = StateBehind
+		case len(s.Unknown) > 0:
+			s.State = StateAhead
+		default:
+			s.State = StateCurrent
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+	return out
+}
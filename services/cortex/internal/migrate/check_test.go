@@ -0,0 +1,32 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	got := Check(testMigrations, []Applied{
+		{Version: 1},
+		{Version: 2, Dirty: true},
+		{Version: 4},
+		{Version: 7, Service: "reasoner"},
+		{Version: 9},
+	})
+	assert.Equal(t, []ServiceState{
+		{Service: "platform", State: StateDirty, Current: 2, Latest: 2, Dirty: []int64{2}},
+		{Service: "reasoner", State: StateBehind, Current: 7, Latest: 4, Pending: []int64{3}, Unknown: []int64{7}},
+		{Service: UnassignedService, State: StateAhead, Current: 9, Unknown: []int64{9}},
+	}, got)
+}
+
+func TestCheck_Current(t *testing.T) {
+	t.Parallel()
+	got := Check(testMigrations, []Applied{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}})
+	assert.Equal(t, []ServiceState{
+		{Service: "platform", State: StateCurrent, Current: 2, Latest: 2},
+		{Service: "reasoner", State: StateCurrent, Current: 4, Latest: 4},
+	}, got)
+}
@@ -0,0 +1,153 @@
+// Package migrate applies versioned SQL migrations to arc-persistence and
+// records them in schema_migrations, one row per applied version.
+//
+// Migrations come from the files embedded in this package (the platform
+// schema) and from per-service directories. A file is named
+// <version>_<name>.up.sql with an optional <version>_<name>.down.sql; a plain
+// <version>_<name>.sql is an up-only migration. Versions are unique across all
+// sources so schema_migrations can stay keyed by version alone.
+//
+// Each migration runs in its own transaction together with its
+// schema_migrations update, so statements that cannot run inside a
+// transaction (CREATE DATABASE, CREATE INDEX CONCURRENTLY) are not supported.
+package migrate
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// EmbeddedService is the service name reported for embedded migrations.
+const EmbeddedService = "platform"
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Service string
+	Up      string
+	// Down is empty when the migration cannot be reverted.
+	Down string
+}
+
+// Source is a set of migration files belonging to one service.
+type Source struct {
+	Service string
+	FS      fs.FS
+}
+
+// Embedded returns the platform migrations compiled into Cortex.
+func Embedded() Source {
+	sub, _ := fs.Sub(embedded, "migrations")
+	return Source{Service: EmbeddedService, FS: sub}
+}
+
+// Sources returns the sources selected by cfg: the embedded platform
+// migrations when enabled, followed by each configured directory.
+func Sources(cfg config.PostgresMigrationsConfig) ([]Source, error) {
+	var sources []Source
+	if cfg.Embedded {
+		sources = append(sources, Embedded())
+	}
+	for _, entry := range cfg.Dirs {
+		src, err := DirSource(entry)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// DirSource parses a "service=path" directory entry. Without a service name
+// the directory's base name is used.
+func DirSource(entry string) (Source, error) {
+	service, dir, ok := strings.Cut(entry, "=")
+	if !ok {
+		dir = entry
+		service = filepath.Base(filepath.Clean(entry))
+	}
+	if service == "" || dir == "" {
+		return Source{}, fmt.Errorf("invalid migration directory %q: want service=path", entry)
+	}
+	return Source{Service: service, FS: os.DirFS(dir)}, nil
+}
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+?)(\.up|\.down)?\.sql$`)
+
+// Load reads every source and returns the migrations ordered by version. It
+// fails on unparseable file names, a down file without an up file, and
+// versions declared twice.
+func Load(sources ...Source) ([]Migration, error) {
+	byVersion := map[int64]*Migration{}
+	for _, src := range sources {
+		entries, err := fs.ReadDir(src.FS, ".")
+		if err != nil {
+			return nil, fmt.Errorf("reading %s migrations: %w", src.Service, err)
+		}
+		found := map[int64]*Migration{}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+				continue
+			}
+			m := fileRe.FindStringSubmatch(e.Name())
+			if m == nil {
+				return nil, fmt.Errorf("%s migration %s: name must be <version>_<name>[.up|.down].sql", src.Service, e.Name())
+			}
+			version, err := strconv.ParseInt(m[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s migration %s: %w", src.Service, e.Name(), err)
+			}
+			body, err := fs.ReadFile(src.FS, e.Name())
+			if err != nil {
+				return nil, fmt.Errorf("reading %s migration %s: %w", src.Service, e.Name(), err)
+			}
+
+			mig := found[version]
+			if mig == nil {
+				mig = &Migration{Version: version, Name: m[2], Service: src.Service}
+				found[version] = mig
+			} else if mig.Name != m[2] {
+				return nil, fmt.Errorf("%s migration %d has two names: %s and %s", src.Service, version, mig.Name, m[2])
+			}
+			if m[3] == ".down" {
+				mig.Down = string(body)
+			} else {
+				if mig.Up != "" {
+					return nil, fmt.Errorf("%s migration %d has more than one up script", src.Service, version)
+				}
+				mig.Up = string(body)
+			}
+		}
+
+		for version, mig := range found {
+			if mig.Up == "" {
+				return nil, fmt.Errorf("%s migration %d_%s has a down script but no up script", src.Service, version, mig.Name)
+			}
+			if prev, ok := byVersion[version]; ok {
+				return nil, fmt.Errorf("migration version %d declared by both %s (%s) and %s (%s)",
+					version, prev.Service, prev.Name, mig.Service, mig.Name)
+			}
+			byVersion[version] = mig
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+func src(service string, files map[string]string) Source {
+	fsys := fstest.MapFS{}
+	for name, body := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(body)}
+	}
+	return Source{Service: service, FS: fsys}
+}
+
+func TestLoad_OrdersAndPairsFiles(t *testing.T) {
+	t.Parallel()
+	migrations, err := Load(
+		src("reasoner", map[string]string{
+			"010_chunks.up.sql":   "CREATE TABLE c();",
+			"010_chunks.down.sql": "DROP TABLE c;",
+			"README.md":           "ignored",
+		}),
+		src("platform", map[string]string{
+			"001_init.sql":      "CREATE SCHEMA x;",
+			"005_seed.up.sql":   "INSERT 1;",
+			"005_seed.down.sql": "DELETE 1;",
+		}),
+	)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+
+	assert.Equal(t, Migration{Version: 1, Name: "init", Service: "platform", Up: "CREATE SCHEMA x;"}, migrations[0])
+	assert.Equal(t, int64(5), migrations[1].Version)
+	assert.Equal(t, "DELETE 1;", migrations[1].Down)
+	assert.Equal(t, Migration{Version: 10, Name: "chunks", Service: "reasoner", Up: "CREATE TABLE c();", Down: "DROP TABLE c;"}, migrations[2])
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+	cases := map[string][]Source{
+		"bad name": {src("a", map[string]string{"init.sql": ""})},
+		"down without up": {src("a", map[string]string{
+			"001_init.down.sql": "DROP;",
+		})},
+		"name mismatch": {src("a", map[string]string{
+			"001_init.up.sql":    "A;",
+			"001_other.down.sql": "B;",
+		})},
+		"two up scripts": {src("a", map[string]string{
+			"001_init.up.sql": "A;",
+			"001_init.sql":    "B;",
+		})},
+		"duplicate across sources": {
+			src("a", map[string]string{"001_init.sql": "A;"}),
+			src("b", map[string]string{"001_init.sql": "B;"}),
+		},
+	}
+	for name, sources := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Load(sources...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbedded_Loads(t *testing.T) {
+	t.Parallel()
+	migrations, err := Load(Embedded())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for _, m := range migrations {
+		assert.Equal(t, EmbeddedService, m.Service)
+		assert.NotEmpty(t, m.Down, "embedded migration %d_%s should be reversible", m.Version, m.Name)
+	}
+}
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	sources, err := Sources(config.PostgresMigrationsConfig{Embedded: true, Dirs: []string{"reasoner=" + dir, dir}})
+	require.NoError(t, err)
+	require.Len(t, sources, 3)
+	assert.Equal(t, EmbeddedService, sources[0].Service)
+	assert.Equal(t, "reasoner", sources[1].Service)
+	assert.NotEmpty(t, sources[2].Service)
+
+	_, err = Sources(config.PostgresMigrationsConfig{Dirs: []string{"=" + dir}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,2 @@
+-- pgvector backs reasoner.knowledge_chunks.embedding.
+CREATE EXTENSION IF NOT EXISTS vector;
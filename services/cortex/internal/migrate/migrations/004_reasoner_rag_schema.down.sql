@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reasoner.knowledge_chunks;
+DROP TABLE IF EXISTS reasoner.vector_store_files;
+DROP TABLE IF EXISTS reasoner.knowledge_files;
+DROP TABLE IF EXISTS reasoner.vector_stores;
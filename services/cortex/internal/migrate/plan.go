@@ -0,0 +1,178 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Direction says whether a step applies or reverts a migration.
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// Step is one migration to run in one direction.
+type Step struct {
+	Migration
+	Direction Direction
+}
+
+// Status states reported for each version.
+const (
+	StateApplied = "applied"
+	StatePending = "pending"
+	StateDirty   = "dirty"
+	// StateUnknown is a version recorded in schema_migrations that no
+	// loaded source declares, usually because this Cortex is older than the
+	// database.
+	StateUnknown = "unknown"
+)
+
+// Status describes one version for `cortex migrate status`.
+type Status struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name,omitempty"`
+	Service string `json:"service,omitempty"`
+	State   string `json:"state"`
+}
+
+// Applied is a schema_migrations row. Service is empty for rows written by
+// other tools.
+type Applied struct {
+	Version int64
+	Dirty   bool
+	Service string
+}
+
+// Statuses merges the known migrations with the recorded rows, ordered by
+// version.
+func Statuses(migrations []Migration, applied []Applied) []Status {
+	rows := map[int64]Applied{}
+	for _, a := range applied {
+		rows[a.Version] = a
+	}
+	out := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		s := Status{Version: m.Version, Name: m.Name, Service: m.Service, State: StatePending}
+		if a, ok := rows[m.Version]; ok {
+			s.State = StateApplied
+			if a.Dirty {
+				s.State = StateDirty
+			}
+			delete(rows, m.Version)
+		}
+		out = append(out, s)
+	}
+	for _, a := range rows {
+		s := Status{Version: a.Version, State: StateUnknown}
+		if a.Dirty {
+			s.State = StateDirty
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
+
+// PlanUp returns every migration not yet applied, oldest first. Versions
+// lower than the newest applied one are included, so a service directory
+// added later still has its migrations run.
+func PlanUp(migrations []Migration, applied []Applied) []Step {
+	done := appliedSet(applied)
+	var steps []Step
+	for _, m := range migrations {
+		if !done[m.Version] {
+			steps = append(steps, Step{Migration: m, Direction: DirectionUp})
+		}
+	}
+	return steps
+}
+
+// PlanDown reverts the n most recently versioned applied migrations, newest
+// first.
+func PlanDown(migrations []Migration, applied []Applied, n int) ([]Step, error) {
+	versions := make([]int64, 0, len(applied))
+	for _, a := range applied {
+		versions = append(versions, a.Version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if n < len(versions) {
+		versions = versions[:n]
+	}
+	return revertSteps(migrations, versions)
+}
+
+// PlanGoto moves the database to target: applied migrations above it are
+// reverted newest first, then pending migrations at or below it are applied
+// oldest first. Target 0 reverts everything.
+func PlanGoto(migrations []Migration, applied []Applied, target int64) ([]Step, error) {
+	if target != 0 {
+		if _, ok := find(migrations, target); !ok {
+			return nil, fmt.Errorf("no migration with version %d", target)
+		}
+	}
+
+	var revert []int64
+	for _, a := range applied {
+		if a.Version > target {
+			revert = append(revert, a.Version)
+		}
+	}
+	sort.Slice(revert, func(i, j int) bool { return revert[i] > revert[j] })
+	steps, err := revertSteps(migrations, revert)
+	if err != nil {
+		return nil, err
+	}
+
+	done := appliedSet(applied)
+	for _, m := range migrations {
+		if m.Version <= target && !done[m.Version] {
+			steps = append(steps, Step{Migration: m, Direction: DirectionUp})
+		}
+	}
+	return steps, nil
+}
+
+// checkClean refuses to plan against a database with a dirty version: a
+// previous tool left it half-migrated and it needs manual repair.
+func checkClean(applied []Applied) error {
+	for _, a := range applied {
+		if a.Dirty {
+			return fmt.Errorf("schema_migrations version %d is dirty: repair the schema by hand, then clear the dirty flag", a.Version)
+		}
+	}
+	return nil
+}
+
+func revertSteps(migrations []Migration, versions []int64) ([]Step, error) {
+	steps := make([]Step, 0, len(versions))
+	for _, v := range versions {
+		m, ok := find(migrations, v)
+		if !ok {
+			return nil, fmt.Errorf("cannot revert version %d: no migration file declares it", v)
+		}
+		if m.Down == "" {
+			return nil, fmt.Errorf("cannot revert %s migration %d_%s: it has no down script", m.Service, m.Version, m.Name)
+		}
+		steps = append(steps, Step{Migration: m, Direction: DirectionDown})
+	}
+	return steps, nil
+}
+
+func find(migrations []Migration, version int64) (Migration, bool) {
+	i := sort.Search(len(migrations), func(i int) bool { return migrations[i].Version >= version })
+	if i < len(migrations) && migrations[i].Version == version {
+		return migrations[i], true
+	}
+	return Migration{}, false
+}
+
+func appliedSet(applied []Applied) map[int64]bool {
+	set := make(map[int64]bool, len(applied))
+	for _, a := range applied {
+		set[a.Version] = true
+	}
+	return set
+}
@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "one", Service: "platform", Up: "1", Down: "-1"},
+	{Version: 2, Name: "two", Service: "platform", Up: "2", Down: "-2"},
+	{Version: 3, Name: "three", Service: "reasoner", Up: "3"},
+	{Version: 4, Name: "four", Service: "reasoner", Up: "4", Down: "-4"},
+}
+
+func versions(steps []Step) []int64 {
+	out := make([]int64, 0, len(steps))
+	for _, s := range steps {
+		out = append(out, s.Version)
+	}
+	return out
+}
+
+func TestPlanUp_FillsGaps(t *testing.T) {
+	t.Parallel()
+	steps := PlanUp(testMigrations, []Applied{{Version: 1}, {Version: 4}})
+	assert.Equal(t, []int64{2, 3}, versions(steps))
+	for _, s := range steps {
+		assert.Equal(t, DirectionUp, s.Direction)
+	}
+}
+
+func TestPlanDown(t *testing.T) {
+	t.Parallel()
+	applied := []Applied{{Version: 1}, {Version: 2}, {Version: 4}}
+
+	steps, err := PlanDown(testMigrations, applied, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4, 2}, versions(steps))
+	assert.Equal(t, DirectionDown, steps[0].Direction)
+
+	steps, err = PlanDown(testMigrations, applied, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4, 2, 1}, versions(steps))
+}
+
+func TestPlanDown_Irreversible(t *testing.T) {
+	t.Parallel()
+	_, err := PlanDown(testMigrations, []Applied{{Version: 3}}, 1)
+	assert.ErrorContains(t, err, "no down script")
+
+	_, err = PlanDown(testMigrations, []Applied{{Version: 9}}, 1)
+	assert.ErrorContains(t, err, "no migration file declares it")
+}
+
+func TestPlanGoto(t *testing.T) {
+	t.Parallel()
+	applied := []Applied{{Version: 1}, {Version: 4}}
+
+	steps, err := PlanGoto(testMigrations, applied, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4, 2, 3}, versions(steps))
+	assert.Equal(t, []Direction{DirectionDown, DirectionUp, DirectionUp},
+		[]Direction{steps[0].Direction, steps[1].Direction, steps[2].Direction})
+
+	steps, err = PlanGoto(testMigrations, []Applied{{Version: 1}, {Version: 2}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, versions(steps))
+
+	_, err = PlanGoto(testMigrations, applied, 7)
+	assert.ErrorContains(t, err, "no migration with version 7")
+}
+
+func TestStatuses(t *testing.T) {
+	t.Parallel()
+	got := Statuses(testMigrations, []Applied{{Version: 1}, {Version: 2, Dirty: true}, {Version: 9}})
+	assert.Equal(t, []Status{
+		{Version: 1, Name: "one", Service: "platform", State: StateApplied},
+		{Version: 2, Name: "two", Service: "platform", State: StateDirty},
+		{Version: 3, Name: "three", Service: "reasoner", State: StatePending},
+		{Version: 4, Name: "four", Service: "reasoner", State: StatePending},
+		{Version: 9, State: StateUnknown},
+	}, got)
+}
+
+func TestCheckClean(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, checkClean([]Applied{{Version: 1}}))
+	assert.ErrorContains(t, checkClean([]Applied{{Version: 1}, {Version: 2, Dirty: true}}), "version 2 is dirty")
+}
@@ -0,0 +1,202 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// lockKey is the pg_advisory_lock key held while migrating so concurrent
+// Cortex replicas do not run the same migration twice.
+const lockKey int64 = 0x636f72746578 // "cortex"
+
+// Conn is a single database session; *pgx.Conn and *pgxpool.Conn satisfy it.
+// A session is required because the advisory lock is session-scoped.
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Runner applies migrations over one connection.
+type Runner struct {
+	conn       Conn
+	migrations []Migration
+}
+
+// NewRunner returns a Runner for migrations, which must be ordered by version
+// as Load returns them.
+func NewRunner(conn Conn, migrations []Migration) *Runner {
+	return &Runner{conn: conn, migrations: migrations}
+}
+
+// Status reports every known and recorded version without changing the
+// database.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := ReadApplied(ctx, r.conn)
+	if err != nil {
+		return nil, err
+	}
+	return Statuses(r.migrations, applied), nil
+}
+
+// Up applies every pending migration.
+func (r *Runner) Up(ctx context.Context) ([]Step, error) {
+	return r.run(ctx, func(applied []Applied) ([]Step, error) {
+		return PlanUp(r.migrations, applied), nil
+	})
+}
+
+// Down reverts the n newest applied migrations.
+func (r *Runner) Down(ctx context.Context, n int) ([]Step, error) {
+	return r.run(ctx, func(applied []Applied) ([]Step, error) {
+		return PlanDown(r.migrations, applied, n)
+	})
+}
+
+// Goto applies or reverts migrations until exactly those up to version are
+// applied.
+func (r *Runner) Goto(ctx context.Context, version int64) ([]Step, error) {
+	return r.run(ctx, func(applied []Applied) ([]Step, error) {
+		return PlanGoto(r.migrations, applied, version)
+	})
+}
+
+// run takes the advisory lock, plans against the recorded state and executes
+// the steps in order, stopping at the first failure. It returns the steps
+// that completed.
+func (r *Runner) run(ctx context.Context, plan func([]Applied) ([]Step, error)) ([]Step, error) {
+	if _, err := r.conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return nil, fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer func() {
+		// Release even if ctx was cancelled mid-run.
+		if _, err := r.conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			slog.Warn("releasing migration lock", "err", err)
+		}
+	}()
+
+	if _, err := r.conn.Exec(ctx, createTableSQL); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	applied, err := ReadApplied(ctx, r.conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClean(applied); err != nil {
+		return nil, err
+	}
+	steps, err := plan(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Step, 0, len(steps))
+	for _, step := range steps {
+		slog.Info("running migration", "version", step.Version, "name", step.Name,
+			"service", step.Service, "direction", step.Direction)
+		if err := r.execute(ctx, step); err != nil {
+			return done, err
+		}
+		done = append(done, step)
+	}
+	return done, nil
+}
+
+// execute runs one step and its schema_migrations update in a transaction.
+func (r *Runner) execute(ctx context.Context, step Step) (err error) {
+	label := fmt.Sprintf("%s migration %d_%s (%s)", step.Service, step.Version, step.Name, step.Direction)
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin: %w", label, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(context.Background())
+		}
+	}()
+
+	sql := step.Up
+	if step.Direction == DirectionDown {
+		sql = step.Down
+	}
+	if strings.TrimSpace(sql) != "" {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+	}
+
+	if step.Direction == DirectionUp {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO public.schema_migrations (version, dirty, name, service) VALUES ($1, false, $2, $3)
+			ON CONFLICT (version) DO UPDATE SET dirty = false, name = EXCLUDED.name, service = EXCLUDED.service, applied_at = now()`,
+			step.Version, step.Name, step.Service)
+	} else {
+		_, err = tx.Exec(ctx, "DELETE FROM public.schema_migrations WHERE version = $1", step.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: recording version: %w", label, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit: %w", label, err)
+	}
+	return nil
+}
+
+// createTableSQL creates schema_migrations in the shape persistence/initdb
+// uses and adds the bookkeeping columns the runner fills in.
+const createTableSQL = `
+CREATE TABLE IF NOT EXISTS public.schema_migrations (
+    version bigint  NOT NULL PRIMARY KEY,
+    dirty   boolean NOT NULL
+);
+ALTER TABLE public.schema_migrations
+    ADD COLUMN IF NOT EXISTS name       text        NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS service    text        NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS applied_at timestamptz NOT NULL DEFAULT now();`
+
+// Querier runs a query; Conn, *pgxpool.Pool and *pgx.Conn satisfy it.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// ReadApplied returns the schema_migrations rows ordered by version, or none
+// when the table does not exist yet. The service column is read through
+// to_jsonb so tables created by initdb, which lack it, can be read too.
+func ReadApplied(ctx context.Context, conn Querier) ([]Applied, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT version, dirty, COALESCE(to_jsonb(m)->>'service', '')
+		FROM public.schema_migrations m ORDER BY version`)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	applied, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Applied, error) {
+		var a Applied
+		err := row.Scan(&a.Version, &a.Dirty, &a.Service)
+		return a, err
+	})
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// isUndefinedTable reports SQLSTATE 42P01, raised when schema_migrations has
+// not been created.
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
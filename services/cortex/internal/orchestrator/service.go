@@ -17,8 +17,10 @@ import (
 // bootstrap is already running.
 var ErrBootstrapInProgress = errors.New("bootstrap already in progress")
 
-// PGProber is satisfied by *clients.PostgresClient.
-type PGProber interface {
+// PGProvisioner is satisfied by *clients.PostgresClient. Provision returns
+// per-resource outcomes alongside any error so partial progress is visible.
+type PGProvisioner interface {
+	Provision(ctx context.Context) ([]ResourceResult, error)
 	Probe(ctx context.Context) ProbeResult
 }
 
@@ -28,9 +30,10 @@ type NATSProvisioner interface {
 	Probe(ctx context.Context) ProbeResult
 }
 
-// PulsarProvisioner is satisfied by *clients.PulsarClient.
+// PulsarProvisioner is satisfied by *clients.PulsarClient. Provision returns
+// per-resource outcomes alongside any error so partial progress is visible.
 type PulsarProvisioner interface {
-	Provision(ctx context.Context) error
+	Provision(ctx context.Context) ([]ResourceResult, error)
 	Probe(ctx context.Context) ProbeResult
 }
 
@@ -41,7 +44,7 @@ type RedisProber interface {
 
 // Orchestrator runs bootstrap phases and health probes.
 type Orchestrator struct {
-	pg     PGProber
+	pg     PGProvisioner
 	nats   NATSProvisioner
 	pulsar PulsarProvisioner
 	redis  RedisProber
@@ -53,7 +56,7 @@ type Orchestrator struct {
 
 // New constructs an Orchestrator with the given clients. The concrete client
 // types satisfy the interfaces defined in this package.
-func New(pg PGProber, nats NATSProvisioner, pulsar PulsarProvisioner, redis RedisProber) *Orchestrator {
+func New(pg PGProvisioner, nats NATSProvisioner, pulsar PulsarProvisioner, redis RedisProber) *Orchestrator {
 	return &Orchestrator{
 		pg:     pg,
 		nats:   nats,
@@ -86,8 +89,14 @@ func (o *Orchestrator) RunBootstrap(ctx context.Context) (*BootstrapResult, erro
 	var g errgroup.Group
 
 	g.Go(func() error {
-		probe := o.pg.Probe(ctx)
-		phase := probeToPhase("postgres", probe)
+		// Roles and schemas are reconciled before the probe so it sees
+		// the provisioned state.
+		resources, err := o.pg.Provision(ctx)
+		phase := provisionToPhase("postgres", err)
+		if err == nil {
+			phase = probeToPhase("postgres", o.pg.Probe(ctx))
+		}
+		phase.Resources = resources
 		logPhase(ctx, phase)
 		result.Lock()
 		result.Phases["postgres"] = phase
@@ -106,8 +115,9 @@ func (o *Orchestrator) RunBootstrap(ctx context.Context) (*BootstrapResult, erro
 	})
 
 	g.Go(func() error {
-		err := o.pulsar.Provision(ctx)
+		resources, err := o.pulsar.Provision(ctx)
 		phase := provisionToPhase("pulsar", err)
+		phase.Resources = resources
 		logPhase(ctx, phase)
 		result.Lock()
 		result.Phases["pulsar"] = phase
@@ -212,6 +222,10 @@ func (o *Orchestrator) IsReady() bool {
 // Errors log at WARN so they are visible without being fatal.
 func logPhase(ctx context.Context, p PhaseResult) {
 	if p.Status == StatusOK {
+		if len(p.Warnings) > 0 {
+			slog.WarnContext(ctx, "bootstrap phase degraded", "phase", p.Name, "warnings", p.Warnings)
+			return
+		}
 		slog.InfoContext(ctx, "bootstrap phase ok", "phase", p.Name)
 		return
 	}
@@ -221,9 +235,9 @@ func logPhase(ctx context.Context, p PhaseResult) {
 // probeToPhase converts a ProbeResult to a PhaseResult.
 func probeToPhase(name string, p ProbeResult) PhaseResult {
 	if p.OK {
-		return PhaseResult{Name: name, Status: StatusOK}
+		return PhaseResult{Name: name, Status: StatusOK, Warnings: p.Warnings}
 	}
-	return PhaseResult{Name: name, Status: StatusError, Error: p.Error}
+	return PhaseResult{Name: name, Status: StatusError, Error: p.Error, Warnings: p.Warnings}
 }
 
 // provisionToPhase converts a provision error to a PhaseResult.
@@ -12,11 +12,16 @@ import (
 
 // --- mock implementations ---
 
-type mockPGProber struct {
-	result ProbeResult
+type mockPGProvisioner struct {
+	resources    []ResourceResult
+	provisionErr error
+	result       ProbeResult
 }
 
-func (m *mockPGProber) Probe(_ context.Context) ProbeResult { return m.result }
+func (m *mockPGProvisioner) Provision(_ context.Context) ([]ResourceResult, error) {
+	return m.resources, m.provisionErr
+}
+func (m *mockPGProvisioner) Probe(_ context.Context) ProbeResult { return m.result }
 
 type mockNATSProvisioner struct {
 	provisionErr error
@@ -27,11 +32,14 @@ func (m *mockNATSProvisioner) ProvisionStreams(_ context.Context) error { return
 func (m *mockNATSProvisioner) Probe(_ context.Context) ProbeResult      { return m.probeResult }
 
 type mockPulsarProvisioner struct {
+	resources    []ResourceResult
 	provisionErr error
 	probeResult  ProbeResult
 }
 
-func (m *mockPulsarProvisioner) Provision(_ context.Context) error { return m.provisionErr }
+func (m *mockPulsarProvisioner) Provision(_ context.Context) ([]ResourceResult, error) {
+	return m.resources, m.provisionErr
+}
 func (m *mockPulsarProvisioner) Probe(_ context.Context) ProbeResult { return m.probeResult }
 
 type mockRedisProber struct {
@@ -40,13 +48,17 @@ type mockRedisProber struct {
 
 func (m *mockRedisProber) Probe(_ context.Context) ProbeResult { return m.result }
 
-// blockingPGProber blocks until released — used to test concurrent bootstrap guard.
-type blockingPGProber struct {
+// blockingPGProvisioner blocks until released — used to test concurrent bootstrap guard.
+type blockingPGProvisioner struct {
 	ready chan struct{} // closed when Probe is entered
 	done  chan struct{} // close to unblock Probe
 }
 
-func (b *blockingPGProber) Probe(_ context.Context) ProbeResult {
+func (b *blockingPGProvisioner) Provision(_ context.Context) ([]ResourceResult, error) {
+	return nil, nil
+}
+
+func (b *blockingPGProvisioner) Probe(_ context.Context) ProbeResult {
 	close(b.ready)
 	<-b.done
 	return ProbeResult{OK: true}
@@ -54,11 +66,11 @@ func (b *blockingPGProber) Probe(_ context.Context) ProbeResult {
 
 // --- helpers ---
 
-func okPG() *mockPGProber {
-	return &mockPGProber{result: ProbeResult{Name: "arc-persistence", OK: true}}
+func okPG() *mockPGProvisioner {
+	return &mockPGProvisioner{result: ProbeResult{Name: "arc-persistence", OK: true}}
 }
-func errPG(msg string) *mockPGProber {
-	return &mockPGProber{result: ProbeResult{Name: "arc-persistence", OK: false, Error: msg}}
+func errPG(msg string) *mockPGProvisioner {
+	return &mockPGProvisioner{result: ProbeResult{Name: "arc-persistence", OK: false, Error: msg}}
 }
 func okNATS() *mockNATSProvisioner {
 	return &mockNATSProvisioner{probeResult: ProbeResult{Name: "arc-messaging", OK: true}}
@@ -92,7 +104,7 @@ func TestRunBootstrap(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		pg             PGProber
+		pg             PGProvisioner
 		nats           NATSProvisioner
 		pulsar         PulsarProvisioner
 		redis          RedisProber
@@ -235,7 +247,7 @@ func TestRunBootstrap_IsReady(t *testing.T) {
 func TestRunBootstrap_InProgressGuard(t *testing.T) {
 	t.Parallel()
 
-	blocker := &blockingPGProber{
+	blocker := &blockingPGProvisioner{
 		ready: make(chan struct{}),
 		done:  make(chan struct{}),
 	}
@@ -286,12 +298,67 @@ func TestRunBootstrap_ResultUpdated(t *testing.T) {
 	assert.Equal(t, StatusOK, stored.Status)
 }
 
+func TestRunBootstrap_PulsarResources(t *testing.T) {
+	t.Parallel()
+
+	pulsar := &mockPulsarProvisioner{
+		resources: []ResourceResult{
+			{Resource: "persistent://arc-system/events/agent-lifecycle", Action: ActionConflict, Detail: "has 6 partitions, want 3"},
+			{Resource: "persistent://arc-system/logs/application", Action: ActionUnchanged},
+		},
+		provisionErr: errors.New("partition conflict"),
+	}
+	o := New(okPG(), okNATS(), pulsar, okRedis())
+
+	result, err := o.RunBootstrap(context.Background())
+	require.NoError(t, err)
+
+	phase := result.Phases["pulsar"]
+	assert.Equal(t, StatusError, phase.Status)
+	assert.Equal(t, pulsar.resources, phase.Resources)
+}
+
+func TestRunBootstrap_PostgresResources(t *testing.T) {
+	t.Parallel()
+
+	t.Run("provisioned then probed", func(t *testing.T) {
+		t.Parallel()
+		pg := &mockPGProvisioner{
+			resources: []ResourceResult{{Resource: "role:reasoner", Action: ActionCreated}},
+			result:    ProbeResult{OK: true, Warnings: []string{"platform migrations behind"}},
+		}
+		result, err := New(pg, okNATS(), okPulsar(), okRedis()).RunBootstrap(context.Background())
+		require.NoError(t, err)
+
+		phase := result.Phases["postgres"]
+		assert.Equal(t, StatusOK, phase.Status)
+		assert.Equal(t, pg.resources, phase.Resources)
+		assert.Equal(t, []string{"platform migrations behind"}, phase.Warnings)
+	})
+
+	t.Run("provision failure skips the probe", func(t *testing.T) {
+		t.Parallel()
+		pg := &mockPGProvisioner{
+			resources:    []ResourceResult{{Resource: "role:reasoner", Action: ActionUnchanged}},
+			provisionErr: errors.New("permission denied to create role"),
+			result:       ProbeResult{OK: true},
+		}
+		result, err := New(pg, okNATS(), okPulsar(), okRedis()).RunBootstrap(context.Background())
+		require.NoError(t, err)
+
+		phase := result.Phases["postgres"]
+		assert.Equal(t, StatusError, phase.Status)
+		assert.Equal(t, "permission denied to create role", phase.Error)
+		assert.Equal(t, pg.resources, phase.Resources)
+	})
+}
+
 func TestRunDeepHealth(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		name   string
-		pg     PGProber
+		pg     PGProvisioner
 		nats   NATSProvisioner
 		pulsar PulsarProvisioner
 		redis  RedisProber
@@ -372,6 +439,13 @@ func TestProbeToPhase(t *testing.T) {
 		assert.Equal(t, StatusError, phase.Status)
 		assert.Equal(t, "timeout", phase.Error)
 	})
+
+	t.Run("degraded probe keeps warnings", func(t *testing.T) {
+		t.Parallel()
+		phase := probeToPhase("pg", ProbeResult{OK: true, Degraded: true, Warnings: []string{"behind"}})
+		assert.Equal(t, StatusOK, phase.Status)
+		assert.Equal(t, []string{"behind"}, phase.Warnings)
+	})
 }
 
 func TestProvisionToPhase(t *testing.T) {
@@ -21,17 +21,43 @@ type BootstrapResult struct {
 	Phases map[string]PhaseResult `json:"phases"`
 }
 
+// Action values used in ResourceResult.
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionConflict  = "conflict"
+)
+
 // PhaseResult represents the outcome of a single bootstrap phase.
+// Resources lists per-resource outcomes for phases that report them;
+// Warnings carries the probe warnings of a phase that passed degraded.
 type PhaseResult struct {
-	Name   string `json:"name"`
-	Status string `json:"status"` // "ok", "error", "skipped"
-	Error  string `json:"error,omitempty"`
+	Name      string           `json:"name"`
+	Status    string           `json:"status"` // "ok", "error", "skipped"
+	Error     string           `json:"error,omitempty"`
+	Resources []ResourceResult `json:"resources,omitempty"`
+	Warnings  []string         `json:"warnings,omitempty"`
+}
+
+// ResourceResult records what a provisioning phase did to a single resource,
+// e.g. a Pulsar topic. Detail explains updates and conflicts.
+type ResourceResult struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"` // "created", "updated", "unchanged", "conflict"
+	Detail   string `json:"detail,omitempty"`
 }
 
 // ProbeResult is returned by RunDeepHealth for each dependency.
+// Degraded marks a dependency that is reachable (OK is still true) but has
+// crossed one of its configured thresholds; Warnings explains which ones.
+// Details carries a dependency-specific payload for the deep health output.
 type ProbeResult struct {
-	Name      string `json:"name"`
-	OK        bool   `json:"ok"`
-	LatencyMs int64  `json:"latencyMs"`
-	Error     string `json:"error,omitempty"`
+	Name      string   `json:"name"`
+	OK        bool     `json:"ok"`
+	Degraded  bool     `json:"degraded,omitempty"`
+	LatencyMs int64    `json:"latencyMs"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Details   any      `json:"details,omitempty"`
 }
@@ -0,0 +1,115 @@
+// Package secrets stores generated credentials in arc-vault (OpenBao) through
+// the KV version 2 HTTP API.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// ErrNotFound is returned by Read when no secret exists at the path.
+var ErrNotFound = errors.New("secret not found")
+
+// OpenBao is a KV version 2 client bound to one mount.
+type OpenBao struct {
+	address string
+	token   string
+	mount   string
+	http    *http.Client
+}
+
+// NewOpenBao returns a client for cfg. No request is made until Read or
+// Write is called.
+func NewOpenBao(cfg config.OpenBaoConfig) *OpenBao {
+	return &OpenBao{
+		address: strings.TrimRight(cfg.Address, "/"),
+		token:   cfg.Token,
+		mount:   strings.Trim(cfg.Mount, "/"),
+		http:    &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Read returns the latest version of the secret at path.
+func (b *OpenBao) Read(ctx context.Context, path string) (map[string]string, error) {
+	resp, err := b.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading secret %s: %s", path, errorBody(resp))
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding secret %s: %w", path, err)
+	}
+	// A deleted latest version reads back as 200 with null data.
+	if body.Data.Data == nil {
+		return nil, ErrNotFound
+	}
+	return body.Data.Data, nil
+}
+
+// Write stores data as a new version of the secret at path.
+func (b *OpenBao) Write(ctx context.Context, path string, data map[string]string) error {
+	payload, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return fmt.Errorf("encoding secret %s: %w", path, err)
+	}
+	resp, err := b.do(ctx, http.MethodPost, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("writing secret %s: %s", path, errorBody(resp))
+	}
+	return nil
+}
+
+func (b *OpenBao) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	url := fmt.Sprintf("%s/v1/%s/data/%s", b.address, b.mount, strings.Trim(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building openbao request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", b.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openbao %s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// errorBody renders the status and the errors array OpenBao returns.
+func errorBody(resp *http.Response) string {
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && len(body.Errors) > 0 {
+		return fmt.Sprintf("HTTP %d: %s", resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+	return fmt.Sprintf("HTTP %d", resp.StatusCode)
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// fakeKV serves the KV version 2 data endpoints of one mount from memory.
+type fakeKV struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+}
+
+func (f *fakeKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "root" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	const prefix = "/v1/secret/data/"
+	if len(r.URL.Path) <= len(prefix) || r.URL.Path[:len(prefix)] != prefix {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	path := r.URL.Path[len(prefix):]
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	case http.MethodPost:
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.secrets[path] = body.Data
+		_, _ = w.Write([]byte(`{"data":{"version":1}}`))
+	}
+}
+
+func newTestOpenBao(t *testing.T, token string) (*OpenBao, *fakeKV) {
+	kv := &fakeKV{secrets: map[string]map[string]string{}}
+	srv := httptest.NewServer(kv)
+	t.Cleanup(srv.Close)
+	return NewOpenBao(config.OpenBaoConfig{
+		Address: srv.URL + "/",
+		Token:   token,
+		Mount:   "secret",
+		Timeout: 5 * time.Second,
+	}), kv
+}
+
+func TestOpenBao_WriteThenRead(t *testing.T) {
+	t.Parallel()
+	bao, kv := newTestOpenBao(t, "root")
+	ctx := context.Background()
+
+	_, err := bao.Read(ctx, "arc/postgres/reasoner")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	want := map[string]string{"username": "reasoner", "password": "s3cret"}
+	require.NoError(t, bao.Write(ctx, "/arc/postgres/reasoner/", want))
+	assert.Equal(t, want, kv.secrets["arc/postgres/reasoner"])
+
+	got, err := bao.Read(ctx, "arc/postgres/reasoner")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestOpenBao_ErrorsIncludeServerMessage(t *testing.T) {
+	t.Parallel()
+	bao, _ := newTestOpenBao(t, "wrong")
+
+	_, err := bao.Read(context.Background(), "arc/postgres/reasoner")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.Contains(t, err.Error(), "HTTP 403: permission denied")
+
+	err = bao.Write(context.Background(), "arc/postgres/reasoner", map[string]string{"password": "x"})
+	assert.ErrorContains(t, err, "permission denied")
+}
@@ -0,0 +1,284 @@
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed schema.sql
+var schemaSQL string
+
+// lockKey is the transaction-scoped pg_advisory_xact_lock key held while a
+// seed is applied so concurrent runs cannot apply it twice.
+const lockKey int64 = 0x7365656473 // "seeds"
+
+// Result status values.
+const (
+	StatusApplied = "applied"
+	StatusSkipped = "skipped"
+)
+
+// Conn is a single database session; *pgx.Conn and *pgxpool.Conn satisfy
+// it. Each seed runs in a transaction opened with BEGIN on it.
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Objects uploads seed objects.
+type Objects interface {
+	Put(ctx context.Context, bucket, key string, data []byte, contentType string) error
+}
+
+// Options controls Apply. Force applies seeds that were already applied or
+// whose tables already have rows; rows that conflict with existing ones are
+// skipped and objects are overwritten.
+type Options struct {
+	Force bool
+}
+
+// Result reports what Apply did with one seed.
+type Result struct {
+	Seed    string `json:"seed"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Rows    int64  `json:"rows"`
+	Objects int    `json:"objects"`
+}
+
+// State is the recorded state of one seed. Changed is set when the seed was
+// applied from a different version of its files.
+type State struct {
+	Seed      string     `json:"seed"`
+	Source    string     `json:"source"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	Changed   bool       `json:"changed,omitempty"`
+}
+
+// Applier applies seeds over one connection. objects may be nil when no seed
+// has objects.
+type Applier struct {
+	conn    Conn
+	objects Objects
+}
+
+// NewApplier returns an Applier writing rows through conn and objects
+// through objects.
+func NewApplier(conn Conn, objects Objects) *Applier {
+	return &Applier{conn: conn, objects: objects}
+}
+
+// Status reads the recorded state of every seed without changing the
+// database.
+func (a *Applier) Status(ctx context.Context, seeds []Seed) ([]State, error) {
+	out := make([]State, 0, len(seeds))
+	for _, s := range seeds {
+		st := State{Seed: s.Name, Source: s.Source}
+		appliedAt, checksum, err := a.recorded(ctx, s.Name)
+		if err != nil {
+			return nil, err
+		}
+		if appliedAt != nil {
+			st.Applied, st.AppliedAt, st.Changed = true, appliedAt, checksum != s.Checksum
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// Apply applies each seed in order, stopping at the first failure. It
+// returns the results of the seeds it got through.
+func (a *Applier) Apply(ctx context.Context, seeds []Seed, opts Options) ([]Result, error) {
+	if _, err := a.conn.Exec(ctx, schemaSQL); err != nil {
+		return nil, fmt.Errorf("creating cortex.seeds: %w", err)
+	}
+	results := make([]Result, 0, len(seeds))
+	for _, s := range seeds {
+		r, err := a.apply(ctx, s, opts)
+		if err != nil {
+			return results, fmt.Errorf("seed %s: %w", s.Name, err)
+		}
+		slog.Info("seed "+r.Status, "seed", s.Name, "reason", r.Reason, "rows", r.Rows, "objects", r.Objects)
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// apply runs one seed in its own transaction. Objects are uploaded before
+// the commit so a failed upload leaves the seed unrecorded.
+func (a *Applier) apply(ctx context.Context, s Seed, opts Options) (Result, error) {
+	result := Result{Seed: s.Name, Status: StatusSkipped}
+	if len(s.Objects) > 0 && a.objects == nil {
+		return result, errors.New("seed has objects but no object store is configured")
+	}
+
+	if _, err := a.conn.Exec(ctx, "BEGIN"); err != nil {
+		return result, fmt.Errorf("begin: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			a.conn.Exec(context.WithoutCancel(ctx), "ROLLBACK") //nolint:errcheck,gosec
+		}
+	}()
+	if _, err := a.conn.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", lockKey); err != nil {
+		return result, fmt.Errorf("acquiring seed lock: %w", err)
+	}
+
+	appliedAt, checksum, err := a.recorded(ctx, s.Name)
+	if err != nil {
+		return result, err
+	}
+	if appliedAt != nil && !opts.Force {
+		result.Reason = "applied " + appliedAt.UTC().Format(time.RFC3339)
+		if checksum != s.Checksum {
+			result.Reason += " from different seed files; apply with force to reapply"
+		}
+		return result, nil
+	}
+	if !opts.Force {
+		for _, t := range s.Tables {
+			var hasRows bool
+			if err := a.conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM "+tableIdent(t.Table)+")").Scan(&hasRows); err != nil {
+				return result, fmt.Errorf("checking %s: %w", t.Table, err)
+			}
+			if hasRows {
+				result.Reason = t.Table + " already has rows"
+				return result, nil
+			}
+		}
+	}
+
+	for _, t := range s.Tables {
+		for i, row := range t.Rows {
+			sql, args, err := insertSQL(t.Table, row)
+			if err != nil {
+				return result, fmt.Errorf("%s rows[%d]: %w", t.Table, i, err)
+			}
+			tag, err := a.conn.Exec(ctx, sql, args...)
+			if err != nil {
+				return result, fmt.Errorf("inserting into %s: %w", t.Table, err)
+			}
+			result.Rows += tag.RowsAffected()
+		}
+	}
+	for _, o := range s.Objects {
+		contentType := o.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if err := a.objects.Put(ctx, o.Bucket, o.Key, o.data, contentType); err != nil {
+			return result, fmt.Errorf("uploading %s/%s: %w", o.Bucket, o.Key, err)
+		}
+		result.Objects++
+	}
+
+	_, err = a.conn.Exec(ctx, `
+		INSERT INTO cortex.seeds (name, checksum, row_count, object_count) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, row_count = EXCLUDED.row_count,
+			object_count = EXCLUDED.object_count, applied_at = now()`,
+		s.Name, s.Checksum, result.Rows, result.Objects)
+	if err != nil {
+		return result, fmt.Errorf("recording seed: %w", err)
+	}
+	if _, err := a.conn.Exec(ctx, "COMMIT"); err != nil {
+		return result, fmt.Errorf("commit: %w", err)
+	}
+	committed = true
+	result.Status = StatusApplied
+	return result, nil
+}
+
+// recorded returns when name was applied and its checksum at the time, or a
+// nil time when it has not been applied or cortex.seeds does not exist yet.
+func (a *Applier) recorded(ctx context.Context, name string) (*time.Time, string, error) {
+	var appliedAt time.Time
+	var checksum string
+	err := a.conn.QueryRow(ctx, "SELECT applied_at, checksum FROM cortex.seeds WHERE name = $1", name).Scan(&appliedAt, &checksum)
+	if errors.Is(err, pgx.ErrNoRows) || isUndefinedTable(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("reading cortex.seeds: %w", err)
+	}
+	return &appliedAt, checksum, nil
+}
+
+// insertSQL builds an INSERT for one row with its columns in name order.
+func insertSQL(table string, row map[string]any) (string, []any, error) {
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		cols = append(cols, col)
+	}
+	slices.Sort(cols)
+
+	idents := make([]string, len(cols))
+	params := make([]string, len(cols))
+	args := make([]any, len(cols))
+	for i, col := range cols {
+		v, err := sqlValue(row[col])
+		if err != nil {
+			return "", nil, fmt.Errorf("column %s: %w", col, err)
+		}
+		idents[i] = pgx.Identifier{col}.Sanitize()
+		params[i] = "$" + strconv.Itoa(i+1)
+		args[i] = v
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+		tableIdent(table), strings.Join(idents, ", "), strings.Join(params, ", ")), args, nil
+}
+
+// sqlValue converts a decoded YAML or JSON value to a query argument. pgx
+// sends strings in text format for any column type, so the server does the
+// conversion.
+func sqlValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []any, map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", v)
+}
+
+func tableIdent(table string) string {
+	schema, name, _ := splitTable(table)
+	return pgx.Identifier{schema, name}.Sanitize()
+}
+
+// isUndefinedTable reports SQLSTATE 42P01, raised when cortex.seeds has not
+// been created.
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
@@ -0,0 +1,240 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn records statements and answers the cortex.seeds lookup and the
+// emptiness checks from its fields.
+type fakeConn struct {
+	applied  map[string]string // seed name -> recorded checksum
+	nonEmpty map[string]bool   // quoted table -> has rows
+	failOn   string
+
+	execs []string
+	args  [][]any
+}
+
+type fakeRow struct {
+	vals []any
+	err  error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *bool:
+			*d = r.vals[i].(bool)
+		case *string:
+			*d = r.vals[i].(string)
+		case *time.Time:
+			*d = r.vals[i].(time.Time)
+		default:
+			return fmt.Errorf("scan: unsupported destination %T", d)
+		}
+	}
+	return nil
+}
+
+func (f *fakeConn) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if f.failOn != "" && strings.Contains(sql, f.failOn) {
+		return pgconn.CommandTag{}, errors.New("boom")
+	}
+	f.execs = append(f.execs, sql)
+	f.args = append(f.args, args)
+	if strings.HasPrefix(sql, "INSERT INTO \"") {
+		return pgconn.NewCommandTag("INSERT 0 1"), nil
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (f *fakeConn) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "FROM cortex.seeds") {
+		checksum, ok := f.applied[args[0].(string)]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{vals: []any{time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), checksum}}
+	}
+	for table, hasRows := range f.nonEmpty {
+		if strings.Contains(sql, table) {
+			return fakeRow{vals: []any{hasRows}}
+		}
+	}
+	return fakeRow{vals: []any{false}}
+}
+
+// statements returns the recorded statements with the schema DDL and
+// transaction control left out.
+func (f *fakeConn) statements() []string {
+	var out []string
+	for _, sql := range f.execs {
+		if sql == schemaSQL || sql == "BEGIN" || sql == "COMMIT" || sql == "ROLLBACK" || strings.Contains(sql, "pg_advisory_xact_lock") {
+			continue
+		}
+		out = append(out, strings.Join(strings.Fields(sql), " "))
+	}
+	return out
+}
+
+type fakeObjects struct {
+	put map[string]string
+	err error
+}
+
+func (o *fakeObjects) Put(_ context.Context, bucket, key string, data []byte, contentType string) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.put[bucket+"/"+key] = contentType + ":" + string(data)
+	return nil
+}
+
+func testSeed(t *testing.T) Seed {
+	t.Helper()
+	s, err := Parse([]byte(`
+name: sample
+tables:
+  - table: reasoner.vector_stores
+    rows:
+      - {id: vs-1, name: Sample, file_count: 2, meta: {a: 1}, embedding: [0.5, 1], archived: false, note: null}
+objects:
+  - {bucket: files, key: k1, content: hello, content_type: text/plain}
+`))
+	require.NoError(t, err)
+	return s
+}
+
+func TestApply_EmptyTables(t *testing.T) {
+	t.Parallel()
+	conn := &fakeConn{}
+	objects := &fakeObjects{put: map[string]string{}}
+	s := testSeed(t)
+
+	results, err := NewApplier(conn, objects).Apply(context.Background(), []Seed{s}, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []Result{{Seed: "sample", Status: StatusApplied, Rows: 1, Objects: 1}}, results)
+	assert.Equal(t, map[string]string{"files/k1": "text/plain:hello"}, objects.put)
+
+	assert.Equal(t, schemaSQL, conn.execs[0])
+	assert.Equal(t, "BEGIN", conn.execs[1])
+	assert.Equal(t, "COMMIT", conn.execs[len(conn.execs)-1])
+	stmts := conn.statements()
+	require.Len(t, stmts, 2)
+	assert.Equal(t, `INSERT INTO "reasoner"."vector_stores" ("archived", "embedding", "file_count", "id", "meta", "name", "note") `+
+		`VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING`, stmts[0])
+	assert.Equal(t, []any{"false", "[0.5,1]", "2", "vs-1", `{"a":1}`, "Sample", nil}, conn.args[3])
+	assert.Contains(t, stmts[1], "INSERT INTO cortex.seeds")
+	assert.Equal(t, []any{"sample", s.Checksum, int64(1), 1}, conn.args[4])
+}
+
+func TestApply_Skips(t *testing.T) {
+	t.Parallel()
+	s := testSeed(t)
+	cases := map[string]struct {
+		conn   *fakeConn
+		reason string
+	}{
+		"already applied": {
+			conn:   &fakeConn{applied: map[string]string{"sample": s.Checksum}},
+			reason: "applied 2026-10-01T09:00:00Z",
+		},
+		"seed changed": {
+			conn:   &fakeConn{applied: map[string]string{"sample": "sha256:old"}},
+			reason: "applied 2026-10-01T09:00:00Z from different seed files; apply with force to reapply",
+		},
+		"table has rows": {
+			conn:   &fakeConn{nonEmpty: map[string]bool{`"reasoner"."vector_stores"`: true}},
+			reason: "reasoner.vector_stores already has rows",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			objects := &fakeObjects{put: map[string]string{}}
+			results, err := NewApplier(tc.conn, objects).Apply(context.Background(), []Seed{s}, Options{})
+			require.NoError(t, err)
+			assert.Equal(t, []Result{{Seed: "sample", Status: StatusSkipped, Reason: tc.reason}}, results)
+			assert.Empty(t, tc.conn.statements())
+			assert.Equal(t, "ROLLBACK", tc.conn.execs[len(tc.conn.execs)-1])
+			assert.Empty(t, objects.put)
+		})
+	}
+}
+
+func TestApply_Force(t *testing.T) {
+	t.Parallel()
+	s := testSeed(t)
+	conn := &fakeConn{
+		applied:  map[string]string{"sample": s.Checksum},
+		nonEmpty: map[string]bool{`"reasoner"."vector_stores"`: true},
+	}
+	objects := &fakeObjects{put: map[string]string{}}
+
+	results, err := NewApplier(conn, objects).Apply(context.Background(), []Seed{s}, Options{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, StatusApplied, results[0].Status)
+	assert.Len(t, conn.statements(), 2)
+	assert.Len(t, objects.put, 1)
+}
+
+func TestApply_FailureRollsBack(t *testing.T) {
+	t.Parallel()
+	s := testSeed(t)
+	conn := &fakeConn{}
+	objects := &fakeObjects{err: errors.New("bucket unreachable")}
+
+	_, err := NewApplier(conn, objects).Apply(context.Background(), []Seed{s}, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "seed sample: uploading files/k1: bucket unreachable")
+	assert.Equal(t, "ROLLBACK", conn.execs[len(conn.execs)-1])
+	for _, sql := range conn.execs {
+		assert.NotContains(t, sql, "cortex.seeds (name")
+	}
+
+	_, err = NewApplier(&fakeConn{}, nil).Apply(context.Background(), []Seed{s}, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no object store is configured")
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+	s := testSeed(t)
+	other := Seed{Name: "other", Source: "other.yaml"}
+	conn := &fakeConn{applied: map[string]string{"sample": "sha256:old"}}
+
+	states, err := NewApplier(conn, nil).Status(context.Background(), []Seed{s, other})
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	assert.True(t, states[0].Applied)
+	assert.True(t, states[0].Changed)
+	assert.Equal(t, State{Seed: "other", Source: "other.yaml"}, states[1])
+	assert.Empty(t, conn.execs)
+}
+
+func TestStatus_NoSeedsTable(t *testing.T) {
+	t.Parallel()
+	conn := &undefinedTableConn{}
+	states, err := NewApplier(conn, nil).Status(context.Background(), []Seed{{Name: "sample"}})
+	require.NoError(t, err)
+	assert.False(t, states[0].Applied)
+}
+
+type undefinedTableConn struct{ fakeConn }
+
+func (*undefinedTableConn) QueryRow(context.Context, string, ...any) pgx.Row {
+	return fakeRow{err: &pgconn.PgError{Code: "42P01"}}
+}
@@ -0,0 +1,49 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"arc-framework/cortex/internal/config"
+)
+
+// S3Objects uploads seed objects to the arc-storage S3 API and creates
+// buckets that do not exist yet.
+type S3Objects struct {
+	client *minio.Client
+	region string
+}
+
+// NewS3Objects returns an Objects for the store described by cfg. No
+// request is made until the first upload.
+func NewS3Objects(cfg config.StorageConfig) (*S3Objects, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating s3 client for %s: %w", cfg.Endpoint, err)
+	}
+	return &S3Objects{client: client, region: cfg.Region}, nil
+}
+
+func (o *S3Objects) Put(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	exists, err := o.client.BucketExists(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("checking bucket %s: %w", bucket, err)
+	}
+	if !exists {
+		if err := o.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: o.region}); err != nil {
+			return fmt.Errorf("creating bucket %s: %w", bucket, err)
+		}
+	}
+	_, err = o.client.PutObject(ctx, bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
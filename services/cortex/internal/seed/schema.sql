@@ -0,0 +1,11 @@
+-- cortex.seeds records each seed applied to this environment. checksum
+-- covers the seed file and its object files at the time it was applied.
+CREATE SCHEMA IF NOT EXISTS cortex;
+
+CREATE TABLE IF NOT EXISTS cortex.seeds (
+    name         TEXT        PRIMARY KEY,
+    checksum     TEXT        NOT NULL,
+    row_count    BIGINT      NOT NULL DEFAULT 0,
+    object_count INTEGER     NOT NULL DEFAULT 0,
+    applied_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
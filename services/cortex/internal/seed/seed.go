@@ -0,0 +1,232 @@
+// Package seed loads declarative sample data into a fresh environment: rows
+// for Postgres tables and, optionally, objects for the arc-storage S3 API.
+//
+// A seed is one YAML or JSON file. It is applied in a single transaction and
+// only when every table it writes to is empty, unless forced, and it is
+// recorded in cortex.seeds so it runs once per environment. Object files are
+// resolved relative to the seed file.
+//
+// The reasoner sample seed is embedded in this package; it is only applied
+// when bootstrap.postgres.seeds.embedded is set. Services add their own
+// through bootstrap.postgres.seeds.files.
+package seed
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"arc-framework/cortex/internal/archive"
+	"arc-framework/cortex/internal/config"
+)
+
+//go:embed seeds
+var embedded embed.FS
+
+// Seed is one seed file.
+type Seed struct {
+	Name    string      `yaml:"name"`
+	Tables  []TableRows `yaml:"tables"`
+	Objects []Object    `yaml:"objects"`
+
+	// Source is the file the seed was read from. Checksum covers that file
+	// and every object file it references, so editing either is noticed.
+	Source   string `yaml:"-"`
+	Checksum string `yaml:"-"`
+}
+
+// TableRows lists rows for one schema-qualified table. Each row maps column
+// names to values; columns left out take their defaults. Scalars are sent
+// as text and cast by the server, and lists and maps as JSON, which suits
+// json, jsonb and pgvector columns.
+type TableRows struct {
+	Table string           `yaml:"table"`
+	Rows  []map[string]any `yaml:"rows"`
+}
+
+// Object is uploaded to Bucket under Key. Its body is File, relative to the
+// seed file, or the inline Content. ContentType defaults to
+// application/octet-stream.
+type Object struct {
+	Bucket      string `yaml:"bucket"`
+	Key         string `yaml:"key"`
+	File        string `yaml:"file"`
+	Content     string `yaml:"content"`
+	ContentType string `yaml:"content_type"`
+
+	data []byte
+}
+
+// Embedded returns the seeds compiled into Cortex, ordered by file name.
+func Embedded() ([]Seed, error) {
+	return readDir(embedded, "seeds")
+}
+
+// Load returns the seeds selected by cfg: the embedded ones when enabled,
+// followed by each configured file. A directory entry adds every seed file
+// directly inside it, ordered by name. A seed name declared twice is an
+// error.
+func Load(cfg config.PostgresSeedsConfig) ([]Seed, error) {
+	var out []Seed
+	if cfg.Embedded {
+		seeds, err := Embedded()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, seeds...)
+	}
+	for _, p := range cfg.Files {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading seed: %w", err)
+		}
+		var seeds []Seed
+		if info.IsDir() {
+			seeds, err = readDir(os.DirFS(p), ".")
+		} else {
+			var s Seed
+			s, err = readFile(os.DirFS(filepath.Dir(p)), filepath.Base(p))
+			seeds = []Seed{s}
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, seeds...)
+	}
+
+	seen := map[string]string{}
+	for _, s := range out {
+		if prev, ok := seen[s.Name]; ok {
+			return nil, fmt.Errorf("seed %s declared by both %s and %s", s.Name, prev, s.Source)
+		}
+		seen[s.Name] = s.Source
+	}
+	return out, nil
+}
+
+// readDir reads every .yaml, .yml and .json file directly inside dir.
+func readDir(fsys fs.FS, dir string) ([]Seed, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading seeds: %w", err)
+	}
+	var out []Seed
+	for _, e := range entries {
+		switch path.Ext(e.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if e.IsDir() {
+			continue
+		}
+		s, err := readFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// readFile parses name and reads the object files it references.
+func readFile(fsys fs.FS, name string) (Seed, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Seed{}, fmt.Errorf("reading seed: %w", err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return Seed{}, fmt.Errorf("seed %s: %w", name, err)
+	}
+	s.Source = name
+
+	digest := archive.NewDigest()
+	digest.Write(data) //nolint:errcheck,gosec
+	for i := range s.Objects {
+		o := &s.Objects[i]
+		if o.File == "" {
+			continue
+		}
+		if o.data, err = fs.ReadFile(fsys, path.Join(path.Dir(name), o.File)); err != nil {
+			return Seed{}, fmt.Errorf("seed %s object %s: %w", s.Name, o.Key, err)
+		}
+		digest.Write(o.data) //nolint:errcheck,gosec
+	}
+	s.Checksum = digest.Sum()
+	return s, nil
+}
+
+// Parse decodes one YAML or JSON seed and checks it is complete. Unknown
+// fields are rejected so a misspelled key is not silently ignored. Object
+// files are not read; Load does that.
+func Parse(data []byte) (Seed, error) {
+	var s Seed
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return Seed{}, err
+	}
+	for i := range s.Objects {
+		if s.Objects[i].File == "" {
+			s.Objects[i].data = []byte(s.Objects[i].Content)
+		}
+	}
+	digest := archive.NewDigest()
+	digest.Write(data) //nolint:errcheck,gosec
+	s.Checksum = digest.Sum()
+	return s, s.validate()
+}
+
+func (s Seed) validate() error {
+	var errs []error
+	if s.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if len(s.Tables) == 0 && len(s.Objects) == 0 {
+		errs = append(errs, errors.New("at least one table or object is required"))
+	}
+	for i, t := range s.Tables {
+		if _, _, ok := splitTable(t.Table); !ok {
+			errs = append(errs, fmt.Errorf("tables[%d]: table must be schema.table, got %q", i, t.Table))
+			continue
+		}
+		if len(t.Rows) == 0 {
+			errs = append(errs, fmt.Errorf("table %s: rows are required", t.Table))
+		}
+		for j, row := range t.Rows {
+			if len(row) == 0 {
+				errs = append(errs, fmt.Errorf("table %s rows[%d]: at least one column is required", t.Table, j))
+			}
+			for col := range row {
+				if col == "" {
+					errs = append(errs, fmt.Errorf("table %s rows[%d]: empty column name", t.Table, j))
+				}
+			}
+		}
+	}
+	for i, o := range s.Objects {
+		if o.Bucket == "" || o.Key == "" {
+			errs = append(errs, fmt.Errorf("objects[%d]: bucket and key are required", i))
+		}
+		if (o.File == "") == (o.Content == "") {
+			errs = append(errs, fmt.Errorf("objects[%d]: exactly one of file and content is required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitTable splits a schema-qualified table name.
+func splitTable(name string) (schema, table string, ok bool) {
+	schema, table, ok = strings.Cut(name, ".")
+	return schema, table, ok && schema != "" && table != "" && !strings.Contains(table, ".")
+}
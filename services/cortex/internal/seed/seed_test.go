@@ -0,0 +1,102 @@
+package seed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"arc-framework/cortex/internal/config"
+)
+
+func TestEmbedded_ReasonerSample(t *testing.T) {
+	t.Parallel()
+	seeds, err := Embedded()
+	require.NoError(t, err)
+	require.Len(t, seeds, 1)
+
+	s := seeds[0]
+	assert.Equal(t, "reasoner-sample", s.Name)
+	require.Len(t, s.Objects, 1)
+	obj := s.Objects[0]
+	assert.Equal(t, "reasoner-files", obj.Bucket)
+	// knowledge_files.bytes must match the object the row points at.
+	files := s.Tables[1]
+	assert.Equal(t, "reasoner.knowledge_files", files.Table)
+	assert.Equal(t, obj.Key, files.Rows[0]["minio_key"])
+	assert.Equal(t, len(obj.data), files.Rows[0]["bytes"])
+}
+
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"missing name":       "tables: [{table: a.b, rows: [{x: 1}]}]",
+		"nothing to seed":    "name: s",
+		"unqualified table":  "name: s\ntables: [{table: b, rows: [{x: 1}]}]",
+		"no rows":            "name: s\ntables: [{table: a.b}]",
+		"empty row":          "name: s\ntables: [{table: a.b, rows: [{}]}]",
+		"object without key": "name: s\nobjects: [{bucket: b, content: x}]",
+		"file and content":   "name: s\nobjects: [{bucket: b, key: k, file: f, content: x}]",
+		"unknown field":      "name: s\ntable: [{table: a.b, rows: [{x: 1}]}]",
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(body))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+}
+
+func TestLoad_FilesAndDirectories(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "one.yaml"), "name: one\nobjects: [{bucket: b, key: k, file: data/one.txt}]")
+	writeFile(t, filepath.Join(dir, "data", "one.txt"), "hello")
+	writeFile(t, filepath.Join(dir, "more", "two.json"), `{"name": "two", "tables": [{"table": "a.b", "rows": [{"x": 1}]}]}`)
+	writeFile(t, filepath.Join(dir, "more", "README.md"), "ignored")
+
+	seeds, err := Load(config.PostgresSeedsConfig{Files: []string{filepath.Join(dir, "one.yaml"), filepath.Join(dir, "more")}})
+	require.NoError(t, err)
+	require.Len(t, seeds, 2)
+	assert.Equal(t, "one", seeds[0].Name)
+	assert.Equal(t, []byte("hello"), seeds[0].Objects[0].data)
+	assert.Equal(t, "two", seeds[1].Name)
+	assert.Equal(t, "two.json", seeds[1].Source)
+
+	// Editing an object file changes the checksum of the seed using it.
+	before := seeds[0].Checksum
+	writeFile(t, filepath.Join(dir, "data", "one.txt"), "hello again")
+	seeds, err = Load(config.PostgresSeedsConfig{Files: []string{filepath.Join(dir, "one.yaml")}})
+	require.NoError(t, err)
+	assert.NotEqual(t, before, seeds[0].Checksum)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "name: dup\nobjects: [{bucket: b, key: k, content: x}]")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "name: dup\nobjects: [{bucket: b, key: k, content: y}]")
+	writeFile(t, filepath.Join(dir, "missing.yaml"), "name: m\nobjects: [{bucket: b, key: k, file: nope.txt}]")
+
+	cases := map[string][]string{
+		"duplicate name":      {filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")},
+		"missing object file": {filepath.Join(dir, "missing.yaml")},
+		"missing seed":        {filepath.Join(dir, "nope.yaml")},
+	}
+	for name, files := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Load(config.PostgresSeedsConfig{Files: files})
+			assert.Error(t, err)
+		})
+	}
+}
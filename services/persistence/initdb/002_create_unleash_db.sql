@@ -2,4 +2,5 @@
 -- Unleash's DATABASE_URL points to this database; migrations run automatically on startup.
 -- This file runs on Oracle's first boot only (Postgres skips initdb if data dir exists).
 -- To recreate: make oracle-nuke && make oracle-up
+-- Cortex bootstrap also creates it when missing (bootstrap.postgres.databases).
 CREATE DATABASE unleash;